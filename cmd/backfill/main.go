@@ -0,0 +1,112 @@
+// Command backfill indexes every document of a Firestore collection into
+// Elasticsearch, for populating a new index or applying a mapping change.
+//
+//	backfill [-config config.yaml] [-collection documents] [-index name]
+//	         [-page-size 500] [-rate 1000] [-checkpoint path] [-restart]
+//
+// Progress is checkpointed after every page; running the command again with
+// the same checkpoint resumes where an interrupted run stopped.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+	"github.com/shubhsaxena/high-scale-search/internal/firestore"
+	"github.com/shubhsaxena/high-scale-search/internal/indexing"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+func main() {
+	configPath := flag.String("config", "config.yaml", "Path to configuration file")
+	opts := indexing.BackfillOptions{}
+	flag.StringVar(&opts.Collection, "collection", "documents", "Firestore collection to backfill")
+	flag.StringVar(&opts.Index, "index", "", "Index to write every document to (default: the index the live pipeline resolves)")
+	flag.IntVar(&opts.PageSize, "page-size", 500, "Documents read and bulk indexed per page")
+	flag.Float64Var(&opts.Rate, "rate", 1000, "Maximum documents per second (0 for unthrottled)")
+	flag.StringVar(&opts.CheckpointPath, "checkpoint", "backfill-checkpoint.json", "Checkpoint file for resuming (empty to disable)")
+	flag.IntVar(&opts.MaxRetries, "max-retries", 5, "Resends of items Elasticsearch pushes back on")
+	restart := flag.Bool("restart", false, "Discard the checkpoint and start from the beginning")
+	flag.Parse()
+
+	if err := run(*configPath, opts, *restart); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath string, opts indexing.BackfillOptions, restart bool) error {
+	if opts.PageSize <= 0 {
+		return fmt.Errorf("-page-size must be positive")
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if cfg.Firestore.ProjectID == "" {
+		return fmt.Errorf("firestore project_id is required for a backfill")
+	}
+
+	logger, err := observability.NewLogger(cfg.Observability.LogLevel)
+	if err != nil {
+		return fmt.Errorf("creating logger: %w", err)
+	}
+	defer logger.Sync()
+
+	if restart && opts.CheckpointPath != "" {
+		if err := os.Remove(opts.CheckpointPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing checkpoint: %w", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	pipelines, err := indexing.NewPipelines(cfg.Indexing)
+	if err != nil {
+		return fmt.Errorf("building indexing pipelines: %w", err)
+	}
+
+	esClient, err := elasticsearch.NewClient(cfg.Elasticsearch, cfg.Search, logger)
+	if err != nil {
+		return fmt.Errorf("initializing elasticsearch: %w", err)
+	}
+	defer esClient.Close()
+
+	fsClient, err := firestore.NewClient(ctx, cfg.Firestore, logger)
+	if err != nil {
+		return fmt.Errorf("initializing firestore: %w", err)
+	}
+	defer fsClient.Close()
+
+	logger.Info("backfill starting",
+		zap.String("collection", opts.Collection),
+		zap.String("index", opts.Index),
+		zap.Int("page_size", opts.PageSize),
+		zap.Float64("rate", opts.Rate),
+	)
+
+	backfiller := indexing.NewBackfiller(esClient, fsClient, opts, pipelines, logger)
+	cp, err := backfiller.Run(ctx)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(cp); encErr != nil && err == nil {
+		err = encErr
+	}
+	if errors.Is(err, context.Canceled) {
+		logger.Info("backfill interrupted, rerun to resume", zap.String("last_id", cp.LastID))
+	}
+	return err
+}
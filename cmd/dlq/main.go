@@ -0,0 +1,177 @@
+// Command dlq lists, inspects and replays dead-lettered change events.
+//
+//	dlq [-config config.yaml] list    [-reason r] [-doc id] [-since t] [-until t] [-limit n]
+//	dlq [-config config.yaml] inspect -partition p -offset o
+//	dlq [-config config.yaml] replay  [filters] [-rate n] [-dry-run]
+//
+// Results are written to stdout as JSON, one message per line for list.
+// Replays go onto the change topic, so replayed events pass through the
+// indexer's normal retry and dead-letter handling again.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/kafka"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+func main() {
+	configPath := flag.String("config", "config.yaml", "Path to configuration file")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := run(*configPath, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: dlq [-config path] list|inspect|replay [flags]\n")
+	flag.PrintDefaults()
+}
+
+func run(configPath, command string, args []string) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	logger, err := observability.NewLogger(cfg.Observability.LogLevel)
+	if err != nil {
+		return fmt.Errorf("creating logger: %w", err)
+	}
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dlq := kafka.NewDLQ(cfg.Kafka, nil, logger)
+	defer dlq.Close()
+
+	switch command {
+	case "list":
+		return list(ctx, dlq, args)
+	case "inspect":
+		return inspect(ctx, dlq, args)
+	case "replay":
+		return replay(ctx, dlq, args, logger)
+	default:
+		usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func list(ctx context.Context, dlq *kafka.DLQ, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	filter := filterFlags(fs)
+	fs.Parse(args)
+
+	f, err := filter()
+	if err != nil {
+		return err
+	}
+	messages, err := dlq.List(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for i := range messages {
+		if err := enc.Encode(&messages[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func inspect(ctx context.Context, dlq *kafka.DLQ, args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	partition := fs.Int("partition", -1, "DLQ partition")
+	offset := fs.Int64("offset", -1, "DLQ offset")
+	fs.Parse(args)
+
+	if *partition < 0 || *offset < 0 {
+		return fmt.Errorf("inspect requires -partition and -offset")
+	}
+	msg, err := dlq.Get(ctx, *partition, *offset)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(msg)
+}
+
+func replay(ctx context.Context, dlq *kafka.DLQ, args []string, logger *zap.Logger) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	filter := filterFlags(fs)
+	rate := fs.Float64("rate", 0, "Messages per second (default from config)")
+	dryRun := fs.Bool("dry-run", false, "List the messages that would be replayed without replaying them")
+	fs.Parse(args)
+
+	f, err := filter()
+	if err != nil {
+		return err
+	}
+
+	logger.Info("dlq replay starting", zap.Bool("dry_run", *dryRun))
+	status, err := dlq.Replay(ctx, kafka.ReplayRequest{
+		DLQFilter: f,
+		Target:    kafka.ReplayToTopic,
+		DryRun:    *dryRun,
+		Rate:      *rate,
+	})
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(status); encErr != nil && err == nil {
+		err = encErr
+	}
+	return err
+}
+
+// filterFlags registers the shared filter flags on fs and returns a function
+// building the filter once fs has been parsed.
+func filterFlags(fs *flag.FlagSet) func() (kafka.DLQFilter, error) {
+	reason := fs.String("reason", "", "Match a substring of the dead-letter reason")
+	docID := fs.String("doc", "", "Match a document ID")
+	since := fs.String("since", "", "Only messages dead-lettered at or after this RFC 3339 time")
+	until := fs.String("until", "", "Only messages dead-lettered before this RFC 3339 time")
+	limit := fs.Int("limit", 0, "Maximum number of messages (default 100)")
+
+	return func() (kafka.DLQFilter, error) {
+		f := kafka.DLQFilter{Reason: *reason, DocumentID: *docID, Limit: *limit}
+		for name, src := range map[string]struct {
+			value string
+			dst   *time.Time
+		}{"since": {*since, &f.Since}, "until": {*until, &f.Until}} {
+			if src.value == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, src.value)
+			if err != nil {
+				return f, fmt.Errorf("-%s must be an RFC 3339 time: %w", name, err)
+			}
+			*src.dst = t
+		}
+		return f, nil
+	}
+}
@@ -0,0 +1,220 @@
+// Command reindex moves the search indices to a new index generation without
+// downtime, behind the read and write aliases.
+//
+//	reindex [-config config.yaml] status
+//	reindex [-config config.yaml] start    [-collection documents] [-page-size 500] [-rate 1000] [-checkpoint path] [-tolerance 0.001]
+//	reindex [-config config.yaml] verify   [-tolerance 0.001]
+//	reindex [-config config.yaml] swap     [-tolerance 0.001] [-force]
+//	reindex [-config config.yaml] rollback
+//	reindex [-config config.yaml] finish   [-delete]
+//
+// start creates the new index, waits for the running servers to start
+// copying live changes into it, backfills it from Firestore and verifies the
+// document counts. Rerunning start after an interruption resumes the
+// backfill. swap moves the aliases once the counts match; rollback moves
+// them back until finish stops mirroring writes to the previous index.
+// Results are written to stdout as JSON.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+	"github.com/shubhsaxena/high-scale-search/internal/firestore"
+	"github.com/shubhsaxena/high-scale-search/internal/indexing"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+func main() {
+	configPath := flag.String("config", "config.yaml", "Path to configuration file")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := run(*configPath, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "fatal: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: reindex [-config path] status|start|verify|swap|rollback|finish [flags]\n")
+	flag.PrintDefaults()
+}
+
+func run(configPath, command string, args []string) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	logger, err := observability.NewLogger(cfg.Observability.LogLevel)
+	if err != nil {
+		return fmt.Errorf("creating logger: %w", err)
+	}
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	esClient, err := elasticsearch.NewClient(cfg.Elasticsearch, cfg.Search, logger)
+	if err != nil {
+		return fmt.Errorf("initializing elasticsearch: %w", err)
+	}
+	defer esClient.Close()
+
+	r := indexing.NewReindexer(esClient, cfg.Elasticsearch, logger)
+
+	switch command {
+	case "status":
+		st, err := r.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return output(st)
+	case "start":
+		return start(ctx, cfg, esClient, r, args, logger)
+	case "verify":
+		fs := flag.NewFlagSet("verify", flag.ExitOnError)
+		tolerance := toleranceFlag(fs)
+		fs.Parse(args)
+		return verify(ctx, r, *tolerance)
+	case "swap":
+		fs := flag.NewFlagSet("swap", flag.ExitOnError)
+		tolerance := toleranceFlag(fs)
+		force := fs.Bool("force", false, "Swap even if the document counts do not match")
+		fs.Parse(args)
+		if !*force {
+			if err := verify(ctx, r, *tolerance); err != nil {
+				return err
+			}
+		}
+		st, err := r.Swap(ctx)
+		if err != nil {
+			return err
+		}
+		return output(st)
+	case "rollback":
+		st, err := r.Rollback(ctx)
+		if err != nil {
+			return err
+		}
+		return output(st)
+	case "finish":
+		fs := flag.NewFlagSet("finish", flag.ExitOnError)
+		deleteIndex := fs.Bool("delete", false, "Delete the index that was being mirrored to")
+		fs.Parse(args)
+		index, err := r.Finish(ctx, *deleteIndex)
+		if err != nil {
+			return err
+		}
+		return output(map[string]any{"mirror": index, "deleted": *deleteIndex})
+	default:
+		usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func start(ctx context.Context, cfg *config.Config, esClient *elasticsearch.Client, r *indexing.Reindexer, args []string, logger *zap.Logger) error {
+	fs := flag.NewFlagSet("start", flag.ExitOnError)
+	opts := indexing.BackfillOptions{}
+	fs.StringVar(&opts.Collection, "collection", "documents", "Firestore collection to backfill")
+	fs.IntVar(&opts.PageSize, "page-size", 500, "Documents read and bulk indexed per page")
+	fs.Float64Var(&opts.Rate, "rate", 1000, "Maximum documents per second (0 for unthrottled)")
+	fs.StringVar(&opts.CheckpointPath, "checkpoint", "reindex-checkpoint.json", "Backfill checkpoint file for resuming")
+	fs.IntVar(&opts.MaxRetries, "max-retries", 5, "Resends of items Elasticsearch pushes back on")
+	tolerance := toleranceFlag(fs)
+	fs.Parse(args)
+
+	if opts.PageSize <= 0 {
+		return fmt.Errorf("-page-size must be positive")
+	}
+	if cfg.Firestore.ProjectID == "" {
+		return fmt.Errorf("firestore project_id is required to backfill the new index")
+	}
+	pipelines, err := indexing.NewPipelines(cfg.Indexing)
+	if err != nil {
+		return fmt.Errorf("building indexing pipelines: %w", err)
+	}
+
+	st, err := r.Status(ctx)
+	if err != nil {
+		return err
+	}
+	switch st.Phase {
+	case indexing.PhaseCatchingUp:
+		opts.Index = st.Mirror
+		logger.Info("resuming reindex", zap.String("index", opts.Index))
+	case indexing.PhaseSwapped:
+		return fmt.Errorf("%w: finish or roll back the previous reindex first", indexing.ErrReindexInProgress)
+	default:
+		if opts.Index, err = r.Start(ctx); err != nil {
+			return err
+		}
+		// Servers pick up the mirror alias on their next alias refresh;
+		// changes made before then would be missed by a backfill that
+		// already read those documents.
+		if err := r.AwaitAliasRefresh(ctx); err != nil {
+			return err
+		}
+	}
+
+	fsClient, err := firestore.NewClient(ctx, cfg.Firestore, logger)
+	if err != nil {
+		return fmt.Errorf("initializing firestore: %w", err)
+	}
+	defer fsClient.Close()
+
+	cp, err := indexing.NewBackfiller(esClient, fsClient, opts, pipelines, logger).Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		logger.Info("reindex interrupted, rerun start to resume", zap.String("last_id", cp.LastID))
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info("reindex backfill complete",
+		zap.String("index", opts.Index),
+		zap.Int64("indexed", cp.Indexed),
+		zap.Int64("failed", cp.Failed),
+	)
+	return verify(ctx, r, *tolerance)
+}
+
+// verify prints the count comparison and fails if the counts do not match.
+func verify(ctx context.Context, r *indexing.Reindexer, tolerance float64) error {
+	v, err := r.Verify(ctx, tolerance)
+	if err != nil {
+		return err
+	}
+	if err := output(v); err != nil {
+		return err
+	}
+	if !v.OK {
+		return fmt.Errorf("document counts differ: %s has %d, %s has %d", v.Source, v.SourceCount, v.Target, v.TargetCount)
+	}
+	return nil
+}
+
+func toleranceFlag(fs *flag.FlagSet) *float64 {
+	return fs.Float64("tolerance", 0.001, "Allowed count difference as a fraction of the live count")
+}
+
+func output(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
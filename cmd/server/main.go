@@ -76,6 +76,42 @@ func run(configPath string) error {
 	} else {
 		defer esClient.Close()
 		logger.Info("elasticsearch client initialized")
+
+		if err := esClient.EnsureSchema(ctx); err != nil {
+			logger.Warn("elasticsearch template installation failed", zap.Error(err))
+		}
+		if drift, err := esClient.CheckDrift(ctx); err != nil {
+			logger.Warn("elasticsearch schema drift check failed", zap.Error(err))
+		} else if len(drift) > 0 {
+			logger.Warn("elasticsearch schema differs from the expected one, see /admin/schema",
+				zap.Int("differences", len(drift)),
+			)
+		}
+
+		// Follow the read/write aliases so a reindex swap takes effect
+		// without a restart; until they exist the monthly indices are used.
+		if err := esClient.RefreshAliases(ctx); err != nil {
+			logger.Warn("reading elasticsearch aliases failed, using monthly indices", zap.Error(err))
+		}
+		go esClient.WatchAliases(ctx, cfg.Elasticsearch.AliasRefreshInterval)
+	}
+
+	var esReplica *elasticsearch.Client
+	if len(cfg.Elasticsearch.Replica.Addresses) > 0 {
+		esReplica, err = elasticsearch.NewReplicaClient(cfg.Elasticsearch, cfg.Search, logger)
+		if err != nil {
+			logger.Warn("elasticsearch replica initialization failed, replica fallback will be skipped", zap.Error(err))
+		} else {
+			defer esReplica.Close()
+			logger.Info("elasticsearch replica client initialized")
+
+			// The replica follows its own aliases, which a reindex run
+			// against it moves independently of the primary's.
+			if err := esReplica.RefreshAliases(ctx); err != nil {
+				logger.Warn("reading elasticsearch replica aliases failed, using monthly indices", zap.Error(err))
+			}
+			go esReplica.WatchAliases(ctx, cfg.Elasticsearch.AliasRefreshInterval)
+		}
 	}
 
 	var chClient *clickhouse.Client
@@ -118,10 +154,55 @@ func run(configPath string) error {
 		esClient, chClient, fsClient, redisCache,
 		slowQueryDetector, cfg.Search, cfg.Elasticsearch, logger,
 	)
+	if esReplica != nil {
+		orch.SetReplica(esReplica)
+	}
+
+	// Initialize cache warming. Served searches are logged to ClickHouse so
+	// the most popular ones can be replayed into a cold cache.
+	if chClient != nil && cfg.Search.Warmer.QueryLog.Enabled {
+		queryLog := clickhouse.NewQueryLog(chClient,
+			cfg.Search.Warmer.QueryLog.BufferSize, cfg.Search.Warmer.QueryLog.FlushInterval, logger)
+		go queryLog.Run(ctx)
+		orch.SetQueryLog(queryLog)
+	}
+
+	var warmer *orchestrator.CacheWarmer
+	if cfg.Search.Warmer.Enabled {
+		source, err := orchestrator.NewQuerySource(cfg.Search.Warmer, chClient)
+		if err != nil {
+			logger.Warn("cache warming unavailable", zap.Error(err))
+		} else {
+			warmer = orchestrator.NewCacheWarmer(orch, source, cfg.Search.Warmer, logger)
+			if cfg.Search.Warmer.OnStartup {
+				if err := warmer.Start(ctx, "startup"); err != nil {
+					logger.Warn("cache warming start failed", zap.Error(err))
+				}
+			}
+			if cfg.Search.Warmer.WatchInterval > 0 {
+				go warmer.WatchCache(ctx, cfg.Search.Warmer.WatchInterval)
+			}
+		}
+	}
+
+	// Keep the last-resort static fallback lists populated: restore the
+	// snapshot first so they exist even if ClickHouse is down, then refresh.
+	var staticFallback *orchestrator.StaticFallbackManager
+	if cfg.Search.StaticFallback.Enabled {
+		staticFallback = orchestrator.NewStaticFallbackManager(orch, chClient, cfg.Search.StaticFallback, logger)
+		if err := staticFallback.LoadSnapshot(); err != nil {
+			logger.Warn("static fallback snapshot unavailable", zap.Error(err))
+		}
+		go staticFallback.Run(ctx)
+	}
 
 	// Initialize indexing pipeline
+	pipelines, err := indexing.NewPipelines(cfg.Indexing)
+	if err != nil {
+		return fmt.Errorf("building indexing pipelines: %w", err)
+	}
 	streamProcessor := indexing.NewStreamProcessor(
-		esClient, chClient, redisCache, cfg.Elasticsearch, logger,
+		esClient, chClient, redisCache, cfg.Elasticsearch, pipelines, logger,
 	)
 	defer streamProcessor.Stop()
 
@@ -133,6 +214,12 @@ func run(configPath string) error {
 		logger.Info("kafka consumer started")
 	}
 
+	// Dead-lettered events can be replayed onto the change topic or straight
+	// into the stream processor, which dead-letters them again if they are
+	// rejected.
+	dlq := kafka.NewDLQ(cfg.Kafka, streamProcessor.HandleEvent, logger)
+	defer dlq.Close()
+
 	// Initialize HTTP server
 	handler := api.NewHandler(orch, redisCache, logger)
 
@@ -146,7 +233,9 @@ func run(configPath string) error {
 	}
 	healthHandler.Register("kafka", consumer)
 
-	router := api.NewRouter(handler, healthHandler, logger)
+	adminHandler := api.NewAdminHandler(ctx, orch, redisCache, warmer, staticFallback, dlq, esClient, logger)
+
+	router := api.NewRouter(handler, healthHandler, adminHandler, cfg.Server.InternalToken, cfg.Search.Export.MaxConcurrent, logger)
 
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	server := &http.Server{
@@ -0,0 +1,447 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/cache"
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+	"github.com/shubhsaxena/high-scale-search/internal/kafka"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/orchestrator"
+)
+
+// AdminHandler serves operational endpoints under /admin. Every route is
+// restricted to internal callers by InternalOnlyMiddleware.
+type AdminHandler struct {
+	// ctx bounds background work started from admin requests, which must
+	// outlive the request but stop on shutdown.
+	ctx          context.Context
+	orchestrator *orchestrator.Orchestrator
+	cache        *cache.RedisCache
+	warmer       *orchestrator.CacheWarmer
+	fallback     *orchestrator.StaticFallbackManager
+	dlq          *kafka.DLQ
+	es           *elasticsearch.Client
+	logger       *zap.Logger
+}
+
+// NewAdminHandler creates the admin handler. warmer, fallback, dlq and es may
+// be nil when cache warming, static fallback management, the indexing
+// pipeline or Elasticsearch is unavailable.
+func NewAdminHandler(
+	ctx context.Context,
+	orch *orchestrator.Orchestrator,
+	cache *cache.RedisCache,
+	warmer *orchestrator.CacheWarmer,
+	fallback *orchestrator.StaticFallbackManager,
+	dlq *kafka.DLQ,
+	es *elasticsearch.Client,
+	logger *zap.Logger,
+) *AdminHandler {
+	return &AdminHandler{
+		ctx:          ctx,
+		orchestrator: orch,
+		cache:        cache,
+		warmer:       warmer,
+		fallback:     fallback,
+		dlq:          dlq,
+		es:           es,
+		logger:       logger,
+	}
+}
+
+// InternalOnlyMiddleware rejects requests not marked internal by
+// InternalCallerMiddleware.
+func InternalOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsInternalCaller(r.Context()) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"admin endpoints are only available to internal callers","code":"forbidden"}`))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CacheLookup shows the fresh and stale cache entries, with their keys, TTLs
+// and decoded responses, that the search request in the body maps to.
+func (h *AdminHandler) CacheLookup(w http.ResponseWriter, r *http.Request) {
+	var req models.SearchRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	lookup, err := h.orchestrator.LookupCache(r.Context(), &req)
+	if err != nil {
+		h.logger.Error("cache lookup failed", zap.Error(err))
+		h.writeError(w, http.StatusServiceUnavailable, "cache_unavailable", "Cache lookup failed")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, lookup)
+}
+
+// CachePurge deletes cached entries by query, region, category, document or
+// key prefix. With dry_run set it only reports how many would be deleted.
+func (h *AdminHandler) CachePurge(w http.ResponseWriter, r *http.Request) {
+	var req cache.PurgeRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	result, err := h.cache.Purge(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, cache.ErrInvalidPurge) {
+			h.writeError(w, http.StatusBadRequest, "invalid_purge", err.Error())
+			return
+		}
+		h.logger.Error("cache purge failed",
+			zap.String("request_id", RequestIDFromContext(r.Context())),
+			zap.Error(err),
+		)
+		if result != nil {
+			// Part of the purge went through; report how far it got.
+			h.writeJSON(w, http.StatusInternalServerError, map[string]any{
+				"error":   "Cache purge incomplete",
+				"code":    "purge_incomplete",
+				"partial": result,
+			})
+			return
+		}
+		h.writeError(w, http.StatusServiceUnavailable, "cache_unavailable", "Cache purge failed")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+// CacheStats reports key counts and hit/miss/eviction counters across the
+// Redis masters, plus this pod's L1 usage.
+func (h *AdminHandler) CacheStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.cache.Stats(r.Context())
+	if err != nil {
+		h.logger.Error("cache stats failed", zap.Error(err))
+		h.writeError(w, http.StatusServiceUnavailable, "cache_unavailable", "Cache stats unavailable")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, stats)
+}
+
+// WarmStatus reports the progress of the current or most recent cache
+// warming run.
+func (h *AdminHandler) WarmStatus(w http.ResponseWriter, r *http.Request) {
+	if h.warmer == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "warmer_unavailable", "Cache warming is disabled")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.warmer.Status())
+}
+
+// StartWarm starts a cache warming run and returns immediately.
+func (h *AdminHandler) StartWarm(w http.ResponseWriter, r *http.Request) {
+	if h.warmer == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "warmer_unavailable", "Cache warming is disabled")
+		return
+	}
+	if err := h.warmer.Start(h.ctx, "admin"); err != nil {
+		if errors.Is(err, orchestrator.ErrWarmInProgress) {
+			h.writeError(w, http.StatusConflict, "warm_in_progress", err.Error())
+			return
+		}
+		h.logger.Error("starting cache warming", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "warm_error", "Failed to start cache warming")
+		return
+	}
+	h.logger.Info("cache warming started by admin request",
+		zap.String("request_id", RequestIDFromContext(r.Context())),
+	)
+	h.writeJSON(w, http.StatusAccepted, h.warmer.Status())
+}
+
+// maxFallbackResults bounds an override list; it is served as a single page.
+const maxFallbackResults = 1000
+
+// StaticFallback lists the effective static fallback results per region and
+// whether each was overridden.
+func (h *AdminHandler) StaticFallback(w http.ResponseWriter, r *http.Request) {
+	if h.fallback == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "fallback_unavailable", "Static fallback management is disabled")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"updated_at": h.fallback.UpdatedAt(),
+		"regions":    h.fallback.Regions(),
+	})
+}
+
+// SetStaticFallback overrides a region's list with the results in the body.
+// The override survives refreshes and restarts until deleted.
+func (h *AdminHandler) SetStaticFallback(w http.ResponseWriter, r *http.Request) {
+	if h.fallback == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "fallback_unavailable", "Static fallback management is disabled")
+		return
+	}
+	region := chi.URLParam(r, "region")
+
+	var body struct {
+		Results []models.SearchResult `json:"results"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodySize)).Decode(&body); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if len(body.Results) == 0 || len(body.Results) > maxFallbackResults {
+		h.writeError(w, http.StatusBadRequest, "invalid_results", "results must contain between 1 and 1000 documents")
+		return
+	}
+	for _, res := range body.Results {
+		if res.ID == "" {
+			h.writeError(w, http.StatusBadRequest, "invalid_results", "every result needs an id")
+			return
+		}
+	}
+
+	if err := h.fallback.SetOverride(region, body.Results); err != nil {
+		// The override is live; only persisting it failed.
+		h.logger.Error("persisting static fallback override", zap.String("region", region), zap.Error(err))
+	}
+	h.logger.Info("static fallback overridden",
+		zap.String("region", region),
+		zap.Int("results", len(body.Results)),
+		zap.String("request_id", RequestIDFromContext(r.Context())),
+	)
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"region":     region,
+		"results":    body.Results,
+		"overridden": true,
+	})
+}
+
+// DeleteStaticFallback removes a region's override, restoring the list
+// generated from popularity.
+func (h *AdminHandler) DeleteStaticFallback(w http.ResponseWriter, r *http.Request) {
+	if h.fallback == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "fallback_unavailable", "Static fallback management is disabled")
+		return
+	}
+	region := chi.URLParam(r, "region")
+
+	existed, err := h.fallback.RemoveOverride(region)
+	if err != nil {
+		h.logger.Error("persisting static fallback override removal", zap.String("region", region), zap.Error(err))
+	}
+	if !existed {
+		h.writeError(w, http.StatusNotFound, "not_found", "No override for region")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DLQList lists dead-lettered messages, filtered by the reason, doc_id, since
+// and until (RFC 3339) query parameters and capped by limit.
+func (h *AdminHandler) DLQList(w http.ResponseWriter, r *http.Request) {
+	if h.dlq == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "dlq_unavailable", "DLQ management is disabled")
+		return
+	}
+	filter, err := parseDLQFilter(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid_filter", err.Error())
+		return
+	}
+
+	messages, err := h.dlq.List(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("listing dlq messages", zap.Error(err))
+		h.writeError(w, http.StatusServiceUnavailable, "dlq_unavailable", "Reading the DLQ failed")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"count":    len(messages),
+		"messages": messages,
+	})
+}
+
+// DLQMessage shows a single dead-lettered message with its decoded event.
+func (h *AdminHandler) DLQMessage(w http.ResponseWriter, r *http.Request) {
+	if h.dlq == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "dlq_unavailable", "DLQ management is disabled")
+		return
+	}
+	partition, err := strconv.Atoi(chi.URLParam(r, "partition"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid_request", "partition must be an integer")
+		return
+	}
+	offset, err := strconv.ParseInt(chi.URLParam(r, "offset"), 10, 64)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid_request", "offset must be an integer")
+		return
+	}
+
+	msg, err := h.dlq.Get(r.Context(), partition, offset)
+	if err != nil {
+		if errors.Is(err, kafka.ErrDLQMessageNotFound) {
+			h.writeError(w, http.StatusNotFound, "not_found", err.Error())
+			return
+		}
+		h.logger.Error("reading dlq message", zap.Error(err))
+		h.writeError(w, http.StatusServiceUnavailable, "dlq_unavailable", "Reading the DLQ failed")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, msg)
+}
+
+// DLQReplayStatus reports the progress of the current or most recent replay.
+func (h *AdminHandler) DLQReplayStatus(w http.ResponseWriter, r *http.Request) {
+	if h.dlq == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "dlq_unavailable", "DLQ management is disabled")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, h.dlq.Status())
+}
+
+// DLQReplay replays the messages the body selects. A dry run answers with the
+// matching messages; a real replay runs in the background at the requested
+// rate and is followed through DLQReplayStatus.
+func (h *AdminHandler) DLQReplay(w http.ResponseWriter, r *http.Request) {
+	if h.dlq == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "dlq_unavailable", "DLQ management is disabled")
+		return
+	}
+	var req kafka.ReplayRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if req.DryRun {
+		status, err := h.dlq.Replay(r.Context(), req)
+		if err != nil {
+			h.writeReplayError(w, err)
+			return
+		}
+		h.writeJSON(w, http.StatusOK, status)
+		return
+	}
+
+	if err := h.dlq.StartReplay(h.ctx, req); err != nil {
+		h.writeReplayError(w, err)
+		return
+	}
+	h.logger.Info("dlq replay started by admin request",
+		zap.String("target", req.Target),
+		zap.String("request_id", RequestIDFromContext(r.Context())),
+	)
+	h.writeJSON(w, http.StatusAccepted, h.dlq.Status())
+}
+
+func (h *AdminHandler) writeReplayError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, kafka.ErrInvalidReplay):
+		h.writeError(w, http.StatusBadRequest, "invalid_replay", err.Error())
+	case errors.Is(err, kafka.ErrReplayInProgress):
+		h.writeError(w, http.StatusConflict, "replay_in_progress", err.Error())
+	default:
+		h.logger.Error("dlq replay failed", zap.Error(err))
+		h.writeError(w, http.StatusServiceUnavailable, "dlq_unavailable", "DLQ replay failed")
+	}
+}
+
+// SchemaDrift compares the Elasticsearch templates, lifecycle policy and
+// search index mappings with what this build expects.
+func (h *AdminHandler) SchemaDrift(w http.ResponseWriter, r *http.Request) {
+	if h.es == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "elasticsearch_unavailable", "Elasticsearch is unavailable")
+		return
+	}
+	drift, err := h.es.CheckDrift(r.Context())
+	if err != nil {
+		h.logger.Error("checking schema drift", zap.Error(err))
+		h.writeError(w, http.StatusServiceUnavailable, "elasticsearch_unavailable", "Reading the Elasticsearch schema failed")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"schema_version": elasticsearch.SchemaVersion,
+		"in_sync":        len(drift) == 0,
+		"drift":          drift,
+	})
+}
+
+// IndexInventory lists the monthly indices and reindex generations with
+// their health, document count, size, write block and aliases.
+func (h *AdminHandler) IndexInventory(w http.ResponseWriter, r *http.Request) {
+	if h.es == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "elasticsearch_unavailable", "Elasticsearch is unavailable")
+		return
+	}
+	indices, err := h.es.Inventory(r.Context())
+	if err != nil {
+		h.logger.Error("listing indices", zap.Error(err))
+		h.writeError(w, http.StatusServiceUnavailable, "elasticsearch_unavailable", "Listing indices failed")
+		return
+	}
+
+	var docs, size int64
+	for _, index := range indices {
+		docs += index.Docs
+		size += index.SizeBytes
+	}
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"count":            len(indices),
+		"total_docs":       docs,
+		"total_size_bytes": size,
+		"indices":          indices,
+	})
+}
+
+// parseDLQFilter reads a DLQ filter from the query string.
+func parseDLQFilter(r *http.Request) (kafka.DLQFilter, error) {
+	q := r.URL.Query()
+	filter := kafka.DLQFilter{
+		Reason:     q.Get("reason"),
+		DocumentID: q.Get("doc_id"),
+	}
+	for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, errors.New(name + " must be an RFC 3339 time")
+			}
+			*dst = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, errors.New("limit must be a positive integer")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+func (h *AdminHandler) writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		h.logger.Error("writing json response", zap.Error(err))
+	}
+}
+
+func (h *AdminHandler) writeError(w http.ResponseWriter, status int, code, message string) {
+	h.writeJSON(w, status, map[string]string{
+		"error": message,
+		"code":  code,
+	})
+}
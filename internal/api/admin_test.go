@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/orchestrator"
+)
+
+func newTestAdminHandler() *AdminHandler {
+	return NewAdminHandler(context.Background(), nil, nil, nil, nil, nil, nil, zap.NewNop())
+}
+
+func TestInternalOnlyMiddleware(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := InternalCallerMiddleware("secret")(InternalOnlyMiddleware(next))
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"no token", "", http.StatusForbidden},
+		{"wrong token", "nope", http.StatusForbidden},
+		{"internal caller", "secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			req := httptest.NewRequest(http.MethodGet, "/admin/cache/warm", nil)
+			if tt.token != "" {
+				req.Header.Set("X-Internal-Token", tt.token)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if called != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("unexpected handler invocation: %v", called)
+			}
+		})
+	}
+}
+
+func TestAdminWarm_Disabled(t *testing.T) {
+	h := newTestAdminHandler()
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		req := httptest.NewRequest(method, "/admin/cache/warm", nil)
+		w := httptest.NewRecorder()
+
+		if method == http.MethodGet {
+			h.WarmStatus(w, req)
+		} else {
+			h.StartWarm(w, req)
+		}
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s: expected status 503, got %d", method, w.Code)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body["code"] != "warmer_unavailable" {
+			t.Errorf("%s: expected code warmer_unavailable, got %q", method, body["code"])
+		}
+	}
+}
+
+func TestAdminCache_InvalidBody(t *testing.T) {
+	h := newTestAdminHandler()
+
+	for name, serve := range map[string]http.HandlerFunc{
+		"lookup": h.CacheLookup,
+		"purge":  h.CachePurge,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/cache/"+name, strings.NewReader("{not json"))
+		w := httptest.NewRecorder()
+
+		serve(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestAdminStaticFallback_SetValidation(t *testing.T) {
+	fallback := orchestrator.NewStaticFallbackManager(&orchestrator.Orchestrator{}, nil, config.StaticFallbackConfig{}, zap.NewNop())
+	h := NewAdminHandler(context.Background(), nil, nil, nil, fallback, nil, nil, zap.NewNop())
+	router := chi.NewRouter()
+	router.Put("/admin/fallback/{region}", h.SetStaticFallback)
+	router.Delete("/admin/fallback/{region}", h.DeleteStaticFallback)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"invalid json", `{`, http.StatusBadRequest},
+		{"no results", `{"results": []}`, http.StatusBadRequest},
+		{"missing id", `{"results": [{"title": "x"}]}`, http.StatusBadRequest},
+		{"valid", `{"results": [{"id": "doc-1", "title": "x"}]}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/admin/fallback/us", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+
+	regions := fallback.Regions()
+	if r, ok := regions["us"]; !ok || !r.Overridden || r.Results[0].ID != "doc-1" {
+		t.Errorf("expected us override, got %+v", regions)
+	}
+
+	for _, want := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/fallback/us", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("expected status %d, got %d", want, w.Code)
+		}
+	}
+}
+
+func TestAdminDLQ_Disabled(t *testing.T) {
+	h := newTestAdminHandler()
+
+	for name, serve := range map[string]http.HandlerFunc{
+		"list":          h.DLQList,
+		"message":       h.DLQMessage,
+		"replay status": h.DLQReplayStatus,
+		"replay":        h.DLQReplay,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/dlq", nil)
+		w := httptest.NewRecorder()
+
+		serve(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected status 503, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestAdminElasticsearch_Disabled(t *testing.T) {
+	h := newTestAdminHandler()
+
+	for name, serve := range map[string]http.HandlerFunc{
+		"schema":  h.SchemaDrift,
+		"indices": h.IndexInventory,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/"+name, nil)
+		w := httptest.NewRecorder()
+
+		serve(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected status 503, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestParseDLQFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"all fields", "reason=mapper&doc_id=doc-1&since=2026-01-01T00:00:00Z&until=2026-02-01T00:00:00Z&limit=50", false},
+		{"bad since", "since=yesterday", true},
+		{"bad until", "until=2026-02-01", true},
+		{"zero limit", "limit=0", true},
+		{"bad limit", "limit=many", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/dlq?"+tt.query, nil)
+			filter, err := parseDLQFilter(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.name == "all fields" {
+				if filter.Reason != "mapper" || filter.DocumentID != "doc-1" || filter.Limit != 50 {
+					t.Errorf("unexpected filter %+v", filter)
+				}
+				if filter.Since.IsZero() || filter.Until.IsZero() {
+					t.Errorf("expected time bounds, got %+v", filter)
+				}
+			}
+		})
+	}
+}
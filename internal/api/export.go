@@ -0,0 +1,278 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+	"github.com/shubhsaxena/high-scale-search/internal/orchestrator"
+)
+
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatCSV    = "csv"
+
+	// Trailers sent after the body, once the outcome of the export is known.
+	exportCursorTrailer = "X-Export-Cursor"
+	exportErrorTrailer  = "X-Export-Error"
+
+	maxExportFields = 50
+
+	// exportWriteTimeout bounds each page write, replacing the server-wide
+	// WriteTimeout which is far shorter than a full export.
+	exportWriteTimeout = 30 * time.Second
+)
+
+var (
+	exportFieldPattern = regexp.MustCompile(`^[a-zA-Z0-9_.]+$`)
+
+	defaultExportFields = []string{
+		"title", "description", "category", "tags",
+		"region", "created_at", "popularity_score",
+	}
+)
+
+type exportRecord struct {
+	ID     string         `json:"id"`
+	Source map[string]any `json:"source"`
+}
+
+// Export streams every document matching a query as NDJSON or CSV. Exports
+// larger than one request allows stop early and return an X-Export-Cursor
+// trailer; passing it back as ?cursor= resumes from the same snapshot.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	requestID := RequestIDFromContext(ctx)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatNDJSON
+	}
+	if format != exportFormatNDJSON && format != exportFormatCSV {
+		h.writeError(w, http.StatusBadRequest, "invalid_format", "Parameter 'format' must be 'ndjson' or 'csv'")
+		return
+	}
+
+	var cursor *orchestrator.ExportCursor
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		c, err := orchestrator.DecodeExportCursor(token)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid_cursor", "Export cursor is malformed")
+			return
+		}
+		cursor = c
+	} else {
+		req, err := h.parseSearchRequest(r)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		if req.Query == "" {
+			h.writeError(w, http.StatusBadRequest, "missing_query", "Query parameter 'q' is required")
+			return
+		}
+		if f := r.URL.Query().Get("fields"); f != "" && len(req.Fields) == 0 {
+			req.Fields = strings.Split(f, ",")
+		}
+		cursor = &orchestrator.ExportCursor{
+			Query:   req.Query,
+			Filters: req.Filters,
+			Region:  req.Region,
+			Fields:  req.Fields,
+		}
+	}
+
+	fields, err := exportFields(cursor.Fields)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid_fields", err.Error())
+		return
+	}
+	cursor.Fields = fields
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeError(w, http.StatusInternalServerError, "streaming_unsupported", "Streaming is not supported")
+		return
+	}
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+
+	var csvWriter *csv.Writer
+	started := false
+	start := func() error {
+		started = true
+		if format == exportFormatCSV {
+			w.Header().Set("Content-Type", "text/csv")
+		} else {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Trailer", exportCursorTrailer+", "+exportErrorTrailer)
+		w.WriteHeader(http.StatusOK)
+
+		if format == exportFormatCSV {
+			csvWriter = csv.NewWriter(w)
+			return csvWriter.Write(append([]string{"id"}, fields...))
+		}
+		return nil
+	}
+
+	emit := func(hits []elasticsearch.ExportHit) error {
+		// Not every writer supports deadlines; without one the export is
+		// still bounded by the orchestrator's timeout.
+		_ = rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		var err error
+		if csvWriter != nil {
+			err = writeCSVRows(csvWriter, hits, fields)
+		} else {
+			err = writeNDJSON(w, hits)
+		}
+		if err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	next, exportErr := h.orchestrator.Export(ctx, cursor, emit)
+
+	if exportErr != nil && !started {
+		h.logger.Error("export failed",
+			zap.String("request_id", requestID),
+			zap.String("query", cursor.Query),
+			zap.Error(exportErr),
+		)
+		switch {
+		case errors.Is(exportErr, orchestrator.ErrCursorExpired):
+			h.writeError(w, http.StatusGone, "cursor_expired", "Export cursor has expired; restart the export")
+		case errors.Is(exportErr, orchestrator.ErrExportUnavailable):
+			h.writeError(w, http.StatusServiceUnavailable, "export_unavailable", "Export is not available")
+		default:
+			h.writeError(w, http.StatusInternalServerError, "export_error", "Export service temporarily unavailable")
+		}
+		return
+	}
+
+	// An export with no matches still returns a well-formed (empty) body.
+	if !started {
+		if err := start(); err != nil {
+			h.logger.Warn("writing export header failed", zap.Error(err))
+			return
+		}
+	}
+	if csvWriter != nil {
+		csvWriter.Flush()
+	}
+
+	if exportErr != nil {
+		h.logger.Error("export interrupted",
+			zap.String("request_id", requestID),
+			zap.String("query", cursor.Query),
+			zap.Error(exportErr),
+		)
+		w.Header().Set(exportErrorTrailer, "export_error")
+	}
+	if next != nil {
+		token, err := orchestrator.EncodeExportCursor(next)
+		if err != nil {
+			h.logger.Error("encoding export cursor", zap.Error(err))
+			return
+		}
+		w.Header().Set(exportCursorTrailer, token)
+	}
+}
+
+// exportFields validates the requested field list, falling back to the
+// standard searchable fields when none are given.
+func exportFields(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return defaultExportFields, nil
+	}
+	if len(fields) > maxExportFields {
+		return nil, fmt.Errorf("at most %d fields may be exported", maxExportFields)
+	}
+	for _, f := range fields {
+		if !exportFieldPattern.MatchString(f) {
+			return nil, fmt.Errorf("invalid field name %q", f)
+		}
+	}
+	return fields, nil
+}
+
+func writeNDJSON(w http.ResponseWriter, hits []elasticsearch.ExportHit) error {
+	enc := json.NewEncoder(w)
+	for _, hit := range hits {
+		if err := enc.Encode(exportRecord{ID: hit.ID, Source: hit.Source}); err != nil {
+			return fmt.Errorf("writing ndjson record: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeCSVRows(cw *csv.Writer, hits []elasticsearch.ExportHit, fields []string) error {
+	row := make([]string, len(fields)+1)
+	for _, hit := range hits {
+		row[0] = hit.ID
+		for i, f := range fields {
+			row[i+1] = csvValue(lookupField(hit.Source, f))
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("writing csv row: %w", err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// lookupField resolves a dotted path; ES returns filtered _source fields
+// nested rather than under their dotted name.
+func lookupField(source map[string]any, path string) any {
+	if v, ok := source[path]; ok {
+		return v
+	}
+	var cur any = source
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+// csvValue renders scalars as-is and encodes arrays and objects as JSON.
+func csvValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+)
+
+func TestExport_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		wantCode string
+	}{
+		{"invalid format", "/api/v1/export?q=laptop&format=xml", "invalid_format"},
+		{"missing query", "/api/v1/export?format=csv", "missing_query"},
+		{"malformed cursor", "/api/v1/export?cursor=not-a-cursor", "invalid_cursor"},
+		{"invalid field", "/api/v1/export?q=laptop&fields=title,bad%20field", "invalid_fields"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			w := httptest.NewRecorder()
+
+			h.Export(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", w.Code)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body["code"] != tt.wantCode {
+				t.Errorf("expected code %q, got %q", tt.wantCode, body["code"])
+			}
+		})
+	}
+}
+
+func TestExportFields(t *testing.T) {
+	fields, err := exportFields(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fields) != len(defaultExportFields) {
+		t.Errorf("expected default fields, got %v", fields)
+	}
+
+	if _, err := exportFields([]string{"title", "geo_point.lat"}); err != nil {
+		t.Errorf("expected dotted field to be valid, got %v", err)
+	}
+	if _, err := exportFields([]string{"title", "bad field"}); err == nil {
+		t.Error("expected error for field with a space")
+	}
+
+	tooMany := make([]string, maxExportFields+1)
+	for i := range tooMany {
+		tooMany[i] = "f"
+	}
+	if _, err := exportFields(tooMany); err == nil {
+		t.Error("expected error for too many fields")
+	}
+}
+
+func TestWriteCSVRows(t *testing.T) {
+	hits := []elasticsearch.ExportHit{
+		{
+			ID: "doc1",
+			Source: map[string]any{
+				"title":            "Laptop, 15\"",
+				"tags":             []any{"a", "b"},
+				"popularity_score": json.Number("42.5"),
+				"geo":              map[string]any{"lat": json.Number("1.5")},
+			},
+		},
+		{ID: "doc2", Source: map[string]any{}},
+	}
+
+	var sb strings.Builder
+	cw := csv.NewWriter(&sb)
+	if err := writeCSVRows(cw, hits, []string{"title", "tags", "popularity_score", "geo.lat"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(sb.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse csv output: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(records))
+	}
+	want := []string{"doc1", "Laptop, 15\"", `["a","b"]`, "42.5", "1.5"}
+	for i, v := range want {
+		if records[0][i] != v {
+			t.Errorf("column %d: expected %q, got %q", i, v, records[0][i])
+		}
+	}
+	for i, v := range records[1][1:] {
+		if v != "" {
+			t.Errorf("column %d: expected empty value for missing field, got %q", i+1, v)
+		}
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	hits := []elasticsearch.ExportHit{
+		{ID: "doc1", Source: map[string]any{"title": "a"}},
+		{ID: "doc2", Source: map[string]any{"title": "b"}},
+	}
+	if err := writeNDJSON(w, hits); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var rec exportRecord
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatalf("failed to decode line: %v", err)
+	}
+	if rec.ID != "doc2" || rec.Source["title"] != "b" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
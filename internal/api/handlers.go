@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
@@ -42,6 +43,10 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusBadRequest, "missing_query", "Query parameter 'q' is required")
 		return
 	}
+	if (req.Explain || req.Profile) && !IsInternalCaller(ctx) {
+		h.writeError(w, http.StatusForbidden, "forbidden", "explain and profile are only available to internal callers")
+		return
+	}
 	req.RequestID = requestID
 
 	resp, err := h.orchestrator.Search(ctx, req)
@@ -58,6 +63,148 @@ func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, resp)
 }
 
+// SearchStream runs a search and streams it as Server-Sent Events: hits
+// first, then facet counts, then spell suggestions, then a final done event.
+func (h *Handler) SearchStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	requestID := RequestIDFromContext(ctx)
+
+	req, err := h.parseSearchRequest(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if req.Query == "" {
+		h.writeError(w, http.StatusBadRequest, "missing_query", "Query parameter 'q' is required")
+		return
+	}
+	if req.Explain || req.Profile {
+		h.writeError(w, http.StatusBadRequest, "unsupported_option", "explain and profile are not supported for streaming search")
+		return
+	}
+	req.RequestID = requestID
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeError(w, http.StatusInternalServerError, "streaming_unsupported", "Streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	emit := func(event string, data any) error {
+		if err := writeSSEEvent(w, event, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if err := h.orchestrator.StreamSearch(ctx, req, emit); err != nil {
+		h.logger.Error("stream search failed",
+			zap.String("request_id", requestID),
+			zap.String("query", req.Query),
+			zap.Error(err),
+		)
+		// Headers are already sent, so the failure is reported in-band.
+		emit("error", map[string]string{
+			"error": "Search service temporarily unavailable",
+			"code":  "search_error",
+		})
+		return
+	}
+	emit("done", map[string]string{"request_id": requestID})
+}
+
+// writeSSEEvent writes one Server-Sent Event with a JSON payload.
+func writeSSEEvent(w io.Writer, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshaling sse event %s: %w", event, err)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// maxMultiSearchRequests bounds the fan-out a single msearch call can cause.
+const maxMultiSearchRequests = 25
+
+type multiSearchItem struct {
+	Response *models.SearchResponse `json:"response,omitempty"`
+	Error    *multiSearchError      `json:"error,omitempty"`
+}
+
+type multiSearchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// MultiSearch executes a JSON array of SearchRequests in one call and returns
+// a response or error for each, in request order.
+func (h *Handler) MultiSearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	requestID := RequestIDFromContext(ctx)
+
+	var reqs []*models.SearchRequest
+	limited := io.LimitReader(r.Body, maxRequestBodySize)
+	if err := json.NewDecoder(limited).Decode(&reqs); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if len(reqs) == 0 {
+		h.writeError(w, http.StatusBadRequest, "empty_batch", "At least one search is required")
+		return
+	}
+	if len(reqs) > maxMultiSearchRequests {
+		h.writeError(w, http.StatusBadRequest, "batch_too_large",
+			fmt.Sprintf("At most %d searches are allowed per request", maxMultiSearchRequests))
+		return
+	}
+
+	items := make([]multiSearchItem, len(reqs))
+	valid := make([]*models.SearchRequest, 0, len(reqs))
+	validIdx := make([]int, 0, len(reqs))
+	internal := IsInternalCaller(ctx)
+	for i, req := range reqs {
+		switch {
+		case req == nil || req.Query == "":
+			items[i].Error = &multiSearchError{Code: "missing_query", Message: "Field 'query' is required"}
+		case (req.Explain || req.Profile) && !internal:
+			items[i].Error = &multiSearchError{Code: "forbidden", Message: "explain and profile are only available to internal callers"}
+		default:
+			req.RequestID = requestID
+			valid = append(valid, req)
+			validIdx = append(validIdx, i)
+		}
+	}
+
+	var results []orchestrator.MultiSearchResult
+	if len(valid) > 0 {
+		results = h.orchestrator.MultiSearch(ctx, valid)
+	}
+	for j, res := range results {
+		i := validIdx[j]
+		if res.Err != nil {
+			h.logger.Error("msearch item failed",
+				zap.String("request_id", requestID),
+				zap.Int("item", i),
+				zap.String("query", reqs[i].Query),
+				zap.Error(res.Err),
+			)
+			items[i].Error = &multiSearchError{Code: "search_error", Message: "Search service temporarily unavailable"}
+			continue
+		}
+		items[i].Response = res.Response
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]any{
+		"responses": items,
+	})
+}
+
 const maxAutocompletePrefixLen = 100
 
 func (h *Handler) Autocomplete(w http.ResponseWriter, r *http.Request) {
@@ -181,6 +328,14 @@ func (h *Handler) parseSearchRequest(r *http.Request) (*models.SearchRequest, er
 		req.ForceFresh = true
 	}
 
+	if r.URL.Query().Get("explain") == "true" {
+		req.Explain = true
+	}
+
+	if r.URL.Query().Get("profile") == "true" {
+		req.Profile = true
+	}
+
 	return req, nil
 }
 
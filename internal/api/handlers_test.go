@@ -324,3 +324,155 @@ func TestMaxRequestBodySize(t *testing.T) {
 		t.Errorf("expected maxRequestBodySize 1MB, got %d", maxRequestBodySize)
 	}
 }
+
+func TestParseSearchRequest_GET_Explain(t *testing.T) {
+	h := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=laptop&explain=true", nil)
+	sr, err := h.parseSearchRequest(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !sr.Explain {
+		t.Error("expected Explain true")
+	}
+}
+
+func TestSearch_ExplainForbiddenForExternalCallers(t *testing.T) {
+	h := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=laptop&explain=true", nil)
+	rr := httptest.NewRecorder()
+
+	h.Search(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for external explain request, got %d", rr.Code)
+	}
+}
+
+func TestSearch_ProfileForbiddenForExternalCallers(t *testing.T) {
+	h := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=laptop&profile=true", nil)
+	rr := httptest.NewRecorder()
+
+	h.Search(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for external profile request, got %d", rr.Code)
+	}
+}
+
+func TestMultiSearch_InvalidBody(t *testing.T) {
+	h := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodPost, "/msearch", strings.NewReader(`{"query":"not an array"}`))
+	rr := httptest.NewRecorder()
+
+	h.MultiSearch(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for non-array body, got %d", rr.Code)
+	}
+}
+
+func TestMultiSearch_EmptyBatch(t *testing.T) {
+	h := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodPost, "/msearch", strings.NewReader(`[]`))
+	rr := httptest.NewRecorder()
+
+	h.MultiSearch(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for empty batch, got %d", rr.Code)
+	}
+}
+
+func TestMultiSearch_BatchTooLarge(t *testing.T) {
+	h := newTestHandler()
+
+	items := make([]string, maxMultiSearchRequests+1)
+	for i := range items {
+		items[i] = `{"query":"laptop"}`
+	}
+	body := "[" + strings.Join(items, ",") + "]"
+	req := httptest.NewRequest(http.MethodPost, "/msearch", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	h.MultiSearch(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for oversized batch, got %d", rr.Code)
+	}
+}
+
+func TestMultiSearch_PerItemValidationErrors(t *testing.T) {
+	h := newTestHandler()
+
+	body := `[{"query":""},{"query":"laptop","explain":true}]`
+	req := httptest.NewRequest(http.MethodPost, "/msearch", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	h.MultiSearch(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 with per-item errors, got %d", rr.Code)
+	}
+
+	var result struct {
+		Responses []multiSearchItem `json:"responses"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if len(result.Responses) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(result.Responses))
+	}
+	if result.Responses[0].Error == nil || result.Responses[0].Error.Code != "missing_query" {
+		t.Errorf("expected missing_query error for item 0, got %+v", result.Responses[0].Error)
+	}
+	if result.Responses[1].Error == nil || result.Responses[1].Error.Code != "forbidden" {
+		t.Errorf("expected forbidden error for item 1, got %+v", result.Responses[1].Error)
+	}
+}
+
+func TestWriteSSEEvent(t *testing.T) {
+	var sb strings.Builder
+
+	if err := writeSSEEvent(&sb, "results", map[string]int{"total": 3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "event: results\ndata: {\"total\":3}\n\n"
+	if sb.String() != want {
+		t.Errorf("expected %q, got %q", want, sb.String())
+	}
+}
+
+func TestSearchStream_MissingQuery(t *testing.T) {
+	h := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/search/stream", nil)
+	rr := httptest.NewRecorder()
+
+	h.SearchStream(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for missing query, got %d", rr.Code)
+	}
+}
+
+func TestSearchStream_RejectsDebugOptions(t *testing.T) {
+	h := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/search/stream?q=laptop&explain=true", nil)
+	rr := httptest.NewRecorder()
+
+	h.SearchStream(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for explain on stream, got %d", rr.Code)
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
 	"runtime/debug"
 	"time"
@@ -15,7 +16,8 @@ import (
 type contextKey string
 
 const (
-	requestIDKey contextKey = "request_id"
+	requestIDKey      contextKey = "request_id"
+	internalCallerKey contextKey = "internal_caller"
 )
 
 func RequestIDFromContext(ctx context.Context) string {
@@ -38,6 +40,29 @@ func RequestIDMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// IsInternalCaller reports whether the request was authenticated as internal
+// by InternalCallerMiddleware.
+func IsInternalCaller(ctx context.Context) bool {
+	internal, _ := ctx.Value(internalCallerKey).(bool)
+	return internal
+}
+
+// InternalCallerMiddleware marks requests carrying a matching X-Internal-Token
+// header as internal. It never rejects requests; handlers decide what internal
+// callers may do. An empty token disables internal access entirely.
+func InternalCallerMiddleware(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := r.Header.Get("X-Internal-Token")
+			if token != "" && provided != "" &&
+				subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+				r = r.WithContext(context.WithValue(r.Context(), internalCallerKey, true))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode  int
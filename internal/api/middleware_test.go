@@ -335,3 +335,37 @@ func TestCORSMiddleware_NonOptionsPassesThrough(t *testing.T) {
 		}
 	}
 }
+
+func TestInternalCallerMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		header   string
+		internal bool
+	}{
+		{"matching token", "secret", "secret", true},
+		{"wrong token", "secret", "guess", false},
+		{"missing header", "secret", "", false},
+		{"disabled when unset", "", "", false},
+		{"disabled ignores header", "", "secret", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			handler := InternalCallerMiddleware(tt.token)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = IsInternalCaller(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Internal-Token", tt.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.internal {
+				t.Errorf("IsInternalCaller = %v, want %v", got, tt.internal)
+			}
+		})
+	}
+}
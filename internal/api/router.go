@@ -8,7 +8,7 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewRouter(handler *Handler, health *HealthHandler, logger *zap.Logger) http.Handler {
+func NewRouter(handler *Handler, health *HealthHandler, admin *AdminHandler, internalToken string, exportConcurrency int, logger *zap.Logger) http.Handler {
 	r := chi.NewRouter()
 
 	// Global middleware (applied to all routes)
@@ -16,6 +16,7 @@ func NewRouter(handler *Handler, health *HealthHandler, logger *zap.Logger) http
 	r.Use(CORSMiddleware)
 	r.Use(RequestIDMiddleware)
 	r.Use(LoggingMiddleware(logger))
+	r.Use(InternalCallerMiddleware(internalToken))
 
 	// Health and metrics endpoints are registered BEFORE the rate limiter
 	// so Kubernetes probes and Prometheus scrapes are never rejected under load.
@@ -23,18 +24,51 @@ func NewRouter(handler *Handler, health *HealthHandler, logger *zap.Logger) http
 	r.Get("/readyz", health.Readiness)
 	r.Handle("/metrics", promhttp.Handler())
 
-	// Rate limiter only applies to API routes below
-	r.Group(func(r chi.Router) {
-		rl := NewRateLimiter(1000, logger)
-		r.Use(rl.Middleware)
+	// Rate limiters only apply to API routes below. Exports hold a slot for
+	// minutes rather than milliseconds, so they get their own smaller pool
+	// and can never exhaust interactive search capacity.
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			rl := NewRateLimiter(1000, logger)
+			r.Use(rl.Middleware)
 
-		// API v1
-		r.Route("/api/v1", func(r chi.Router) {
 			r.Get("/search", handler.Search)
 			r.Post("/search", handler.Search)
+			r.Get("/search/stream", handler.SearchStream)
+			r.Post("/search/stream", handler.SearchStream)
+			r.Post("/msearch", handler.MultiSearch)
 			r.Get("/autocomplete", handler.Autocomplete)
 			r.Get("/trending", handler.Trending)
 		})
+
+		r.Group(func(r chi.Router) {
+			rl := NewRateLimiter(exportConcurrency, logger)
+			r.Use(rl.Middleware)
+
+			r.Get("/export", handler.Export)
+			r.Post("/export", handler.Export)
+		})
+	})
+
+	// Admin endpoints are internal-only and not rate limited, so operators
+	// can still reach them when the search pools are saturated.
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(InternalOnlyMiddleware)
+
+		r.Post("/cache/lookup", admin.CacheLookup)
+		r.Post("/cache/purge", admin.CachePurge)
+		r.Get("/cache/stats", admin.CacheStats)
+		r.Get("/cache/warm", admin.WarmStatus)
+		r.Post("/cache/warm", admin.StartWarm)
+		r.Get("/fallback", admin.StaticFallback)
+		r.Put("/fallback/{region}", admin.SetStaticFallback)
+		r.Delete("/fallback/{region}", admin.DeleteStaticFallback)
+		r.Get("/dlq", admin.DLQList)
+		r.Get("/dlq/replay", admin.DLQReplayStatus)
+		r.Post("/dlq/replay", admin.DLQReplay)
+		r.Get("/dlq/{partition}/{offset}", admin.DLQMessage)
+		r.Get("/schema", admin.SchemaDrift)
+		r.Get("/indices", admin.IndexInventory)
 	})
 
 	return r
@@ -0,0 +1,399 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// scanBatchSize is the COUNT hint for SCAN and the number of keys deleted per
+// pipeline while purging by prefix.
+const scanBatchSize = 500
+
+// purgeablePrefixes are the key namespaces a prefix purge may target. A bare
+// or unknown prefix is rejected so a typo cannot wipe unrelated keys.
+var purgeablePrefixes = []string{"sr:", "ac:", "trend:", "fc:", "tag:", "lock:"}
+
+// ErrInvalidPurge is returned when a purge request matches nothing it could
+// safely act on.
+var ErrInvalidPurge = errors.New("invalid cache purge")
+
+// QueryTag is the tag for responses to a query, under any filters, page,
+// sort or region. It exists so operators can purge a query's responses.
+func QueryTag(query string) string {
+	return fmt.Sprintf("tag:query:%s", hashString(strings.ToLower(strings.TrimSpace(query))))
+}
+
+// CacheEntry describes one Redis entry for a search request.
+type CacheEntry struct {
+	Key       string                 `json:"key"`
+	Exists    bool                   `json:"exists"`
+	InLocal   bool                   `json:"in_local"`
+	TTLMs     int64                  `json:"ttl_ms,omitempty"`
+	SizeBytes int                    `json:"size_bytes,omitempty"`
+	CachedAt  *time.Time             `json:"cached_at,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+	Response  *models.SearchResponse `json:"response,omitempty"`
+}
+
+// CacheLookup is the fresh and stale entries a search request maps to.
+type CacheLookup struct {
+	Fresh CacheEntry `json:"fresh"`
+	Stale CacheEntry `json:"stale"`
+}
+
+// Lookup reads the fresh and stale entries for req straight from Redis,
+// without populating the L1 or counting them as hits or misses.
+func (rc *RedisCache) Lookup(ctx context.Context, req *models.SearchRequest) (*CacheLookup, error) {
+	lookup := &CacheLookup{
+		Fresh: CacheEntry{Key: rc.buildSearchKey(req)},
+		Stale: CacheEntry{Key: rc.buildStaleKey(req)},
+	}
+	entries := []*CacheEntry{&lookup.Fresh, &lookup.Stale}
+
+	getCmds := make([]*redis.StringCmd, len(entries))
+	ttlCmds := make([]*redis.DurationCmd, len(entries))
+	_, err := rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, e := range entries {
+			getCmds[i] = pipe.Get(ctx, e.Key)
+			ttlCmds[i] = pipe.PTTL(ctx, e.Key)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("cache lookup: %w", err)
+	}
+
+	for i, e := range entries {
+		if rc.local != nil {
+			e.InLocal = rc.local.peek(e.Key)
+		}
+		val, err := getCmds[i].Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cache lookup %s: %w", e.Key, err)
+		}
+		e.Exists = true
+		e.SizeBytes = len(val)
+		e.TTLMs = ttlCmds[i].Val().Milliseconds()
+
+		payload, createdAt, err := rc.codec.decode(val)
+		if err != nil {
+			e.Error = err.Error()
+			continue
+		}
+		var resp models.SearchResponse
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			e.Error = fmt.Sprintf("unmarshal: %v", err)
+			continue
+		}
+		if resp.Metadata.CachedAt == nil && !createdAt.IsZero() {
+			resp.Metadata.CachedAt = &createdAt
+		}
+		e.CachedAt = resp.Metadata.CachedAt
+		e.Response = &resp
+	}
+	return lookup, nil
+}
+
+// PurgeRequest selects cached entries to delete. Query, Region, Category and
+// DocID select search responses through their tags; Prefix selects keys in
+// one of the cache namespaces. Selectors narrow each other: only entries
+// matching all of them are purged, so {query, region} purges that query in
+// that region. Prefix alone SCANs the namespace.
+type PurgeRequest struct {
+	Query    string `json:"query,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Category string `json:"category,omitempty"`
+	DocID    string `json:"doc_id,omitempty"`
+	Prefix   string `json:"prefix,omitempty"`
+	DryRun   bool   `json:"dry_run,omitempty"`
+}
+
+// PurgeResult reports how many keys a purge deleted, or would delete for a
+// dry run.
+type PurgeResult struct {
+	Tags    []string `json:"tags,omitempty"`
+	Prefix  string   `json:"prefix,omitempty"`
+	Matched int      `json:"matched"`
+	Deleted int      `json:"deleted"`
+	DryRun  bool     `json:"dry_run"`
+}
+
+// Purge deletes the entries selected by req.
+func (rc *RedisCache) Purge(ctx context.Context, req PurgeRequest) (*PurgeResult, error) {
+	var tags []string
+	if req.Query != "" {
+		tags = append(tags, QueryTag(req.Query))
+	}
+	if req.Region != "" {
+		tags = append(tags, RegionTag(req.Region))
+	}
+	if req.Category != "" {
+		tags = append(tags, CategoryTag(req.Category))
+	}
+	if req.DocID != "" {
+		tags = append(tags, DocTag(req.DocID))
+	}
+	if len(tags) == 0 && req.Prefix == "" {
+		return nil, fmt.Errorf("%w: no selector given", ErrInvalidPurge)
+	}
+	if req.Prefix != "" && !purgeablePrefix(req.Prefix) {
+		return nil, fmt.Errorf("%w: prefix must start with one of %s", ErrInvalidPurge, strings.Join(purgeablePrefixes, ", "))
+	}
+
+	result := &PurgeResult{Tags: tags, Prefix: req.Prefix, DryRun: req.DryRun}
+
+	if len(tags) > 0 {
+		matched, deleted, err := rc.purgeTagged(ctx, tags, req.Prefix, req.DryRun)
+		result.Matched = matched
+		result.Deleted = deleted
+		if err != nil {
+			return result, err
+		}
+	} else {
+		matched, deleted, err := rc.purgePrefix(ctx, req.Prefix, req.DryRun)
+		result.Matched = matched
+		result.Deleted = deleted
+		if err != nil {
+			return result, err
+		}
+	}
+
+	observability.CachePurgedKeys.Add(float64(result.Deleted))
+	rc.logger.Info("cache purged",
+		zap.Strings("tags", tags),
+		zap.String("prefix", req.Prefix),
+		zap.Int("matched", result.Matched),
+		zap.Int("deleted", result.Deleted),
+		zap.Bool("dry_run", req.DryRun),
+	)
+	return result, nil
+}
+
+// purgeTagged deletes the keys recorded under every one of tags and starting
+// with prefix. The tag sets are intersected here rather than with SINTER,
+// which Redis Cluster refuses for sets in different hash slots.
+func (rc *RedisCache) purgeTagged(ctx context.Context, tags []string, prefix string, dryRun bool) (int, int, error) {
+	sets := make([][]string, len(tags))
+	for i, tag := range tags {
+		keys, err := rc.client.SMembers(ctx, tag).Result()
+		if err != nil {
+			return 0, 0, fmt.Errorf("cache read tag %s: %w", tag, err)
+		}
+		sets[i] = keys
+	}
+	keys := intersectKeys(sets, prefix)
+	if dryRun || len(keys) == 0 {
+		return len(keys), 0, nil
+	}
+
+	members := make([]any, len(keys))
+	for i, k := range keys {
+		members[i] = k
+	}
+	_, err := rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, k := range keys {
+			pipe.Del(ctx, k)
+		}
+		for _, tag := range tags {
+			pipe.SRem(ctx, tag, members...)
+		}
+		return nil
+	})
+	if err != nil {
+		return len(keys), 0, fmt.Errorf("cache delete: %w", err)
+	}
+	rc.publishInvalidation(ctx, keys)
+	observability.CacheTagEvictions.Add(float64(len(keys)))
+	return len(keys), len(keys), nil
+}
+
+// intersectKeys returns the keys present in every set and starting with
+// prefix, each once, in the order of the first set.
+func intersectKeys(sets [][]string, prefix string) []string {
+	if len(sets) == 0 {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, set := range sets {
+		seen := make(map[string]bool, len(set))
+		for _, k := range set {
+			if !seen[k] {
+				seen[k] = true
+				counts[k]++
+			}
+		}
+	}
+	var keys []string
+	for _, k := range sets[0] {
+		if counts[k] == len(sets) && strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+			counts[k] = 0
+		}
+	}
+	return keys
+}
+
+func purgeablePrefix(prefix string) bool {
+	for _, p := range purgeablePrefixes {
+		if strings.HasPrefix(prefix, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// purgePrefix SCANs every master for keys starting with prefix and deletes
+// them in batches. Keys are deleted one per command through the main client
+// so each reaches the node owning its slot.
+func (rc *RedisCache) purgePrefix(ctx context.Context, prefix string, dryRun bool) (int, int, error) {
+	var mu sync.Mutex
+	matched, deleted := 0, 0
+
+	err := rc.forEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		iter := node.Scan(ctx, 0, escapeGlob(prefix)+"*", scanBatchSize).Iterator()
+		batch := make([]string, 0, scanBatchSize)
+
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			n := len(batch)
+			if !dryRun {
+				_, err := rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+					for _, k := range batch {
+						pipe.Del(ctx, k)
+					}
+					return nil
+				})
+				if err != nil {
+					return fmt.Errorf("cache delete: %w", err)
+				}
+				rc.publishInvalidation(ctx, batch)
+			}
+			mu.Lock()
+			matched += n
+			if !dryRun {
+				deleted += n
+			}
+			mu.Unlock()
+			batch = make([]string, 0, scanBatchSize)
+			return nil
+		}
+
+		for iter.Next(ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) == scanBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return fmt.Errorf("cache scan: %w", err)
+		}
+		return flush()
+	})
+	return matched, deleted, err
+}
+
+// forEachMaster calls fn with each master node in cluster mode, concurrently,
+// and with the single client otherwise. SCAN only covers the node it is sent
+// to, so keyspace walks must go through this.
+func (rc *RedisCache) forEachMaster(ctx context.Context, fn func(ctx context.Context, node *redis.Client) error) error {
+	switch c := rc.client.(type) {
+	case *redis.ClusterClient:
+		return c.ForEachMaster(ctx, fn)
+	case *redis.Client:
+		return fn(ctx, c)
+	default:
+		return fmt.Errorf("unsupported redis client %T", rc.client)
+	}
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as patterns.
+func escapeGlob(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Stats summarizes the cache across all Redis masters and this pod's L1.
+type Stats struct {
+	Nodes          int         `json:"nodes"`
+	Keys           int64       `json:"keys"`
+	UsedMemory     int64       `json:"used_memory_bytes"`
+	KeyspaceHits   int64       `json:"keyspace_hits"`
+	KeyspaceMisses int64       `json:"keyspace_misses"`
+	ExpiredKeys    int64       `json:"expired_keys"`
+	EvictedKeys    int64       `json:"evicted_keys"`
+	Local          *LocalStats `json:"local,omitempty"`
+}
+
+// LocalStats describes this pod's L1.
+type LocalStats struct {
+	Entries  int   `json:"entries"`
+	Bytes    int64 `json:"bytes"`
+	MaxBytes int64 `json:"max_bytes"`
+}
+
+// Stats collects key counts and INFO counters from every master.
+func (rc *RedisCache) Stats(ctx context.Context) (*Stats, error) {
+	var mu sync.Mutex
+	stats := &Stats{}
+
+	err := rc.forEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		keys, err := node.DBSize(ctx).Result()
+		if err != nil {
+			return fmt.Errorf("cache dbsize: %w", err)
+		}
+		info, err := node.InfoMap(ctx, "memory", "stats").Result()
+		if err != nil {
+			return fmt.Errorf("cache info: %w", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		stats.Nodes++
+		stats.Keys += keys
+		stats.UsedMemory += infoInt(info, "Memory", "used_memory")
+		stats.KeyspaceHits += infoInt(info, "Stats", "keyspace_hits")
+		stats.KeyspaceMisses += infoInt(info, "Stats", "keyspace_misses")
+		stats.ExpiredKeys += infoInt(info, "Stats", "expired_keys")
+		stats.EvictedKeys += infoInt(info, "Stats", "evicted_keys")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rc.local != nil {
+		stats.Local = rc.local.stats()
+	}
+	return stats, nil
+}
+
+func infoInt(info map[string]map[string]string, section, key string) int64 {
+	n, _ := strconv.ParseInt(info[section][key], 10, 64)
+	return n
+}
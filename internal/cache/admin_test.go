@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestQueryTag_Normalized(t *testing.T) {
+	if QueryTag("Laptop ") != QueryTag("laptop") {
+		t.Error("expected query tags to ignore case and surrounding whitespace")
+	}
+	if QueryTag("laptop") == QueryTag("laptops") {
+		t.Error("expected different queries to produce different tags")
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"sr:", "sr:"},
+		{"sr:*", `sr:\*`},
+		{"tag:doc:a?b", `tag:doc:a\?b`},
+		{`ac:[x]\`, `ac:\[x\]\\`},
+	}
+	for _, tt := range tests {
+		if got := escapeGlob(tt.in); got != tt.want {
+			t.Errorf("escapeGlob(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPurge_InvalidRequest(t *testing.T) {
+	rc := &RedisCache{logger: zap.NewNop()}
+
+	tests := []struct {
+		name string
+		req  PurgeRequest
+	}{
+		{"no selector", PurgeRequest{DryRun: true}},
+		{"empty namespace", PurgeRequest{Prefix: "s"}},
+		{"unknown namespace", PurgeRequest{Prefix: "session:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := rc.Purge(context.Background(), tt.req); !errors.Is(err, ErrInvalidPurge) {
+				t.Errorf("expected ErrInvalidPurge, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPurgeablePrefix(t *testing.T) {
+	for _, p := range []string{"sr:", "sr:stale:", "tag:doc:", "ac:", "trend:us", "fc:", "lock:sr:"} {
+		if !purgeablePrefix(p) {
+			t.Errorf("expected %q to be purgeable", p)
+		}
+	}
+	for _, p := range []string{"", "*", "s", "user:"} {
+		if purgeablePrefix(p) {
+			t.Errorf("expected %q to be rejected", p)
+		}
+	}
+}
+
+func TestIntersectKeys(t *testing.T) {
+	query := []string{"sr:us:1", "sr:stale:us:1", "sr:eu:1", "sr:stale:eu:1"}
+	region := []string{"sr:us:1", "sr:stale:us:1", "sr:us:2", "sr:us:1"}
+
+	got := intersectKeys([][]string{query, region}, "")
+	if len(got) != 2 || got[0] != "sr:us:1" || got[1] != "sr:stale:us:1" {
+		t.Errorf("expected the query's keys in the region only, got %v", got)
+	}
+	if got := intersectKeys([][]string{query, region}, "sr:stale:"); len(got) != 1 || got[0] != "sr:stale:us:1" {
+		t.Errorf("expected the prefix to narrow the match, got %v", got)
+	}
+	if got := intersectKeys([][]string{region}, ""); len(got) != 3 {
+		t.Errorf("expected a single tag's keys once each, got %v", got)
+	}
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Search responses are stored in a small binary envelope:
+//
+//	magic(1) | envelope version(1) | codec(1) | schema version(2) | created-at unix ms(8) | payload
+//
+// The payload is the JSON-encoded response, zstd-compressed when it is large
+// enough to benefit. Entries written before the envelope existed are plain
+// JSON and are still read.
+const (
+	envelopeMagic      byte = 0xE5
+	envelopeVersion    byte = 1
+	envelopeHeaderSize      = 13
+
+	codecNone byte = 0
+	codecZstd byte = 1
+
+	// searchResponseSchemaVersion must be bumped whenever models.SearchResponse
+	// changes in a way that existing cache entries can no longer be decoded
+	// into. Entries with a different schema version are treated as misses.
+	searchResponseSchemaVersion uint16 = 1
+
+	// minCompressSize skips compression for payloads too small to shrink.
+	minCompressSize = 256
+
+	// maxDecodedSize bounds decompression of a corrupt or hostile entry.
+	maxDecodedSize = 64 << 20
+)
+
+// errSchemaMismatch is returned when a cache entry was written for a
+// different response schema, e.g. by a pod running an older release.
+var errSchemaMismatch = errors.New("cache entry schema version mismatch")
+
+type envelopeCodec struct {
+	compress bool
+	enc      *zstd.Encoder
+	dec      *zstd.Decoder
+}
+
+// newEnvelopeCodec returns a codec for compression "zstd" or "none". Entries
+// of either kind are always readable regardless of the setting.
+func newEnvelopeCodec(compression string) (*envelopeCodec, error) {
+	c := &envelopeCodec{}
+	switch compression {
+	case "zstd":
+		c.compress = true
+	case "none", "":
+	default:
+		return nil, fmt.Errorf("unsupported cache compression %q", compression)
+	}
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	if err != nil {
+		return nil, fmt.Errorf("creating zstd encoder: %w", err)
+	}
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecodedSize))
+	if err != nil {
+		enc.Close()
+		return nil, fmt.Errorf("creating zstd decoder: %w", err)
+	}
+	c.enc = enc
+	c.dec = dec
+	return c, nil
+}
+
+func (c *envelopeCodec) encode(payload []byte, createdAt time.Time) []byte {
+	codec := codecNone
+	if c.compress && len(payload) >= minCompressSize {
+		codec = codecZstd
+	}
+
+	out := make([]byte, envelopeHeaderSize, envelopeHeaderSize+len(payload))
+	out[0] = envelopeMagic
+	out[1] = envelopeVersion
+	out[2] = codec
+	binary.BigEndian.PutUint16(out[3:5], searchResponseSchemaVersion)
+	binary.BigEndian.PutUint64(out[5:13], uint64(createdAt.UnixMilli()))
+
+	if codec == codecZstd {
+		return c.enc.EncodeAll(payload, out)
+	}
+	return append(out, payload...)
+}
+
+// decode returns the JSON payload and creation time of an entry. Legacy plain
+// JSON entries have a zero creation time.
+func (c *envelopeCodec) decode(data []byte) ([]byte, time.Time, error) {
+	if len(data) > 0 && data[0] == '{' {
+		return data, time.Time{}, nil
+	}
+	if len(data) < envelopeHeaderSize || data[0] != envelopeMagic {
+		return nil, time.Time{}, fmt.Errorf("unrecognized cache entry encoding")
+	}
+	if data[1] != envelopeVersion {
+		return nil, time.Time{}, fmt.Errorf("unsupported cache envelope version %d", data[1])
+	}
+	if binary.BigEndian.Uint16(data[3:5]) != searchResponseSchemaVersion {
+		return nil, time.Time{}, errSchemaMismatch
+	}
+	createdAt := time.UnixMilli(int64(binary.BigEndian.Uint64(data[5:13]))).UTC()
+
+	body := data[envelopeHeaderSize:]
+	switch data[2] {
+	case codecNone:
+		return body, createdAt, nil
+	case codecZstd:
+		payload, err := c.dec.DecodeAll(body, nil)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("decompressing cache entry: %w", err)
+		}
+		return payload, createdAt, nil
+	default:
+		return nil, time.Time{}, fmt.Errorf("unsupported cache entry codec %d", data[2])
+	}
+}
+
+func (c *envelopeCodec) close() {
+	c.enc.Close()
+	c.dec.Close()
+}
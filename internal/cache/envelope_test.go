@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestCodec(t *testing.T, compression string) *envelopeCodec {
+	t.Helper()
+	c, err := newEnvelopeCodec(compression)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(c.close)
+	return c
+}
+
+func TestEnvelope_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	small := []byte(`{"total":1}`)
+	large := []byte(`{"results":[` + string(bytes.Repeat([]byte(`{"title":"laptop","description":"a fast laptop"},`), 50)) + `{}]}`)
+
+	for _, compression := range []string{"zstd", "none"} {
+		c := newTestCodec(t, compression)
+		for _, payload := range [][]byte{small, large} {
+			data := c.encode(payload, createdAt)
+			got, gotCreatedAt, err := c.decode(data)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", compression, err)
+			}
+			if !bytes.Equal(got, payload) {
+				t.Errorf("%s: payload mismatch", compression)
+			}
+			if !gotCreatedAt.Equal(createdAt) {
+				t.Errorf("%s: expected created-at %v, got %v", compression, createdAt, gotCreatedAt)
+			}
+		}
+	}
+}
+
+func TestEnvelope_CompressesLargePayloads(t *testing.T) {
+	c := newTestCodec(t, "zstd")
+	payload := bytes.Repeat([]byte(`{"title":"laptop","description":"a fast laptop"},`), 100)
+
+	data := c.encode(payload, time.Now())
+	if data[2] != codecZstd {
+		t.Fatalf("expected zstd codec, got %d", data[2])
+	}
+	if len(data) >= len(payload) {
+		t.Errorf("expected compressed size below %d, got %d", len(payload), len(data))
+	}
+
+	small := c.encode([]byte(`{"total":1}`), time.Now())
+	if small[2] != codecNone {
+		t.Errorf("expected small payload to be stored uncompressed, got codec %d", small[2])
+	}
+}
+
+func TestEnvelope_ReadsAcrossCompressionSettings(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 1024)
+	data := newTestCodec(t, "zstd").encode(payload, time.Now())
+
+	got, _, err := newTestCodec(t, "none").decode(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Error("payload mismatch")
+	}
+}
+
+func TestEnvelope_LegacyJSON(t *testing.T) {
+	c := newTestCodec(t, "zstd")
+	legacy := []byte(`{"results":[],"total":0}`)
+
+	got, createdAt, err := c.decode(legacy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, legacy) || !createdAt.IsZero() {
+		t.Errorf("expected legacy entry to pass through, got %q at %v", got, createdAt)
+	}
+}
+
+func TestEnvelope_SchemaMismatch(t *testing.T) {
+	c := newTestCodec(t, "none")
+	data := c.encode([]byte(`{}`), time.Now())
+	binary.BigEndian.PutUint16(data[3:5], searchResponseSchemaVersion+1)
+
+	if _, _, err := c.decode(data); !errors.Is(err, errSchemaMismatch) {
+		t.Errorf("expected errSchemaMismatch, got %v", err)
+	}
+}
+
+func TestEnvelope_Invalid(t *testing.T) {
+	c := newTestCodec(t, "none")
+
+	for _, data := range [][]byte{nil, {0x00, 0x01}, bytes.Repeat([]byte{0x01}, envelopeHeaderSize)} {
+		if _, _, err := c.decode(data); err == nil {
+			t.Errorf("expected error for %v", data)
+		}
+	}
+
+	bad := c.encode([]byte(`{}`), time.Now())
+	bad[2] = 9
+	if _, _, err := c.decode(bad); err == nil {
+		t.Error("expected error for unknown codec")
+	}
+}
+
+func TestNewEnvelopeCodec_Unsupported(t *testing.T) {
+	if _, err := newEnvelopeCodec("gzip"); err == nil {
+		t.Error("expected error for unsupported compression")
+	}
+}
@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+// localCache is the in-process L1 in front of Redis: an LRU of decoded search
+// responses bounded by their approximate encoded size. Entries carry their own
+// expiry, which never exceeds the Redis entry they were read from.
+type localCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	maxTTL   time.Duration
+	bytes    int64
+	ll       *list.List
+	items    map[string]*list.Element
+	now      func() time.Time
+}
+
+type localEntry struct {
+	key       string
+	resp      models.SearchResponse
+	size      int64
+	expiresAt time.Time
+}
+
+func newLocalCache(maxBytes int64, maxTTL time.Duration) *localCache {
+	return &localCache{
+		maxBytes: maxBytes,
+		maxTTL:   maxTTL,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// get returns a copy of the cached response. The copy's slices and maps are
+// shared with the cache and must be treated as read-only.
+func (lc *localCache) get(key string) (*models.SearchResponse, bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	el, ok := lc.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*localEntry)
+	if !lc.now().Before(entry.expiresAt) {
+		lc.removeElement(el)
+		return nil, false
+	}
+	lc.ll.MoveToFront(el)
+	resp := entry.resp
+	return &resp, true
+}
+
+// peek reports whether key holds an unexpired entry without touching its
+// recency or evicting it, so inspecting the cache does not change what it
+// evicts next.
+func (lc *localCache) peek(key string) bool {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	el, ok := lc.items[key]
+	if !ok {
+		return false
+	}
+	return lc.now().Before(el.Value.(*localEntry).expiresAt)
+}
+
+// set stores a copy of resp for at most ttl (capped at maxTTL). size is the
+// encoded size used for the memory bound; entries larger than the whole
+// cache are not stored.
+func (lc *localCache) set(key string, resp *models.SearchResponse, size int64, ttl time.Duration) {
+	if ttl > lc.maxTTL {
+		ttl = lc.maxTTL
+	}
+	if ttl <= 0 || size > lc.maxBytes {
+		return
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if el, ok := lc.items[key]; ok {
+		lc.removeElement(el)
+	}
+	entry := &localEntry{
+		key:       key,
+		resp:      *resp,
+		size:      size,
+		expiresAt: lc.now().Add(ttl),
+	}
+	lc.items[key] = lc.ll.PushFront(entry)
+	lc.bytes += size
+
+	for lc.bytes > lc.maxBytes {
+		lc.removeElement(lc.ll.Back())
+	}
+}
+
+func (lc *localCache) remove(keys ...string) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := lc.items[key]; ok {
+			lc.removeElement(el)
+		}
+	}
+}
+
+func (lc *localCache) len() int {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.ll.Len()
+}
+
+func (lc *localCache) stats() *LocalStats {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return &LocalStats{
+		Entries:  lc.ll.Len(),
+		Bytes:    lc.bytes,
+		MaxBytes: lc.maxBytes,
+	}
+}
+
+func (lc *localCache) removeElement(el *list.Element) {
+	entry := el.Value.(*localEntry)
+	lc.ll.Remove(el)
+	delete(lc.items, entry.key)
+	lc.bytes -= entry.size
+}
+
+// invalidationMessage is published on the invalidation channel whenever
+// search cache keys are deleted, so every pod drops them from its L1.
+type invalidationMessage struct {
+	Keys []string `json:"keys"`
+}
+
+// subscribeInvalidations listens for keys deleted by any pod and drops them
+// from the L1. Messages missed while the connection is re-established are
+// covered by the L1's MaxTTL.
+func (rc *RedisCache) subscribeInvalidations(ctx context.Context, channel string) error {
+	pubsub := rc.client.Subscribe(ctx, channel)
+	// Wait for the subscription confirmation so a broken channel is reported
+	// at startup instead of silently leaving the L1 incoherent.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return fmt.Errorf("subscribing to %s: %w", channel, err)
+	}
+	rc.pubsub = pubsub
+	rc.channel = channel
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			var m invalidationMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+				rc.logger.Warn("invalid cache invalidation message", zap.Error(err))
+				continue
+			}
+			rc.local.remove(m.Keys...)
+		}
+	}()
+	return nil
+}
+
+// publishInvalidation drops keys from this pod's L1 and tells the other pods
+// to do the same. Publish failures are logged, not returned: the Redis delete
+// has already succeeded and peers converge within MaxTTL.
+func (rc *RedisCache) publishInvalidation(ctx context.Context, keys []string) {
+	if rc.local == nil || len(keys) == 0 {
+		return
+	}
+	rc.local.remove(keys...)
+
+	data, err := json.Marshal(invalidationMessage{Keys: keys})
+	if err != nil {
+		rc.logger.Warn("cache invalidation marshal error", zap.Error(err))
+		return
+	}
+	if err := rc.client.Publish(ctx, rc.channel, data).Err(); err != nil {
+		rc.logger.Warn("cache invalidation publish error",
+			zap.Int("key_count", len(keys)),
+			zap.Error(err),
+		)
+	}
+}
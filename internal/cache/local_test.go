@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+func newTestLocalCache(maxBytes int64, maxTTL time.Duration) (*localCache, *time.Time) {
+	lc := newLocalCache(maxBytes, maxTTL)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	lc.now = func() time.Time { return now }
+	return lc, &now
+}
+
+func TestLocalCache_GetSet(t *testing.T) {
+	lc, _ := newTestLocalCache(1024, time.Minute)
+
+	if _, ok := lc.get("sr:a"); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+
+	lc.set("sr:a", &models.SearchResponse{Total: 7}, 100, time.Minute)
+	resp, ok := lc.get("sr:a")
+	if !ok {
+		t.Fatal("expected hit")
+	}
+	if resp.Total != 7 {
+		t.Errorf("expected total hits 7, got %d", resp.Total)
+	}
+}
+
+func TestLocalCache_ReturnsCopies(t *testing.T) {
+	lc, _ := newTestLocalCache(1024, time.Minute)
+
+	orig := &models.SearchResponse{Total: 7}
+	lc.set("sr:a", orig, 100, time.Minute)
+	orig.Total = 99
+
+	resp, _ := lc.get("sr:a")
+	resp.Metadata.CacheHit = true
+	resp.TookMs = 5
+
+	again, _ := lc.get("sr:a")
+	if again.Total != 7 || again.Metadata.CacheHit || again.TookMs != 0 {
+		t.Errorf("cached entry was mutated through a caller's copy: %+v", again)
+	}
+}
+
+func TestLocalCache_Expiry(t *testing.T) {
+	lc, now := newTestLocalCache(1024, time.Minute)
+
+	lc.set("sr:short", &models.SearchResponse{}, 10, 5*time.Second)
+	lc.set("sr:capped", &models.SearchResponse{}, 10, time.Hour)
+
+	*now = now.Add(10 * time.Second)
+	if _, ok := lc.get("sr:short"); ok {
+		t.Error("expected entry to expire with its Redis TTL")
+	}
+	if _, ok := lc.get("sr:capped"); !ok {
+		t.Error("expected entry within max TTL to be served")
+	}
+
+	*now = now.Add(time.Minute)
+	if _, ok := lc.get("sr:capped"); ok {
+		t.Error("expected entry to expire at max TTL")
+	}
+	if lc.len() != 0 {
+		t.Errorf("expected expired entries to be removed, got %d", lc.len())
+	}
+}
+
+func TestLocalCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	lc, _ := newTestLocalCache(300, time.Minute)
+
+	lc.set("sr:a", &models.SearchResponse{}, 100, time.Minute)
+	lc.set("sr:b", &models.SearchResponse{}, 100, time.Minute)
+	lc.set("sr:c", &models.SearchResponse{}, 100, time.Minute)
+	lc.get("sr:a") // a is now more recent than b
+
+	lc.set("sr:d", &models.SearchResponse{}, 100, time.Minute)
+
+	if _, ok := lc.get("sr:b"); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	for _, key := range []string{"sr:a", "sr:c", "sr:d"} {
+		if _, ok := lc.get(key); !ok {
+			t.Errorf("expected %s to be retained", key)
+		}
+	}
+	if lc.bytes != 300 {
+		t.Errorf("expected 300 bytes accounted, got %d", lc.bytes)
+	}
+}
+
+func TestLocalCache_PeekLeavesRecencyAlone(t *testing.T) {
+	lc, now := newTestLocalCache(200, time.Minute)
+
+	lc.set("sr:a", &models.SearchResponse{}, 100, time.Minute)
+	lc.set("sr:b", &models.SearchResponse{}, 100, 30*time.Second)
+	if !lc.peek("sr:a") {
+		t.Fatal("expected peek to find sr:a")
+	}
+
+	lc.set("sr:c", &models.SearchResponse{}, 100, time.Minute)
+	if lc.peek("sr:a") {
+		t.Error("expected peek not to protect sr:a from eviction")
+	}
+
+	*now = now.Add(time.Minute)
+	if lc.peek("sr:b") {
+		t.Error("expected peek to miss an expired entry")
+	}
+	if lc.len() != 2 {
+		t.Errorf("expected peek to leave the expired entry in place, got %d entries", lc.len())
+	}
+}
+
+func TestLocalCache_SkipsOversizedAndNonPositiveTTL(t *testing.T) {
+	lc, _ := newTestLocalCache(100, time.Minute)
+
+	lc.set("sr:big", &models.SearchResponse{}, 101, time.Minute)
+	lc.set("sr:nottl", &models.SearchResponse{}, 10, -1)
+
+	if lc.len() != 0 {
+		t.Errorf("expected nothing cached, got %d entries", lc.len())
+	}
+}
+
+func TestLocalCache_Remove(t *testing.T) {
+	lc, _ := newTestLocalCache(1024, time.Minute)
+
+	lc.set("sr:a", &models.SearchResponse{}, 100, time.Minute)
+	lc.set("sr:b", &models.SearchResponse{}, 100, time.Minute)
+	lc.remove("sr:a", "sr:missing")
+
+	if _, ok := lc.get("sr:a"); ok {
+		t.Error("expected removed entry to miss")
+	}
+	if _, ok := lc.get("sr:b"); !ok {
+		t.Error("expected other entry to be retained")
+	}
+	if lc.bytes != 100 {
+		t.Errorf("expected 100 bytes accounted, got %d", lc.bytes)
+	}
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+// releaseLockScript deletes the lock only if it still holds our token, so a
+// holder whose lock expired cannot release a lock another pod has since taken.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// SearchKey returns the cache key a search request is stored under. Requests
+// with the same key share a cached response and may share a backend search.
+func (rc *RedisCache) SearchKey(req *models.SearchRequest) string {
+	return rc.buildSearchKey(req)
+}
+
+// TryLock attempts to take a fleet-wide lock named name for at most ttl. It
+// does not block: acquired is false when another holder has it. The returned
+// release function must be called once the protected work is done.
+func (rc *RedisCache) TryLock(ctx context.Context, name string, ttl time.Duration) (release func(), acquired bool, err error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, false, fmt.Errorf("generating lock token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	ok, err := rc.client.SetNX(ctx, name, token, ttl).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("acquiring lock %s: %w", name, err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+
+	release = func() {
+		// The caller's context may already be done; release regardless.
+		releaseCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := releaseLockScript.Run(releaseCtx, rc.client, []string{name}, token).Err(); err != nil {
+			rc.logger.Warn("releasing lock failed", zap.String("lock", name), zap.Error(err))
+		}
+	}
+	return release, true, nil
+}
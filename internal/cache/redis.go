@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -21,6 +22,14 @@ type RedisCache struct {
 	client redis.UniversalClient
 	ttl    config.CacheTTLConfig
 	logger *zap.Logger
+
+	// Encodes search responses in a versioned, optionally compressed envelope
+	codec *envelopeCodec
+
+	// L1 in front of Redis for search responses; nil when disabled.
+	local   *localCache
+	pubsub  *redis.PubSub
+	channel string
 }
 
 func NewRedisCache(cfg config.RedisConfig, logger *zap.Logger) (*RedisCache, error) {
@@ -59,11 +68,35 @@ func NewRedisCache(cfg config.RedisConfig, logger *zap.Logger) (*RedisCache, err
 
 	logger.Info("redis cache connected", zap.Strings("addresses", cfg.Addresses))
 
-	return &RedisCache{
+	codec, err := newEnvelopeCodec(cfg.Compression)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	rc := &RedisCache{
 		client: client,
 		ttl:    cfg.TTL,
 		logger: logger,
-	}, nil
+		codec:  codec,
+	}
+
+	if cfg.Local.Enabled {
+		rc.local = newLocalCache(cfg.Local.MaxBytes, cfg.Local.MaxTTL)
+		if err := rc.subscribeInvalidations(ctx, cfg.Local.InvalidationChannel); err != nil {
+			// Without invalidations the L1 could serve evicted entries, so
+			// run Redis-only rather than incoherent.
+			rc.local = nil
+			logger.Warn("local cache disabled: invalidation channel unavailable", zap.Error(err))
+		} else {
+			logger.Info("local cache enabled",
+				zap.Int64("max_bytes", cfg.Local.MaxBytes),
+				zap.Duration("max_ttl", cfg.Local.MaxTTL),
+			)
+		}
+	}
+
+	return rc, nil
 }
 
 func (rc *RedisCache) GetSearchResults(ctx context.Context, req *models.SearchRequest) (*models.SearchResponse, error) {
@@ -72,13 +105,38 @@ func (rc *RedisCache) GetSearchResults(ctx context.Context, req *models.SearchRe
 }
 
 func (rc *RedisCache) SetSearchResults(ctx context.Context, req *models.SearchRequest, resp *models.SearchResponse) error {
-	key := rc.buildSearchKey(req)
-	ttl := rc.ttlForIntent(resp.Metadata.Intent)
-	if err := rc.setResponse(ctx, key, resp, ttl); err != nil {
+	// Stamp the cached copy, not the caller's response. A response that was
+	// itself served from cache (e.g. a stale fallback) keeps its original
+	// timestamp so its age is never reset.
+	cp := *resp
+	if cp.Metadata.CachedAt == nil {
+		now := time.Now().UTC()
+		cp.Metadata.CachedAt = &now
+	}
+	cp.Metadata.AgeMs = 0
+
+	data, size, err := rc.encodeResponse(&cp)
+	if err != nil {
 		return err
 	}
+
+	// The fresh and stale copies share one encoding and one round trip.
+	key := rc.buildSearchKey(req)
 	staleKey := rc.buildStaleKey(req)
-	return rc.setResponse(ctx, staleKey, resp, rc.ttl.StaleFallback)
+	ttl := rc.ttlForIntent(resp.Metadata.Intent)
+	_, err = rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, ttl)
+		pipe.Set(ctx, staleKey, data, rc.ttl.StaleFallback)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cache set: %w", err)
+	}
+	if rc.local != nil {
+		rc.local.set(key, &cp, size, ttl)
+		rc.local.set(staleKey, &cp, size, rc.ttl.StaleFallback)
+	}
+	return rc.tagResponse(ctx, req, resp, key, staleKey)
 }
 
 func (rc *RedisCache) GetStaleResults(ctx context.Context, req *models.SearchRequest) (*models.SearchResponse, error) {
@@ -96,6 +154,7 @@ func (rc *RedisCache) InvalidateKeys(ctx context.Context, keys []string) error {
 		rc.logger.Warn("cache delete error", zap.Int("key_count", len(keys)), zap.Error(err))
 		return err
 	}
+	rc.publishInvalidation(ctx, keys)
 	return nil
 }
 
@@ -156,33 +215,93 @@ func (rc *RedisCache) HealthCheck(ctx context.Context) error {
 }
 
 func (rc *RedisCache) Close() error {
+	if rc.pubsub != nil {
+		rc.pubsub.Close()
+	}
+	if rc.codec != nil {
+		rc.codec.close()
+	}
 	return rc.client.Close()
 }
 
+// getResponse reads a search response from the L1, falling back to Redis.
+// Redis hits are promoted to the L1 for no longer than their remaining TTL.
 func (rc *RedisCache) getResponse(ctx context.Context, key string) (*models.SearchResponse, error) {
-	val, err := rc.client.Get(ctx, key).Result()
+	if rc.local != nil {
+		if resp, ok := rc.local.get(key); ok {
+			observability.CacheTierHits.WithLabelValues("l1").Inc()
+			return resp, nil
+		}
+		observability.CacheTierMisses.WithLabelValues("l1").Inc()
+	}
+
+	var val string
+	var ttl time.Duration
+	var err error
+	if rc.local != nil {
+		// Fetch the TTL in the same round trip so the L1 copy expires with Redis.
+		var getCmd *redis.StringCmd
+		var ttlCmd *redis.DurationCmd
+		_, err = rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			getCmd = pipe.Get(ctx, key)
+			ttlCmd = pipe.PTTL(ctx, key)
+			return nil
+		})
+		val, ttl = getCmd.Val(), ttlCmd.Val()
+		if err == nil {
+			err = getCmd.Err()
+		}
+	} else {
+		val, err = rc.client.Get(ctx, key).Result()
+	}
 	if err == redis.Nil {
 		observability.CacheMisses.Inc()
+		observability.CacheTierMisses.WithLabelValues("l2").Inc()
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("cache get: %w", err)
 	}
 
+	payload, createdAt, err := rc.codec.decode([]byte(val))
+	if errors.Is(err, errSchemaMismatch) {
+		// Written by a release with an incompatible response model; the next
+		// search overwrites it.
+		observability.CacheMisses.Inc()
+		observability.CacheTierMisses.WithLabelValues("l2").Inc()
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache decode: %w", err)
+	}
+
 	observability.CacheHits.Inc()
+	observability.CacheTierHits.WithLabelValues("l2").Inc()
 	var resp models.SearchResponse
-	if err := json.Unmarshal([]byte(val), &resp); err != nil {
+	if err := json.Unmarshal(payload, &resp); err != nil {
 		return nil, fmt.Errorf("cache unmarshal: %w", err)
 	}
+	if resp.Metadata.CachedAt == nil && !createdAt.IsZero() {
+		resp.Metadata.CachedAt = &createdAt
+	}
+	if rc.local != nil {
+		rc.local.set(key, &resp, int64(len(payload)), ttl)
+	}
 	return &resp, nil
 }
 
-func (rc *RedisCache) setResponse(ctx context.Context, key string, resp *models.SearchResponse, ttl time.Duration) error {
-	data, err := json.Marshal(resp)
+// encodeResponse returns the envelope-encoded response and the size of its
+// uncompressed payload, which approximates its in-memory footprint.
+func (rc *RedisCache) encodeResponse(resp *models.SearchResponse) ([]byte, int64, error) {
+	payload, err := json.Marshal(resp)
 	if err != nil {
-		return fmt.Errorf("cache marshal: %w", err)
+		return nil, 0, fmt.Errorf("cache marshal: %w", err)
+	}
+	createdAt := time.Now()
+	if resp.Metadata.CachedAt != nil {
+		createdAt = *resp.Metadata.CachedAt
 	}
-	return rc.client.Set(ctx, key, data, ttl).Err()
+	return rc.codec.encode(payload, createdAt), int64(len(payload)), nil
 }
 
 // buildSearchKey produces a deterministic cache key by sorting filter keys
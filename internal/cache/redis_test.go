@@ -225,3 +225,36 @@ func TestTtlForIntent(t *testing.T) {
 		})
 	}
 }
+
+func TestResponseTags(t *testing.T) {
+	req := &models.SearchRequest{
+		Query:   "laptop",
+		Filters: map[string]any{"category": "electronics"},
+		Region:  "us-east",
+	}
+	resp := &models.SearchResponse{
+		Results: []models.SearchResult{{ID: "doc-1"}, {ID: "doc-2"}, {ID: ""}},
+	}
+
+	tags := responseTags(req, resp)
+
+	expected := []string{"tag:doc:doc-1", "tag:doc:doc-2", "tag:cat:electronics", "tag:region:us-east"}
+	if len(tags) != len(expected) {
+		t.Fatalf("expected %d tags, got %d: %v", len(expected), len(tags), tags)
+	}
+	for i, tag := range expected {
+		if tags[i] != tag {
+			t.Errorf("tag %d: expected %q, got %q", i, tag, tags[i])
+		}
+	}
+}
+
+func TestResponseTags_Unscoped(t *testing.T) {
+	req := &models.SearchRequest{Query: "laptop", Filters: map[string]any{"brand": "acme"}}
+	resp := &models.SearchResponse{Results: []models.SearchResult{{ID: "doc-1"}}}
+
+	tags := responseTags(req, resp)
+	if len(tags) != 1 || tags[0] != "tag:doc:doc-1" {
+		t.Errorf("expected only the document tag, got %v", tags)
+	}
+}
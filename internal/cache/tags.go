@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// Tags are Redis sets of the sr: and sr:stale: keys whose cached response
+// depends on a document, category or region. They let the indexing pipeline
+// evict exactly the responses a change affects without SCANning the keyspace.
+
+// DocTag is the tag for responses that contain a document.
+func DocTag(docID string) string {
+	return fmt.Sprintf("tag:doc:%s", docID)
+}
+
+// CategoryTag is the tag for responses scoped to a category filter.
+func CategoryTag(category string) string {
+	return fmt.Sprintf("tag:cat:%s", category)
+}
+
+// RegionTag is the tag for responses scoped to a region.
+func RegionTag(region string) string {
+	return fmt.Sprintf("tag:region:%s", region)
+}
+
+// responseTags returns the tags a cached response depends on: every document
+// it returned, plus the category and region it was scoped to. A change to a
+// listed document, or a new document in a scoped category or region, may
+// alter the response. Unscoped responses only depend on their own hits; new
+// matching documents appear in them once the TTL lapses.
+func responseTags(req *models.SearchRequest, resp *models.SearchResponse) []string {
+	tags := make([]string, 0, len(resp.Results)+2)
+	for i := range resp.Results {
+		if id := resp.Results[i].ID; id != "" {
+			tags = append(tags, DocTag(id))
+		}
+	}
+	if category, ok := req.Filters["category"].(string); ok && category != "" {
+		tags = append(tags, CategoryTag(category))
+	}
+	if req.Region != "" {
+		tags = append(tags, RegionTag(req.Region))
+	}
+	return tags
+}
+
+// tagResponse records key and staleKey under each of the response's tags,
+// plus its query tag for admin purges. The tag sets live as long as the
+// longest-lived entry they point at, so a dangling member is at worst a DEL
+// of an already expired key.
+func (rc *RedisCache) tagResponse(ctx context.Context, req *models.SearchRequest, resp *models.SearchResponse, key, staleKey string) error {
+	tags := append(responseTags(req, resp), QueryTag(req.Query))
+
+	_, err := rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range tags {
+			pipe.SAdd(ctx, tag, key, staleKey)
+			pipe.Expire(ctx, tag, rc.ttl.StaleFallback)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cache tag response: %w", err)
+	}
+	return nil
+}
+
+// InvalidateTags deletes every cached search response recorded under tags and
+// returns the number of entries evicted. Keys are deleted one per command so
+// this works on Redis Cluster, where the tagged keys span hash slots.
+func (rc *RedisCache) InvalidateTags(ctx context.Context, tags []string) (int, error) {
+	evicted := 0
+	for _, tag := range tags {
+		keys, err := rc.client.SMembers(ctx, tag).Result()
+		if err != nil {
+			return evicted, fmt.Errorf("cache read tag %s: %w", tag, err)
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		members := make([]any, len(keys))
+		_, err = rc.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, k := range keys {
+				pipe.Del(ctx, k)
+				members[i] = k
+			}
+			// SREM rather than DEL so keys tagged while we were evicting survive.
+			pipe.SRem(ctx, tag, members...)
+			return nil
+		})
+		if err != nil {
+			rc.logger.Warn("cache tag eviction error", zap.String("tag", tag), zap.Error(err))
+			return evicted, fmt.Errorf("cache evict tag %s: %w", tag, err)
+		}
+		rc.publishInvalidation(ctx, keys)
+		evicted += len(keys)
+	}
+
+	observability.CacheTagEvictions.Add(float64(evicted))
+	return evicted, nil
+}
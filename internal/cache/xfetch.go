@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+// RefreshDue reports whether a fresh cached response should be refreshed
+// early, using XFetch (probabilistic early expiration): the closer the entry
+// is to expiry and the longer it took to compute, the more likely each read
+// triggers a refresh, so popular keys are recomputed by one reader shortly
+// before they expire instead of by every reader right after.
+func (rc *RedisCache) RefreshDue(resp *models.SearchResponse, beta float64) bool {
+	if beta <= 0 || resp.Metadata.CachedAt == nil {
+		return false
+	}
+	expiresAt := resp.Metadata.CachedAt.Add(rc.ttlForIntent(resp.Metadata.Intent))
+	delta := time.Duration(resp.TookMs) * time.Millisecond
+	return xfetchDue(time.Now(), expiresAt, delta, beta, 1-rand.Float64())
+}
+
+// xfetchDue is the XFetch test: now - delta*beta*ln(u) >= expiry, for u
+// drawn uniformly from (0, 1].
+func xfetchDue(now, expiresAt time.Time, delta time.Duration, beta, u float64) bool {
+	if u <= 0 {
+		u = math.SmallestNonzeroFloat64
+	}
+	early := time.Duration(-float64(delta) * beta * math.Log(u))
+	return !now.Add(early).Before(expiresAt)
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+func TestXFetchDue(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	delta := 100 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		u         float64
+		want      bool
+	}{
+		{"already expired", -time.Second, 1, true},
+		{"far from expiry", time.Minute, 0.01, false},
+		{"near expiry, unlucky draw", 50 * time.Millisecond, 1, false},
+		{"near expiry, lucky draw", 50 * time.Millisecond, 0.5, true},
+		{"zero draw is clamped", time.Minute, 0, true},
+		{"zero draw stays finite", time.Hour, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := xfetchDue(now, now.Add(tt.expiresIn), delta, 1.0, tt.u)
+			if got != tt.want {
+				t.Errorf("xfetchDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshDue(t *testing.T) {
+	rc := &RedisCache{ttl: config.CacheTTLConfig{SearchResults: 2 * time.Minute}}
+
+	if rc.RefreshDue(&models.SearchResponse{TookMs: 100}, 1.0) {
+		t.Error("expected no refresh for a response without CachedAt")
+	}
+
+	expired := time.Now().Add(-3 * time.Minute)
+	resp := &models.SearchResponse{
+		TookMs:   100,
+		Metadata: models.ResponseMetadata{Intent: "fulltext", CachedAt: &expired},
+	}
+	if !rc.RefreshDue(resp, 1.0) {
+		t.Error("expected refresh for a response past its TTL")
+	}
+	if rc.RefreshDue(resp, 0) {
+		t.Error("expected beta 0 to disable early refresh")
+	}
+
+	fresh := time.Now()
+	resp.Metadata.CachedAt = &fresh
+	if rc.RefreshDue(resp, 1.0) {
+		t.Error("did not expect a just-cached response to be refreshed")
+	}
+}
@@ -80,7 +80,7 @@ func (c *Client) QueryFacets(ctx context.Context, category string, filters map[s
 		LIMIT 100
 	`
 
-	rows, err := c.conn.Query(ctx, query, category)
+	rows, err := c.conn.Query(trackQuery(ctx, "facets"), query, category)
 	if err != nil {
 		observability.CHQueryDuration.WithLabelValues("facets", "error").Observe(time.Since(start).Seconds())
 		return nil, fmt.Errorf("ch facet query: %w", err)
@@ -130,7 +130,7 @@ func (c *Client) QueryAnalytics(ctx context.Context, query string, filters map[s
 		LIMIT 50
 	`
 
-	rows, err := c.conn.Query(ctx, chQuery, query, query)
+	rows, err := c.conn.Query(trackQuery(ctx, "analytics"), chQuery, query, query)
 	if err != nil {
 		observability.CHQueryDuration.WithLabelValues("analytics", "error").Observe(time.Since(start).Seconds())
 		return nil, fmt.Errorf("ch analytics query: %w", err)
@@ -223,7 +223,7 @@ func (c *Client) FallbackSearch(ctx context.Context, queryText string, limit int
 		LIMIT ?
 	`
 
-	rows, err := c.conn.Query(ctx, query, queryText, queryText, limit)
+	rows, err := c.conn.Query(trackQuery(ctx, "fallback"), query, queryText, queryText, limit)
 	if err != nil {
 		observability.CHQueryDuration.WithLabelValues("fallback", "error").Observe(time.Since(start).Seconds())
 		return nil, fmt.Errorf("ch fallback search: %w", err)
@@ -246,6 +246,50 @@ func (c *Client) FallbackSearch(ctx context.Context, queryText string, limit int
 	return results, nil
 }
 
+// PopularDocuments returns the perRegion most popular documents in each
+// region, most popular first.
+func (c *Client) PopularDocuments(ctx context.Context, perRegion int) (map[string][]models.SearchResult, error) {
+	ctx, span := observability.StartSpan(ctx, "ch.popular_documents")
+	defer span.End()
+
+	start := time.Now()
+
+	query := `
+		SELECT
+			document_id,
+			title,
+			description,
+			category,
+			region,
+			popularity_score
+		FROM search_documents FINAL
+		ORDER BY region, popularity_score DESC
+		LIMIT ? BY region
+	`
+
+	rows, err := c.conn.Query(trackQuery(ctx, "popular"), query, perRegion)
+	if err != nil {
+		observability.CHQueryDuration.WithLabelValues("popular", "error").Observe(time.Since(start).Seconds())
+		return nil, fmt.Errorf("ch popular documents: %w", err)
+	}
+	defer rows.Close()
+
+	results := make(map[string][]models.SearchResult)
+	for rows.Next() {
+		var r models.SearchResult
+		if err := rows.Scan(&r.ID, &r.Title, &r.Description, &r.Category, &r.Region, &r.PopularityScore); err != nil {
+			return nil, fmt.Errorf("scanning popular document row: %w", err)
+		}
+		results[r.Region] = append(results[r.Region], r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating popular document rows: %w", err)
+	}
+
+	observability.CHQueryDuration.WithLabelValues("popular", "success").Observe(time.Since(start).Seconds())
+	return results, nil
+}
+
 func (c *Client) HealthCheck(ctx context.Context) error {
 	return c.conn.Ping(ctx)
 }
@@ -304,6 +348,29 @@ func (c *Client) EnsureTables(ctx context.Context) error {
 		) ENGINE = SummingMergeTree(count)
 		PARTITION BY category
 		ORDER BY (category, facet_name, facet_value)`,
+
+		`CREATE TABLE IF NOT EXISTS search_query_log (
+			query String,
+			filters String,
+			sort String,
+			page_size UInt16,
+			region LowCardinality(String),
+			timestamp DateTime
+		) ENGINE = MergeTree()
+		PARTITION BY toYYYYMMDD(timestamp)
+		ORDER BY (region, timestamp)
+		TTL timestamp + INTERVAL 30 DAY`,
+
+		`CREATE TABLE IF NOT EXISTS query_profiles (
+			query_hash String,
+			query_type String,
+			trace_id String,
+			timestamp DateTime,
+			es_shards String,
+			ch_queries String
+		) ENGINE = MergeTree()
+		PARTITION BY toYYYYMM(timestamp)
+		ORDER BY (timestamp, query_hash)`,
 	}
 
 	for _, ddl := range tables {
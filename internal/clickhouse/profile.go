@@ -0,0 +1,146 @@
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+type queryTrackerKey struct{}
+
+// QueryTracker records the ClickHouse query IDs issued while serving a
+// profiled search so their read stats can be looked up in system.query_log.
+type QueryTracker struct {
+	mu      sync.Mutex
+	queries []models.CHQueryStats
+}
+
+func NewQueryTracker() *QueryTracker {
+	return &QueryTracker{}
+}
+
+// WithQueryTracker returns a context that makes every query issued through
+// Client tag itself with a unique query ID and record it on t.
+func WithQueryTracker(ctx context.Context, t *QueryTracker) context.Context {
+	return context.WithValue(ctx, queryTrackerKey{}, t)
+}
+
+// Queries returns a copy of the queries recorded so far.
+func (t *QueryTracker) Queries() []models.CHQueryStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queries) == 0 {
+		return nil
+	}
+	cp := make([]models.CHQueryStats, len(t.queries))
+	copy(cp, t.queries)
+	return cp
+}
+
+// trackQuery assigns a query ID to ctx when a tracker is present. Untracked
+// contexts are returned unchanged so the common path pays nothing.
+func trackQuery(ctx context.Context, queryType string) context.Context {
+	t, ok := ctx.Value(queryTrackerKey{}).(*QueryTracker)
+	if !ok || t == nil {
+		return ctx
+	}
+
+	id := uuid.New().String()
+	t.mu.Lock()
+	t.queries = append(t.queries, models.CHQueryStats{QueryID: id, QueryType: queryType})
+	t.mu.Unlock()
+
+	return clickhouse.Context(ctx, clickhouse.WithQueryID(id))
+}
+
+// QueryLogStats fills in rows/bytes read and duration for the given queries
+// from system.query_log. Queries not yet present in the log keep zero values.
+func (c *Client) QueryLogStats(ctx context.Context, queries []models.CHQueryStats) ([]models.CHQueryStats, error) {
+	if len(queries) == 0 {
+		return queries, nil
+	}
+
+	// query_log is flushed periodically; force a flush so just-finished
+	// queries are visible. Requires the SYSTEM FLUSH LOGS grant, so failure
+	// only means stats may be missing.
+	if err := c.conn.Exec(ctx, "SYSTEM FLUSH LOGS"); err != nil {
+		c.logger.Debug("system flush logs failed", zap.Error(err))
+	}
+
+	ids := make([]string, len(queries))
+	for i, q := range queries {
+		ids[i] = q.QueryID
+	}
+
+	rows, err := c.conn.Query(ctx, `
+		SELECT query_id, read_rows, read_bytes, query_duration_ms
+		FROM system.query_log
+		WHERE type = 'QueryFinish' AND query_id IN ?
+	`, ids)
+	if err != nil {
+		return queries, fmt.Errorf("ch query_log lookup: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[string]models.CHQueryStats, len(queries))
+	for rows.Next() {
+		var s models.CHQueryStats
+		if err := rows.Scan(&s.QueryID, &s.ReadRows, &s.ReadBytes, &s.DurationMs); err != nil {
+			return queries, fmt.Errorf("scanning query_log row: %w", err)
+		}
+		stats[s.QueryID] = s
+	}
+	if err := rows.Err(); err != nil {
+		return queries, fmt.Errorf("iterating query_log rows: %w", err)
+	}
+
+	result := make([]models.CHQueryStats, len(queries))
+	for i, q := range queries {
+		if s, ok := stats[q.QueryID]; ok {
+			q.ReadRows = s.ReadRows
+			q.ReadBytes = s.ReadBytes
+			q.DurationMs = s.DurationMs
+		}
+		result[i] = q
+	}
+	return result, nil
+}
+
+// WriteQueryProfile stores the profile of a slow query in query_profiles,
+// keyed the same way as its query_performance row.
+func (c *Client) WriteQueryProfile(ctx context.Context, event *models.AnalyticsEvent, profile *models.QueryProfile) error {
+	chQueries, err := c.QueryLogStats(ctx, profile.CHQueries)
+	if err != nil {
+		c.logger.Warn("resolving clickhouse query stats failed", zap.Error(err))
+	}
+
+	esShards, err := json.Marshal(profile.ESShards)
+	if err != nil {
+		return fmt.Errorf("marshaling es shard profile: %w", err)
+	}
+	chStats, err := json.Marshal(chQueries)
+	if err != nil {
+		return fmt.Errorf("marshaling ch query stats: %w", err)
+	}
+
+	query := `
+		INSERT INTO query_profiles (
+			query_hash, query_type, trace_id, timestamp, es_shards, ch_queries
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`
+	return c.conn.Exec(ctx, query,
+		event.QueryHash,
+		event.QueryType,
+		event.TraceID,
+		event.Timestamp,
+		string(esShards),
+		string(chStats),
+	)
+}
@@ -0,0 +1,190 @@
+package clickhouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// QueryLog buffers served searches and writes them to search_query_log in
+// batches. Recording never blocks the request path: when the buffer is full
+// the search is dropped from the log.
+type QueryLog struct {
+	client   *Client
+	logger   *zap.Logger
+	maxSize  int
+	interval time.Duration
+
+	mu  sync.Mutex
+	buf []queryLogRow
+}
+
+type queryLogRow struct {
+	query     string
+	filters   string
+	sort      string
+	pageSize  int
+	region    string
+	timestamp time.Time
+}
+
+func NewQueryLog(client *Client, bufferSize int, flushInterval time.Duration, logger *zap.Logger) *QueryLog {
+	return &QueryLog{
+		client:   client,
+		logger:   logger,
+		maxSize:  bufferSize,
+		interval: flushInterval,
+		buf:      make([]queryLogRow, 0, bufferSize),
+	}
+}
+
+// Record adds a search to the next batch.
+func (ql *QueryLog) Record(req *models.SearchRequest) {
+	filters := ""
+	if len(req.Filters) > 0 {
+		// encoding/json sorts map keys, so identical filter sets group together.
+		data, err := json.Marshal(req.Filters)
+		if err != nil {
+			return
+		}
+		filters = string(data)
+	}
+
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+	if len(ql.buf) >= ql.maxSize {
+		observability.QueryLogDropped.Inc()
+		return
+	}
+	ql.buf = append(ql.buf, queryLogRow{
+		query:     req.Query,
+		filters:   filters,
+		sort:      req.Sort,
+		pageSize:  req.PageSize,
+		region:    req.Region,
+		timestamp: time.Now().UTC(),
+	})
+}
+
+// Run flushes the buffer every flush interval until ctx is cancelled, then
+// flushes once more.
+func (ql *QueryLog) Run(ctx context.Context) {
+	ticker := time.NewTicker(ql.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ql.flush(ctx)
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			ql.flush(flushCtx)
+			cancel()
+			return
+		}
+	}
+}
+
+func (ql *QueryLog) flush(ctx context.Context) {
+	ql.mu.Lock()
+	if len(ql.buf) == 0 {
+		ql.mu.Unlock()
+		return
+	}
+	rows := ql.buf
+	ql.buf = make([]queryLogRow, 0, ql.maxSize)
+	ql.mu.Unlock()
+
+	if err := ql.client.writeQueryLog(ctx, rows); err != nil {
+		observability.QueryLogDropped.Add(float64(len(rows)))
+		ql.logger.Warn("query log flush failed", zap.Int("rows", len(rows)), zap.Error(err))
+	}
+}
+
+func (c *Client) writeQueryLog(ctx context.Context, rows []queryLogRow) error {
+	batch, err := c.conn.PrepareBatch(ctx, `
+		INSERT INTO search_query_log (
+			query, filters, sort, page_size, region, timestamp
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("preparing query log batch: %w", err)
+	}
+	for _, r := range rows {
+		if err := batch.Append(r.query, r.filters, r.sort, uint16(r.pageSize), r.region, r.timestamp); err != nil {
+			batch.Abort()
+			return fmt.Errorf("appending query log row: %w", err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("sending query log batch: %w", err)
+	}
+	return nil
+}
+
+// TopQueries returns the perRegion most frequent searches in each region over
+// the trailing window, most frequent first within a region.
+func (c *Client) TopQueries(ctx context.Context, perRegion int, window time.Duration) ([]models.SearchRequest, error) {
+	ctx, span := observability.StartSpan(ctx, "ch.top_queries")
+	defer span.End()
+
+	start := time.Now()
+
+	query := `
+		SELECT
+			region,
+			query,
+			filters,
+			sort,
+			page_size,
+			count() AS cnt
+		FROM search_query_log
+		WHERE timestamp >= now() - toIntervalSecond(?)
+		GROUP BY region, query, filters, sort, page_size
+		ORDER BY region, cnt DESC
+		LIMIT ? BY region
+	`
+
+	rows, err := c.conn.Query(trackQuery(ctx, "top_queries"), query, int64(window.Seconds()), perRegion)
+	if err != nil {
+		observability.CHQueryDuration.WithLabelValues("top_queries", "error").Observe(time.Since(start).Seconds())
+		return nil, fmt.Errorf("ch top queries: %w", err)
+	}
+	defer rows.Close()
+
+	var queries []models.SearchRequest
+	for rows.Next() {
+		var region, text, filters, sort string
+		var pageSize uint16
+		var count uint64
+		if err := rows.Scan(&region, &text, &filters, &sort, &pageSize, &count); err != nil {
+			return nil, fmt.Errorf("scanning top query row: %w", err)
+		}
+		req := models.SearchRequest{
+			Query:    text,
+			Region:   region,
+			Sort:     sort,
+			PageSize: int(pageSize),
+		}
+		if filters != "" {
+			if err := json.Unmarshal([]byte(filters), &req.Filters); err != nil {
+				c.logger.Debug("skipping top query with invalid filters", zap.String("filters", filters))
+				continue
+			}
+		}
+		queries = append(queries, req)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating top query rows: %w", err)
+	}
+
+	observability.CHQueryDuration.WithLabelValues("top_queries", "success").Observe(time.Since(start).Seconds())
+	return queries, nil
+}
@@ -16,6 +16,7 @@ type Config struct {
 	Firestore    FirestoreConfig    `yaml:"firestore"`
 	Kafka        KafkaConfig        `yaml:"kafka"`
 	Search       SearchConfig       `yaml:"search"`
+	Indexing     IndexingConfig     `yaml:"indexing"`
 	Observability ObservabilityConfig `yaml:"observability"`
 }
 
@@ -25,6 +26,9 @@ type ServerConfig struct {
 	ReadTimeout     time.Duration `yaml:"read_timeout"`
 	WriteTimeout    time.Duration `yaml:"write_timeout"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// InternalToken authenticates internal callers (X-Internal-Token header)
+	// for debug options and admin endpoints. Empty disables internal access.
+	InternalToken string `yaml:"internal_token"`
 }
 
 type ElasticsearchConfig struct {
@@ -39,6 +43,98 @@ type ElasticsearchConfig struct {
 	RefreshInterval string        `yaml:"refresh_interval"`
 	BulkSize        int           `yaml:"bulk_size"`
 	BulkFlushInterval time.Duration `yaml:"bulk_flush_interval"`
+	// AliasRefreshInterval is how often the read, write and mirror aliases
+	// are re-read, bounding how long a reindex swap takes to be followed.
+	AliasRefreshInterval time.Duration `yaml:"alias_refresh_interval"`
+	Lifecycle       IndexLifecycleConfig `yaml:"lifecycle"`
+	Replica         ElasticsearchReplicaConfig `yaml:"replica"`
+}
+
+// IndexLifecycleConfig controls upkeep of the monthly indices, carried out by
+// Elasticsearch through their ILM policy. Once a month has been over for
+// ReadOnlyAfterMonths months, its indices are force-merged down to
+// MaxSegments segments and blocked for writes; once it has been over for
+// more than RetentionMonths they are deleted (0 keeps them forever).
+type IndexLifecycleConfig struct {
+	Enabled             bool `yaml:"enabled"`
+	ReadOnlyAfterMonths int  `yaml:"read_only_after_months"`
+	MaxSegments         int  `yaml:"max_segments"`
+	RetentionMonths     int  `yaml:"retention_months"`
+}
+
+// ElasticsearchReplicaConfig is a second cluster holding a copy of the search
+// indices, queried by the replica fallback strategy. No addresses disables it.
+type ElasticsearchReplicaConfig struct {
+	Addresses []string `yaml:"addresses"`
+	Username  string   `yaml:"username"`
+	Password  string   `yaml:"password"`
+}
+
+// IndexingConfig controls how change events become search documents.
+// Pipelines are keyed by the collection whose events they transform;
+// collections without one index the standard search fields as they are.
+type IndexingConfig struct {
+	Pipelines map[string]PipelineConfig `yaml:"pipelines"`
+}
+
+// PipelineConfig is the transformation of one collection's documents. Stages
+// run in order on a copy of the source document, after which only Fields
+// (empty means the standard search fields) and the fields stages write are
+// indexed. TypeField and RegionField name the fields the index is resolved
+// from, "type" and "region" when empty.
+type PipelineConfig struct {
+	TypeField   string                `yaml:"type_field"`
+	RegionField string                `yaml:"region_field"`
+	Fields      []string              `yaml:"fields"`
+	Stages      []PipelineStageConfig `yaml:"stages"`
+}
+
+// PipelineStageConfig is one pipeline stage. Type selects the stage, which
+// reads the options below:
+//
+//	rename:     moves From to To
+//	coerce:     converts Field to As: string, int, float, bool, date or
+//	            string_list (comma-separated text becomes a list)
+//	strip_html: removes markup from the text in Fields
+//	compute:    sets Field from Source with Fn: length (characters),
+//	            lowercase, or scale (multiplied by Factor)
+//	enrich:     sets Field to the entry for Source's value in Values, or in
+//	            the JSON object read from File
+//	drop:       keeps the document out of the index when Field's value is
+//	            one of In, or when Field is absent and Missing is set
+//
+// Values are compared as text, so 1 in In matches both 1 and "1".
+type PipelineStageConfig struct {
+	Type    string         `yaml:"type"`
+	Field   string         `yaml:"field"`
+	Fields  []string       `yaml:"fields"`
+	From    string         `yaml:"from"`
+	To      string         `yaml:"to"`
+	As      string         `yaml:"as"`
+	Fn      string         `yaml:"fn"`
+	Source  string         `yaml:"source"`
+	Factor  float64        `yaml:"factor"`
+	Values  map[string]any `yaml:"values"`
+	File    string         `yaml:"file"`
+	In      []any          `yaml:"in"`
+	Missing bool           `yaml:"missing"`
+}
+
+// coerceTypes are the types a coerce stage may convert to.
+var coerceTypes = map[string]bool{
+	"string":      true,
+	"int":         true,
+	"float":       true,
+	"bool":        true,
+	"date":        true,
+	"string_list": true,
+}
+
+// computeFns are the functions a compute stage may apply.
+var computeFns = map[string]bool{
+	"length":    true,
+	"lowercase": true,
+	"scale":     true,
 }
 
 type RedisConfig struct {
@@ -51,6 +147,19 @@ type RedisConfig struct {
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
 	TTL          CacheTTLConfig `yaml:"ttl"`
+	Local        LocalCacheConfig `yaml:"local"`
+	// Compression for cached search responses: "zstd" or "none".
+	Compression  string        `yaml:"compression"`
+}
+
+// LocalCacheConfig controls the in-process L1 cache in front of Redis. MaxTTL
+// caps how long an entry may be served locally, bounding staleness should an
+// invalidation message be missed.
+type LocalCacheConfig struct {
+	Enabled             bool          `yaml:"enabled"`
+	MaxBytes            int64         `yaml:"max_bytes"`
+	MaxTTL              time.Duration `yaml:"max_ttl"`
+	InvalidationChannel string        `yaml:"invalidation_channel"`
 }
 
 type CacheTTLConfig struct {
@@ -91,6 +200,14 @@ type KafkaConfig struct {
 	BatchSize       int           `yaml:"batch_size"`
 	BatchTimeout    time.Duration `yaml:"batch_timeout"`
 	MaxRetries      int           `yaml:"max_retries"`
+	// MaxInFlight bounds messages fetched but not yet acknowledged by the
+	// indexer; fetching pauses at the limit.
+	MaxInFlight     int           `yaml:"max_in_flight"`
+	// Workers is the number of goroutines processing fetched messages.
+	// Messages for one document always go to the same worker.
+	Workers         int           `yaml:"workers"`
+	// DLQReplayRate is the default messages per second for DLQ replays.
+	DLQReplayRate   float64       `yaml:"dlq_replay_rate"`
 }
 
 type SearchConfig struct {
@@ -100,6 +217,144 @@ type SearchConfig struct {
 	CircuitBreaker  CircuitBreakerConfig `yaml:"circuit_breaker"`
 	Retry           RetryConfig   `yaml:"retry"`
 	SlowQuery       SlowQueryConfig `yaml:"slow_query"`
+	Export          ExportConfig  `yaml:"export"`
+	Coalescing      CoalescingConfig `yaml:"coalescing"`
+	StaleWhileRevalidate StaleWhileRevalidateConfig `yaml:"stale_while_revalidate"`
+	Warmer          CacheWarmerConfig `yaml:"warmer"`
+	StaticFallback  StaticFallbackConfig `yaml:"static_fallback"`
+	Hedging         HedgingConfig `yaml:"hedging"`
+	LatencyBudget   LatencyBudgetConfig `yaml:"latency_budget"`
+	Fallback        FallbackConfig `yaml:"fallback"`
+}
+
+// HedgingConfig controls hedged Elasticsearch searches. A search still
+// running after the Percentile latency of recent searches (but at least
+// MinDelay) is sent again with a different shard preference, and the first
+// answer wins. No hedges are sent until MinSamples latencies are known.
+type HedgingConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	Percentile float64       `yaml:"percentile"`
+	MinDelay   time.Duration `yaml:"min_delay"`
+	MinSamples int           `yaml:"min_samples"`
+}
+
+// LatencyBudgetConfig bounds a search's fallback chain by a deadline of Total
+// from the start of the backend search, or the request's own deadline if
+// sooner. Each strategy gets its budget share of the time left when it
+// starts. ParallelStale starts the stale cache lookup alongside the first
+// strategy instead of when its turn comes.
+type LatencyBudgetConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Total         time.Duration `yaml:"total"`
+	ParallelStale bool          `yaml:"parallel_stale"`
+}
+
+// FallbackConfig orders the strategies tried for a search, per intent
+// ("fulltext", "analytics", "faceted", "autocomplete"). Intents without a
+// chain use the "default" one.
+type FallbackConfig struct {
+	Chains map[string][]FallbackStepConfig `yaml:"chains"`
+}
+
+// FallbackStepConfig is one strategy in a fallback chain: "primary",
+// "replica", "stale_cache", "clickhouse" or "static". Timeout caps the
+// strategy; 0 means query_timeout for the Elasticsearch strategies and no cap
+// for the others. BudgetShare is the fraction of the remaining latency budget
+// it may use; 0 exempts it from the budget, so it runs even once the budget
+// is spent.
+type FallbackStepConfig struct {
+	Strategy    string        `yaml:"strategy"`
+	Timeout     time.Duration `yaml:"timeout"`
+	BudgetShare float64       `yaml:"budget_share"`
+}
+
+// fallbackStrategies are the strategy names a chain may use.
+var fallbackStrategies = map[string]bool{
+	"primary":     true,
+	"replica":     true,
+	"stale_cache": true,
+	"clickhouse":  true,
+	"static":      true,
+}
+
+// fallbackIntents are the keys a fallback chain may be configured under.
+var fallbackIntents = map[string]bool{
+	"default":      true,
+	"fulltext":     true,
+	"analytics":    true,
+	"faceted":      true,
+	"autocomplete": true,
+}
+
+// StaticFallbackConfig controls the per-region popular results served when
+// every other fallback level has failed. The lists are refreshed from
+// ClickHouse every RefreshInterval and persisted to SnapshotPath (empty
+// disables persistence) so they survive restarts with the backends down.
+type StaticFallbackConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	PerRegion       int           `yaml:"per_region"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	RefreshTimeout  time.Duration `yaml:"refresh_timeout"`
+	SnapshotPath    string        `yaml:"snapshot_path"`
+}
+
+// CacheWarmerConfig controls replaying popular searches into a cold cache.
+// Searches come from SeedFile (JSON lines of search requests) when set, and
+// otherwise from the ClickHouse query log: the TopN most frequent per region
+// over Window. WatchInterval is how often Redis is probed so a run can start
+// when it recovers; 0 disables the watch.
+type CacheWarmerConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	OnStartup     bool          `yaml:"on_startup"`
+	TopN          int           `yaml:"top_n"`
+	Window        time.Duration `yaml:"window"`
+	Concurrency   int           `yaml:"concurrency"`
+	QueryTimeout  time.Duration `yaml:"query_timeout"`
+	SeedFile      string        `yaml:"seed_file"`
+	WatchInterval time.Duration `yaml:"watch_interval"`
+	QueryLog      QueryLogConfig `yaml:"query_log"`
+}
+
+// QueryLogConfig controls recording of served searches to ClickHouse.
+// Searches are buffered in memory and dropped when BufferSize is reached
+// before the next flush.
+type QueryLogConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	BufferSize    int           `yaml:"buffer_size"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+}
+
+// StaleWhileRevalidateConfig controls background refresh of cached responses.
+// Once the fresh entry expires, the stale copy is served for up to MaxStale
+// while a refresh runs. XFetchBeta scales probabilistic early refresh of
+// fresh entries (1.0 is the standard XFetch setting, 0 disables it).
+type StaleWhileRevalidateConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	MaxStale   time.Duration `yaml:"max_stale"`
+	XFetchBeta float64       `yaml:"xfetch_beta"`
+}
+
+// CoalescingConfig controls deduplication of concurrent cache misses for the
+// same key. Within a pod identical searches always share one backend call when
+// enabled; DistributedLock additionally elects one pod per key to refresh,
+// while the others wait up to LockWait for its result to reach the cache.
+// LockTTL should cover how long a search may run, or a second pod may start
+// refreshing the key while the first is still at it.
+type CoalescingConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	DistributedLock bool          `yaml:"distributed_lock"`
+	LockTTL         time.Duration `yaml:"lock_ttl"`
+	LockWait        time.Duration `yaml:"lock_wait"`
+}
+
+// ExportConfig bounds bulk exports, which are limited separately from
+// interactive search so a few long-running exports cannot starve it.
+type ExportConfig struct {
+	MaxConcurrent     int           `yaml:"max_concurrent"`
+	PageSize          int           `yaml:"page_size"`
+	MaxDocsPerRequest int           `yaml:"max_docs_per_request"`
+	KeepAlive         time.Duration `yaml:"keep_alive"`
+	Timeout           time.Duration `yaml:"timeout"`
 }
 
 type CircuitBreakerConfig struct {
@@ -167,6 +422,12 @@ func DefaultConfig() *Config {
 			RefreshInterval: "1s",
 			BulkSize:        5000,
 			BulkFlushInterval: 5 * time.Second,
+			AliasRefreshInterval: 30 * time.Second,
+			Lifecycle: IndexLifecycleConfig{
+				Enabled:             true,
+				ReadOnlyAfterMonths: 1,
+				MaxSegments:         1,
+			},
 		},
 		Redis: RedisConfig{
 			Addresses:    []string{"localhost:6379"},
@@ -184,6 +445,13 @@ func DefaultConfig() *Config {
 				PopularQueries: 5 * time.Minute,
 				StaleFallback:  1 * time.Hour,
 			},
+			Compression: "zstd",
+			Local: LocalCacheConfig{
+				Enabled:             true,
+				MaxBytes:            64 << 20,
+				MaxTTL:              30 * time.Second,
+				InvalidationChannel: "cache:invalidate",
+			},
 		},
 		ClickHouse: ClickHouseConfig{
 			Addresses:    []string{"localhost:9000"},
@@ -207,6 +475,9 @@ func DefaultConfig() *Config {
 			BatchSize:         1000,
 			BatchTimeout:      1 * time.Second,
 			MaxRetries:        3,
+			MaxInFlight:       10000,
+			Workers:           8,
+			DLQReplayRate:     100,
 		},
 		Search: SearchConfig{
 			DefaultPageSize: 20,
@@ -228,6 +499,67 @@ func DefaultConfig() *Config {
 				WarningThreshold:  200 * time.Millisecond,
 				CriticalThreshold: 500 * time.Millisecond,
 			},
+			Export: ExportConfig{
+				MaxConcurrent:     4,
+				PageSize:          1000,
+				MaxDocsPerRequest: 100000,
+				KeepAlive:         5 * time.Minute,
+				Timeout:           2 * time.Minute,
+			},
+			Coalescing: CoalescingConfig{
+				Enabled:         true,
+				DistributedLock: false,
+				LockTTL:         2 * time.Second,
+				LockWait:        300 * time.Millisecond,
+			},
+			StaleWhileRevalidate: StaleWhileRevalidateConfig{
+				Enabled:    true,
+				MaxStale:   10 * time.Minute,
+				XFetchBeta: 1.0,
+			},
+			Warmer: CacheWarmerConfig{
+				Enabled:       true,
+				OnStartup:     true,
+				TopN:          200,
+				Window:        24 * time.Hour,
+				Concurrency:   8,
+				QueryTimeout:  2 * time.Second,
+				WatchInterval: 10 * time.Second,
+				QueryLog: QueryLogConfig{
+					Enabled:       true,
+					BufferSize:    10000,
+					FlushInterval: 5 * time.Second,
+				},
+			},
+			StaticFallback: StaticFallbackConfig{
+				Enabled:         true,
+				PerRegion:       50,
+				RefreshInterval: 15 * time.Minute,
+				RefreshTimeout:  30 * time.Second,
+				SnapshotPath:    "/var/lib/search/static_fallback.json",
+			},
+			Hedging: HedgingConfig{
+				Enabled:    true,
+				Percentile: 0.95,
+				MinDelay:   20 * time.Millisecond,
+				MinSamples: 100,
+			},
+			LatencyBudget: LatencyBudgetConfig{
+				Enabled:       true,
+				Total:         300 * time.Millisecond,
+				ParallelStale: true,
+			},
+			Fallback: FallbackConfig{
+				Chains: map[string][]FallbackStepConfig{
+					"default": {
+						{Strategy: "primary", BudgetShare: 0.6},
+						{Strategy: "replica", BudgetShare: 0.5},
+						{Strategy: "stale_cache", BudgetShare: 0.3},
+						{Strategy: "clickhouse", BudgetShare: 1},
+						{Strategy: "static"},
+					},
+				},
+			},
 		},
 		Observability: ObservabilityConfig{
 			MetricsPort:   9090,
@@ -244,17 +576,183 @@ func (c *Config) Validate() error {
 	if len(c.Elasticsearch.Addresses) == 0 {
 		return fmt.Errorf("at least one elasticsearch address required")
 	}
+	if c.Elasticsearch.AliasRefreshInterval <= 0 {
+		return fmt.Errorf("elasticsearch alias refresh interval must be positive")
+	}
+	if lc := c.Elasticsearch.Lifecycle; lc.Enabled {
+		// The current month is still being written to.
+		if lc.ReadOnlyAfterMonths < 1 || lc.MaxSegments < 1 {
+			return fmt.Errorf("index lifecycle read only after months and max segments must be at least 1")
+		}
+		if lc.RetentionMonths < 0 {
+			return fmt.Errorf("index lifecycle retention months must not be negative")
+		}
+		// ILM phases run in order, so deletion cannot come before the
+		// write block.
+		if lc.RetentionMonths > 0 && lc.RetentionMonths <= lc.ReadOnlyAfterMonths {
+			return fmt.Errorf("index lifecycle retention months must exceed read only after months")
+		}
+	}
 	if len(c.Redis.Addresses) == 0 {
 		return fmt.Errorf("at least one redis address required")
 	}
 	if len(c.Kafka.Brokers) == 0 {
 		return fmt.Errorf("at least one kafka broker required")
 	}
+	if c.Kafka.MaxInFlight <= 0 {
+		return fmt.Errorf("kafka max in flight must be positive")
+	}
+	if c.Kafka.Workers <= 0 {
+		return fmt.Errorf("kafka workers must be positive")
+	}
+	if c.Kafka.DLQReplayRate <= 0 {
+		return fmt.Errorf("kafka dlq replay rate must be positive")
+	}
+	if c.Redis.Compression != "zstd" && c.Redis.Compression != "none" {
+		return fmt.Errorf("redis compression must be 'zstd' or 'none', got %q", c.Redis.Compression)
+	}
+	if c.Redis.Local.Enabled && c.Redis.Local.MaxBytes <= 0 {
+		return fmt.Errorf("local cache max bytes must be positive when enabled")
+	}
 	if c.Search.DefaultPageSize <= 0 {
 		return fmt.Errorf("default page size must be positive")
 	}
 	if c.Search.MaxPageSize <= 0 || c.Search.MaxPageSize > 1000 {
 		return fmt.Errorf("max page size must be between 1 and 1000")
 	}
+	if c.Search.Coalescing.Enabled && c.Search.Coalescing.LockTTL <= 0 {
+		return fmt.Errorf("coalescing lock ttl must be positive when enabled")
+	}
+	if c.Search.StaleWhileRevalidate.XFetchBeta < 0 {
+		return fmt.Errorf("xfetch beta must not be negative")
+	}
+	if c.Search.Warmer.Enabled {
+		if c.Search.Warmer.TopN <= 0 {
+			return fmt.Errorf("cache warmer top n must be positive when enabled")
+		}
+		if c.Search.Warmer.Concurrency <= 0 {
+			return fmt.Errorf("cache warmer concurrency must be positive when enabled")
+		}
+		if c.Search.Warmer.QueryTimeout <= 0 {
+			return fmt.Errorf("cache warmer query timeout must be positive when enabled")
+		}
+	}
+	if c.Search.Warmer.QueryLog.Enabled && (c.Search.Warmer.QueryLog.BufferSize <= 0 || c.Search.Warmer.QueryLog.FlushInterval <= 0) {
+		return fmt.Errorf("query log buffer size and flush interval must be positive when enabled")
+	}
+	if c.Search.StaticFallback.Enabled {
+		if c.Search.StaticFallback.PerRegion <= 0 {
+			return fmt.Errorf("static fallback per region must be positive when enabled")
+		}
+		if c.Search.StaticFallback.RefreshInterval <= 0 || c.Search.StaticFallback.RefreshTimeout <= 0 {
+			return fmt.Errorf("static fallback refresh interval and timeout must be positive when enabled")
+		}
+	}
+	if c.Search.Hedging.Enabled {
+		if c.Search.Hedging.Percentile <= 0 || c.Search.Hedging.Percentile >= 1 {
+			return fmt.Errorf("hedging percentile must be between 0 and 1 exclusive")
+		}
+		if c.Search.Hedging.MinSamples <= 0 {
+			return fmt.Errorf("hedging min samples must be positive when enabled")
+		}
+	}
+	if c.Search.LatencyBudget.Enabled && c.Search.LatencyBudget.Total < 0 {
+		return fmt.Errorf("latency budget total must not be negative")
+	}
+	if err := c.Search.Fallback.validate(); err != nil {
+		return err
+	}
+	if err := c.Indexing.validate(); err != nil {
+		return err
+	}
+	if c.Search.Export.MaxConcurrent <= 0 {
+		return fmt.Errorf("export max concurrent must be positive")
+	}
+	if c.Search.Export.PageSize <= 0 || c.Search.Export.PageSize > 10000 {
+		return fmt.Errorf("export page size must be between 1 and 10000")
+	}
+	if c.Search.Export.MaxDocsPerRequest <= 0 {
+		return fmt.Errorf("export max docs per request must be positive")
+	}
+	return nil
+}
+
+func (f FallbackConfig) validate() error {
+	if len(f.Chains["default"]) == 0 {
+		return fmt.Errorf("fallback chain \"default\" must have at least one strategy")
+	}
+	for intent, chain := range f.Chains {
+		if !fallbackIntents[intent] {
+			return fmt.Errorf("fallback chain for unknown intent %q", intent)
+		}
+		if len(chain) == 0 {
+			return fmt.Errorf("fallback chain %q must have at least one strategy", intent)
+		}
+		seen := make(map[string]bool, len(chain))
+		for _, step := range chain {
+			if !fallbackStrategies[step.Strategy] {
+				return fmt.Errorf("fallback chain %q: unknown strategy %q", intent, step.Strategy)
+			}
+			if seen[step.Strategy] {
+				return fmt.Errorf("fallback chain %q: strategy %q listed twice", intent, step.Strategy)
+			}
+			seen[step.Strategy] = true
+			if step.Timeout < 0 {
+				return fmt.Errorf("fallback chain %q: strategy %q timeout must not be negative", intent, step.Strategy)
+			}
+			if step.BudgetShare < 0 || step.BudgetShare > 1 {
+				return fmt.Errorf("fallback chain %q: strategy %q budget share must be between 0 and 1", intent, step.Strategy)
+			}
+		}
+	}
+	return nil
+}
+
+func (ic IndexingConfig) validate() error {
+	for collection, p := range ic.Pipelines {
+		for i, st := range p.Stages {
+			if err := st.validate(); err != nil {
+				return fmt.Errorf("indexing pipeline %q stage %d: %w", collection, i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (st PipelineStageConfig) validate() error {
+	switch st.Type {
+	case "rename":
+		if st.From == "" || st.To == "" || st.From == st.To {
+			return fmt.Errorf("rename needs distinct from and to fields")
+		}
+	case "coerce":
+		if st.Field == "" || !coerceTypes[st.As] {
+			return fmt.Errorf("coerce needs a field and a known type, got %q", st.As)
+		}
+	case "strip_html":
+		if len(st.Fields) == 0 {
+			return fmt.Errorf("strip_html needs at least one field")
+		}
+	case "compute":
+		if st.Field == "" || st.Source == "" || !computeFns[st.Fn] {
+			return fmt.Errorf("compute needs a field, a source and a known fn, got %q", st.Fn)
+		}
+		if st.Fn == "scale" && st.Factor == 0 {
+			return fmt.Errorf("compute scale needs a non-zero factor")
+		}
+	case "enrich":
+		if st.Field == "" || st.Source == "" {
+			return fmt.Errorf("enrich needs a field and a source")
+		}
+		if (len(st.Values) == 0) == (st.File == "") {
+			return fmt.Errorf("enrich needs either values or a file")
+		}
+	case "drop":
+		if st.Field == "" || (len(st.In) == 0 && !st.Missing) {
+			return fmt.Errorf("drop needs a field and values to match or missing")
+		}
+	default:
+		return fmt.Errorf("unknown stage type %q", st.Type)
+	}
 	return nil
 }
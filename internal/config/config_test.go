@@ -100,6 +100,249 @@ func TestValidate_InvalidPort(t *testing.T) {
 	}
 }
 
+func TestValidate_InvalidExport(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"zero concurrency", func(c *Config) { c.Search.Export.MaxConcurrent = 0 }},
+		{"zero page size", func(c *Config) { c.Search.Export.PageSize = 0 }},
+		{"page size too large", func(c *Config) { c.Search.Export.PageSize = 10001 }},
+		{"zero max docs", func(c *Config) { c.Search.Export.MaxDocsPerRequest = 0 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestValidate_LocalCache(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Redis.Local.MaxBytes = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for enabled local cache without a size bound")
+	}
+
+	cfg.Redis.Local.Enabled = false
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected disabled local cache to need no size bound, got %v", err)
+	}
+}
+
+func TestValidate_Coalescing(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Search.Coalescing.LockTTL = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for enabled coalescing without a lock ttl")
+	}
+
+	cfg.Search.Coalescing.Enabled = false
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected disabled coalescing to need no lock ttl, got %v", err)
+	}
+}
+
+func TestValidate_NegativeXFetchBeta(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Search.StaleWhileRevalidate.XFetchBeta = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative xfetch beta")
+	}
+}
+
+func TestValidate_RedisCompression(t *testing.T) {
+	for _, c := range []string{"zstd", "none"} {
+		cfg := DefaultConfig()
+		cfg.Redis.Compression = c
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected compression %q to be valid, got %v", c, err)
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.Redis.Compression = "gzip"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unsupported compression")
+	}
+}
+
+func TestValidate_CacheWarmer(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"zero top n", func(c *Config) { c.Search.Warmer.TopN = 0 }},
+		{"zero concurrency", func(c *Config) { c.Search.Warmer.Concurrency = 0 }},
+		{"zero query timeout", func(c *Config) { c.Search.Warmer.QueryTimeout = 0 }},
+		{"zero query log buffer", func(c *Config) { c.Search.Warmer.QueryLog.BufferSize = 0 }},
+		{"zero query log flush interval", func(c *Config) { c.Search.Warmer.QueryLog.FlushInterval = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+
+	cfg := DefaultConfig()
+	cfg.Search.Warmer.Enabled = false
+	cfg.Search.Warmer.QueryLog.Enabled = false
+	cfg.Search.Warmer.Concurrency = 0
+	cfg.Search.Warmer.QueryLog.BufferSize = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected disabled warmer to skip validation, got %v", err)
+	}
+}
+
+func TestValidate_StaticFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"zero per region", func(c *Config) { c.Search.StaticFallback.PerRegion = 0 }},
+		{"zero refresh interval", func(c *Config) { c.Search.StaticFallback.RefreshInterval = 0 }},
+		{"zero refresh timeout", func(c *Config) { c.Search.StaticFallback.RefreshTimeout = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestValidate_IndexLifecycle(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"current month read only", func(c *Config) { c.Elasticsearch.Lifecycle.ReadOnlyAfterMonths = 0 }},
+		{"zero max segments", func(c *Config) { c.Elasticsearch.Lifecycle.MaxSegments = 0 }},
+		{"negative retention", func(c *Config) { c.Elasticsearch.Lifecycle.RetentionMonths = -1 }},
+		{"retention before read only", func(c *Config) {
+			c.Elasticsearch.Lifecycle.ReadOnlyAfterMonths = 3
+			c.Elasticsearch.Lifecycle.RetentionMonths = 3
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestValidate_HedgingAndLatencyBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"zero percentile", func(c *Config) { c.Search.Hedging.Percentile = 0 }},
+		{"percentile of one", func(c *Config) { c.Search.Hedging.Percentile = 1 }},
+		{"zero min samples", func(c *Config) { c.Search.Hedging.MinSamples = 0 }},
+		{"negative total", func(c *Config) { c.Search.LatencyBudget.Total = -time.Second }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestValidate_FallbackChains(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"no default chain", func(c *Config) { delete(c.Search.Fallback.Chains, "default") }},
+		{"unknown intent", func(c *Config) {
+			c.Search.Fallback.Chains["geo"] = []FallbackStepConfig{{Strategy: "primary"}}
+		}},
+		{"empty chain", func(c *Config) { c.Search.Fallback.Chains["faceted"] = nil }},
+		{"unknown strategy", func(c *Config) {
+			c.Search.Fallback.Chains["default"] = []FallbackStepConfig{{Strategy: "memcached"}}
+		}},
+		{"duplicate strategy", func(c *Config) {
+			c.Search.Fallback.Chains["default"] = []FallbackStepConfig{{Strategy: "primary"}, {Strategy: "primary"}}
+		}},
+		{"negative timeout", func(c *Config) {
+			c.Search.Fallback.Chains["default"] = []FallbackStepConfig{{Strategy: "primary", Timeout: -time.Second}}
+		}},
+		{"budget share above one", func(c *Config) {
+			c.Search.Fallback.Chains["default"] = []FallbackStepConfig{{Strategy: "primary", BudgetShare: 1.5}}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+			if err := cfg.Validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+
+	cfg := DefaultConfig()
+	cfg.Search.Fallback.Chains["autocomplete"] = []FallbackStepConfig{
+		{Strategy: "primary", Timeout: 50 * time.Millisecond},
+		{Strategy: "static"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid autocomplete chain, got %v", err)
+	}
+}
+
+func TestValidate_KafkaMaxInFlight(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Kafka.MaxInFlight = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for zero kafka max in flight")
+	}
+}
+
+func TestValidate_KafkaWorkers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Kafka.Workers = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for zero kafka workers")
+	}
+}
+
+func TestValidate_KafkaDLQReplayRate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Kafka.DLQReplayRate = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for zero dlq replay rate")
+	}
+}
+
+func TestValidate_ESAliasRefreshInterval(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Elasticsearch.AliasRefreshInterval = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for zero alias refresh interval")
+	}
+}
+
 func TestValidate_EmptyESAddresses(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.Elasticsearch.Addresses = nil
@@ -348,3 +591,46 @@ search:
 		t.Errorf("expected default bulk size preserved, got %d", cfg.Elasticsearch.BulkSize)
 	}
 }
+
+func TestValidate_IndexingPipelines(t *testing.T) {
+	tests := []struct {
+		name  string
+		stage PipelineStageConfig
+	}{
+		{"unknown stage", PipelineStageConfig{Type: "uppercase", Field: "title"}},
+		{"rename onto itself", PipelineStageConfig{Type: "rename", From: "name", To: "name"}},
+		{"unknown coerce type", PipelineStageConfig{Type: "coerce", Field: "price", As: "decimal"}},
+		{"strip_html without fields", PipelineStageConfig{Type: "strip_html"}},
+		{"unknown compute fn", PipelineStageConfig{Type: "compute", Field: "x", Source: "title", Fn: "hash"}},
+		{"scale without factor", PipelineStageConfig{Type: "compute", Field: "price", Source: "price_cents", Fn: "scale"}},
+		{"enrich without values", PipelineStageConfig{Type: "enrich", Field: "category_name", Source: "category"}},
+		{"enrich with values and file", PipelineStageConfig{
+			Type: "enrich", Field: "category_name", Source: "category",
+			Values: map[string]any{"a": "A"}, File: "/etc/search/categories.json",
+		}},
+		{"drop without condition", PipelineStageConfig{Type: "drop", Field: "status"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Indexing.Pipelines = map[string]PipelineConfig{
+				"documents": {Stages: []PipelineStageConfig{tt.stage}},
+			}
+			if err := cfg.Validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+
+	cfg := DefaultConfig()
+	cfg.Indexing.Pipelines = map[string]PipelineConfig{
+		"documents": {Stages: []PipelineStageConfig{
+			{Type: "rename", From: "name", To: "title"},
+			{Type: "compute", Field: "price", Source: "price_cents", Fn: "scale", Factor: 0.01},
+			{Type: "drop", Field: "status", In: []any{"draft"}},
+		}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid pipeline, got %v", err)
+	}
+}
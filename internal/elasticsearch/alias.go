@@ -0,0 +1,252 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// Index generations are single indices named {prefix}_gen_{timestamp}, read
+// and written through aliases. The underscore keeps generations and aliases
+// out of the {prefix}-* pattern that covers the monthly indices, so either
+// layout can be searched without seeing the other.
+//
+//   - the read alias is what searches query once it exists;
+//   - the write alias is where the stream processor indexes once it exists;
+//   - the mirror alias, while it exists, receives a copy of every write: the
+//     new generation during a reindex, the old one after a swap so that a
+//     rollback loses nothing.
+
+// ReadAlias returns the alias searches query.
+func ReadAlias(prefix string) string { return prefix + "_read" }
+
+// WriteAlias returns the alias live changes are indexed through.
+func WriteAlias(prefix string) string { return prefix + "_write" }
+
+// MirrorAlias returns the alias that receives a copy of every live change.
+func MirrorAlias(prefix string) string { return prefix + "_mirror" }
+
+// GenerationIndex returns the name of a new index generation created at t.
+func GenerationIndex(prefix string, t time.Time) string {
+	return prefix + "_gen_" + t.UTC().Format("20060102150405")
+}
+
+// IsAlias reports whether index names one of the service's aliases.
+func (c *Client) IsAlias(index string) bool {
+	prefix := c.cfg.IndexPrefix
+	return index == ReadAlias(prefix) || index == WriteAlias(prefix) || index == MirrorAlias(prefix)
+}
+
+// aliasState records which of the service's aliases exist, as last seen by
+// RefreshAliases.
+type aliasState struct {
+	read   bool
+	write  bool
+	mirror bool
+}
+
+// SearchAlias returns the read alias if it exists, or "" when searches
+// should use the monthly index pattern.
+func (c *Client) SearchAlias() string {
+	if st := c.aliases.Load(); st != nil && st.read {
+		return ReadAlias(c.cfg.IndexPrefix)
+	}
+	return ""
+}
+
+// MirrorIndex returns the mirror alias if it exists, or "" when writes are
+// not being copied anywhere.
+func (c *Client) MirrorIndex() string {
+	if st := c.aliases.Load(); st != nil && st.mirror {
+		return MirrorAlias(c.cfg.IndexPrefix)
+	}
+	return ""
+}
+
+// RefreshAliases reloads which aliases exist.
+func (c *Client) RefreshAliases(ctx context.Context) error {
+	aliases, err := c.Aliases(ctx)
+	if err != nil {
+		return err
+	}
+	prefix := c.cfg.IndexPrefix
+	st := &aliasState{
+		read:   len(aliases[ReadAlias(prefix)]) > 0,
+		write:  len(aliases[WriteAlias(prefix)]) > 0,
+		mirror: len(aliases[MirrorAlias(prefix)]) > 0,
+	}
+	if prev := c.aliases.Swap(st); prev == nil || *prev != *st {
+		c.logger.Info("elasticsearch aliases changed",
+			zap.Bool("read", st.read),
+			zap.Bool("write", st.write),
+			zap.Bool("mirror", st.mirror),
+		)
+	}
+	return nil
+}
+
+// WatchAliases refreshes the alias state every interval so a reindex swap
+// started elsewhere is followed by this process. It returns when ctx is
+// cancelled.
+func (c *Client) WatchAliases(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		refreshCtx, cancel := context.WithTimeout(ctx, interval)
+		if err := c.RefreshAliases(refreshCtx); err != nil {
+			c.logger.Warn("refreshing elasticsearch aliases failed", zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// Aliases returns the indices each of the service's aliases points to. An
+// alias that does not exist is absent from the map.
+func (c *Client) Aliases(ctx context.Context) (map[string][]string, error) {
+	prefix := c.cfg.IndexPrefix
+	res, err := c.es.Indices.GetAlias(
+		c.es.Indices.GetAlias.WithContext(ctx),
+		c.es.Indices.GetAlias.WithName(ReadAlias(prefix), WriteAlias(prefix), MirrorAlias(prefix)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("getting aliases: %w", err)
+	}
+	defer res.Body.Close()
+
+	// A 404 still lists the aliases that do exist, next to an error naming
+	// the missing ones.
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("get aliases error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding aliases response: %w", err)
+	}
+
+	aliases := make(map[string][]string)
+	for index, raw := range body {
+		var entry struct {
+			Aliases map[string]json.RawMessage `json:"aliases"`
+		}
+		if index == "error" || index == "status" || json.Unmarshal(raw, &entry) != nil {
+			continue
+		}
+		for alias := range entry.Aliases {
+			aliases[alias] = append(aliases[alias], index)
+		}
+	}
+	return aliases, nil
+}
+
+// UpdateAliases applies alias add/remove actions atomically.
+func (c *Client) UpdateAliases(ctx context.Context, actions []map[string]any) error {
+	body, err := json.Marshal(map[string]any{"actions": actions})
+	if err != nil {
+		return fmt.Errorf("marshaling alias actions: %w", err)
+	}
+	res, err := c.es.Indices.UpdateAliases(
+		bytes.NewReader(body),
+		c.es.Indices.UpdateAliases.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("updating aliases: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("update aliases error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+	return nil
+}
+
+// CreateIndex creates index with the given settings and mappings body. A nil
+// body leaves both to the matching index template.
+func (c *Client) CreateIndex(ctx context.Context, index string, body map[string]any) error {
+	opts := []func(*esapi.IndicesCreateRequest){c.es.Indices.Create.WithContext(ctx)}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshaling index body: %w", err)
+		}
+		opts = append(opts, c.es.Indices.Create.WithBody(bytes.NewReader(data)))
+	}
+	res, err := c.es.Indices.Create(index, opts...)
+	if err != nil {
+		return fmt.Errorf("creating index %s: %w", index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("create index error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+	return nil
+}
+
+// DeleteIndex deletes index.
+func (c *Client) DeleteIndex(ctx context.Context, index string) error {
+	res, err := c.es.Indices.Delete(
+		[]string{index},
+		c.es.Indices.Delete.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("deleting index %s: %w", index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("delete index error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+	return nil
+}
+
+// Count refreshes the indices matching target and returns their document
+// count, so counts taken right after indexing are comparable.
+func (c *Client) Count(ctx context.Context, target string) (int64, error) {
+	refresh, err := c.es.Indices.Refresh(
+		c.es.Indices.Refresh.WithContext(ctx),
+		c.es.Indices.Refresh.WithIndex(target),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("refreshing %s: %w", target, err)
+	}
+	refresh.Body.Close()
+
+	res, err := c.es.Count(
+		c.es.Count.WithContext(ctx),
+		c.es.Count.WithIndex(target),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("counting %s: %w", target, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return 0, fmt.Errorf("count error status=%s body=%s", res.Status(), strings.TrimSpace(string(bodyBytes)))
+	}
+	var count struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&count); err != nil {
+		return 0, fmt.Errorf("decoding count response: %w", err)
+	}
+	return count.Count, nil
+}
@@ -6,10 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
+	"net/http"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"github.com/sony/gobreaker"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
@@ -21,14 +24,36 @@ import (
 )
 
 type Client struct {
-	es      *elasticsearch.Client
-	cb      *gobreaker.CircuitBreaker
-	cfg     config.ElasticsearchConfig
+	es       *elasticsearch.Client
+	cb       *gobreaker.CircuitBreaker
+	cfg      config.ElasticsearchConfig
 	retryCfg resilience.RetryConfig
-	logger  *zap.Logger
+	logger   *zap.Logger
+
+	// latency drives hedged searches; nil when hedging is disabled.
+	latency       *latencyTracker
+	hedgeMinDelay time.Duration
+
+	// aliases is which index aliases exist; nil until RefreshAliases runs,
+	// which is treated as the monthly index layout.
+	aliases atomic.Pointer[aliasState]
 }
 
 func NewClient(cfg config.ElasticsearchConfig, searchCfg config.SearchConfig, logger *zap.Logger) (*Client, error) {
+	return newClient("primary", cfg, searchCfg, logger)
+}
+
+// NewReplicaClient connects to the replica cluster configured in
+// cfg.Replica, used by the replica fallback strategy. Index settings are
+// shared with the primary cluster.
+func NewReplicaClient(cfg config.ElasticsearchConfig, searchCfg config.SearchConfig, logger *zap.Logger) (*Client, error) {
+	cfg.Addresses = cfg.Replica.Addresses
+	cfg.Username = cfg.Replica.Username
+	cfg.Password = cfg.Replica.Password
+	return newClient("replica", cfg, searchCfg, logger)
+}
+
+func newClient(cluster string, cfg config.ElasticsearchConfig, searchCfg config.SearchConfig, logger *zap.Logger) (*Client, error) {
 	esCfg := elasticsearch.Config{
 		Addresses:  cfg.Addresses,
 		Username:   cfg.Username,
@@ -51,9 +76,17 @@ func NewClient(cfg config.ElasticsearchConfig, searchCfg config.SearchConfig, lo
 		return nil, fmt.Errorf("elasticsearch ping returned status: %s", res.Status())
 	}
 
-	cb := resilience.NewCircuitBreaker("elasticsearch-primary", searchCfg.CircuitBreaker, logger)
+	cb := resilience.NewCircuitBreaker("elasticsearch-"+cluster, searchCfg.CircuitBreaker, logger)
+
+	logger.Info("elasticsearch client connected",
+		zap.String("cluster", cluster),
+		zap.Strings("addresses", cfg.Addresses),
+	)
 
-	logger.Info("elasticsearch client connected", zap.Strings("addresses", cfg.Addresses))
+	var latency *latencyTracker
+	if searchCfg.Hedging.Enabled {
+		latency = newLatencyTracker(searchCfg.Hedging.Percentile, searchCfg.Hedging.MinSamples)
+	}
 
 	return &Client{
 		es:  es,
@@ -65,7 +98,9 @@ func NewClient(cfg config.ElasticsearchConfig, searchCfg config.SearchConfig, lo
 			MaxWait:     searchCfg.Retry.MaxWait,
 			Multiplier:  searchCfg.Retry.Multiplier,
 		},
-		logger: logger,
+		logger:        logger,
+		latency:       latency,
+		hedgeMinDelay: searchCfg.Hedging.MinDelay,
 	}, nil
 }
 
@@ -75,6 +110,9 @@ type SearchResult struct {
 	TookMs    int64
 	ShardsHit int
 	TimedOut  bool
+	Profile   []models.ShardProfile
+	// SpellSuggestion is the top phrase suggestion for the query, if any.
+	SpellSuggestion string
 }
 
 func (c *Client) Search(ctx context.Context, index string, query map[string]any) (*SearchResult, error) {
@@ -90,7 +128,7 @@ func (c *Client) Search(ctx context.Context, index string, query map[string]any)
 		var retryResult *SearchResult
 		retryErr := resilience.Retry(ctx, c.retryCfg, func() error {
 			var execErr error
-			retryResult, execErr = c.executeSearch(ctx, index, query)
+			retryResult, execErr = c.hedgedSearch(ctx, index, query)
 			return execErr
 		})
 		return retryResult, retryErr
@@ -114,19 +152,25 @@ func (c *Client) Search(ctx context.Context, index string, query map[string]any)
 	return result, nil
 }
 
-func (c *Client) executeSearch(ctx context.Context, index string, query map[string]any) (*SearchResult, error) {
+// executeSearch runs one search request. A non-empty preference routes it to
+// a consistent set of shard copies.
+func (c *Client) executeSearch(ctx context.Context, index string, query map[string]any, preference string) (*SearchResult, error) {
 	body, err := json.Marshal(query)
 	if err != nil {
 		return nil, fmt.Errorf("marshaling es query: %w", err)
 	}
 
-	res, err := c.es.Search(
+	opts := []func(*esapi.SearchRequest){
 		c.es.Search.WithContext(ctx),
 		c.es.Search.WithIndex(index),
 		c.es.Search.WithBody(bytes.NewReader(body)),
 		c.es.Search.WithTimeout(c.cfg.RequestTimeout),
 		c.es.Search.WithTrackTotalHits(true),
-	)
+	}
+	if preference != "" {
+		opts = append(opts, c.es.Search.WithPreference(preference))
+	}
+	res, err := c.es.Search(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("executing es search: %w", err)
 	}
@@ -142,6 +186,11 @@ func (c *Client) executeSearch(ctx context.Context, index string, query map[stri
 		return nil, fmt.Errorf("decoding es response: %w", err)
 	}
 
+	return toSearchResult(&esResp), nil
+}
+
+// toSearchResult converts a raw ES search response into a SearchResult.
+func toSearchResult(esResp *esSearchResponse) *SearchResult {
 	hits := make([]models.SearchResult, 0, len(esResp.Hits.Hits))
 	for _, h := range esResp.Hits.Hits {
 		hit := models.SearchResult{
@@ -175,6 +224,9 @@ func (c *Client) executeSearch(ctx context.Context, index string, query map[stri
 		if h.Highlight != nil {
 			hit.Highlights = h.Highlight
 		}
+		if h.Explanation != nil {
+			hit.Explanation = h.Explanation
+		}
 		hits = append(hits, hit)
 	}
 
@@ -184,14 +236,55 @@ func (c *Client) executeSearch(ctx context.Context, index string, query map[stri
 		TookMs:    esResp.Took,
 		ShardsHit: esResp.Shards.Total,
 		TimedOut:  esResp.TimedOut,
-	}, nil
+		Profile:   shardProfiles(esResp.Profile),
+
+		SpellSuggestion: topSuggestion(esResp.Suggest["spell_suggest"]),
+	}
 }
 
-func (c *Client) BulkIndex(ctx context.Context, actions []models.IndexAction) error {
-	if len(actions) == 0 {
+// topSuggestion returns the highest-ranked option of a phrase suggester.
+func topSuggestion(entries []esSuggestEntry) string {
+	for _, e := range entries {
+		if len(e.Options) > 0 {
+			return e.Options[0].Text
+		}
+	}
+	return ""
+}
+
+// shardProfiles flattens the ES profile API output into per-shard totals.
+// Nested query breakdowns are summed at the top level only, since child
+// timings are already included in their parent's time_in_nanos.
+func shardProfiles(p *esProfile) []models.ShardProfile {
+	if p == nil || len(p.Shards) == 0 {
 		return nil
 	}
 
+	profiles := make([]models.ShardProfile, 0, len(p.Shards))
+	for _, shard := range p.Shards {
+		sp := models.ShardProfile{ShardID: shard.ID}
+		for _, search := range shard.Searches {
+			for _, q := range search.Query {
+				sp.QueryNanos += q.TimeInNanos
+			}
+			sp.RewriteNanos += search.RewriteTime
+			for _, c := range search.Collector {
+				sp.CollectorNanos += c.TimeInNanos
+			}
+		}
+		profiles = append(profiles, sp)
+	}
+	return profiles
+}
+
+// BulkIndex sends actions in one bulk request. An error means the request as
+// a whole failed; otherwise the result holds one outcome per action, in order,
+// since Elasticsearch applies (or rejects) each item independently.
+func (c *Client) BulkIndex(ctx context.Context, actions []models.IndexAction) ([]BulkItemResult, error) {
+	if len(actions) == 0 {
+		return nil, nil
+	}
+
 	ctx, span := observability.StartSpan(ctx, "es.bulk_index",
 		attribute.Int("batch_size", len(actions)),
 	)
@@ -205,15 +298,28 @@ func (c *Client) BulkIndex(ctx context.Context, actions []models.IndexAction) er
 				"_id":    action.ID,
 			},
 		}
-		if action.Routing != "" {
-			if inner, ok := meta[action.Action].(map[string]any); ok {
+		if inner, ok := meta[action.Action].(map[string]any); ok {
+			if action.Routing != "" {
 				inner["routing"] = action.Routing
 			}
+			// external_gte rather than external so a redelivered event, or a
+			// backfill of the same version, rewrites the document instead of
+			// conflicting.
+			if action.Version > 0 {
+				inner["version"] = action.Version
+				inner["version_type"] = "external_gte"
+			}
+			// Once a reindex step removes an alias, a write through it
+			// must fail rather than create an index of that name, which
+			// would keep the alias from being added back.
+			if action.Action != "delete" && c.IsAlias(action.Index) {
+				inner["require_alias"] = true
+			}
 		}
 
 		metaLine, err := json.Marshal(meta)
 		if err != nil {
-			return fmt.Errorf("marshaling bulk meta: %w", err)
+			return nil, fmt.Errorf("marshaling bulk meta: %w", err)
 		}
 		buf.Write(metaLine)
 		buf.WriteByte('\n')
@@ -221,7 +327,7 @@ func (c *Client) BulkIndex(ctx context.Context, actions []models.IndexAction) er
 		if action.Action != "delete" && action.Body != nil {
 			bodyLine, err := json.Marshal(action.Body)
 			if err != nil {
-				return fmt.Errorf("marshaling bulk body: %w", err)
+				return nil, fmt.Errorf("marshaling bulk body: %w", err)
 			}
 			buf.Write(bodyLine)
 			buf.WriteByte('\n')
@@ -233,36 +339,48 @@ func (c *Client) BulkIndex(ctx context.Context, actions []models.IndexAction) er
 		c.es.Bulk.WithContext(ctx),
 	)
 	if err != nil {
-		return fmt.Errorf("executing bulk request: %w", err)
+		return nil, fmt.Errorf("executing bulk request: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
 		bodyBytes, _ := io.ReadAll(res.Body)
-		return fmt.Errorf("bulk request error status=%s body=%s", res.Status(), string(bodyBytes))
+		return nil, fmt.Errorf("bulk request error status=%s body=%s", res.Status(), string(bodyBytes))
 	}
 
 	var bulkResp bulkResponse
 	if err := json.NewDecoder(res.Body).Decode(&bulkResp); err != nil {
-		return fmt.Errorf("decoding bulk response: %w", err)
+		return nil, fmt.Errorf("decoding bulk response: %w", err)
 	}
 
-	if bulkResp.Errors {
-		var errMsgs []string
-		for _, item := range bulkResp.Items {
-			for _, result := range item {
-				if result.Error != nil {
-					errMsgs = append(errMsgs, fmt.Sprintf("id=%s: %s", result.ID, result.Error.Reason))
-				}
+	if len(bulkResp.Items) != len(actions) {
+		return nil, fmt.Errorf("bulk response has %d items for %d actions", len(bulkResp.Items), len(actions))
+	}
+
+	results := make([]BulkItemResult, len(actions))
+	failed := 0
+	for i, item := range bulkResp.Items {
+		for _, result := range item {
+			results[i] = BulkItemResult{ID: result.ID, Status: result.Status}
+			if result.Error != nil {
+				results[i].ErrorType = result.Error.Type
+				results[i].Reason = result.Error.Reason
+				failed++
 			}
 		}
-		return fmt.Errorf("bulk indexing had errors: %s", strings.Join(errMsgs, "; "))
+		observability.ESBulkItemsTotal.WithLabelValues(strconv.Itoa(results[i].Status)).Inc()
 	}
+	span.SetAttributes(attribute.Int("failed_items", failed))
 
-	return nil
+	return results, nil
 }
 
+// ResolveIndex returns the index a document is written to: the write alias
+// once it exists, otherwise the monthly index for its type and region.
 func (c *Client) ResolveIndex(docType, region string) string {
+	if st := c.aliases.Load(); st != nil && st.write {
+		return WriteAlias(c.cfg.IndexPrefix)
+	}
 	now := time.Now()
 	return fmt.Sprintf("%s-%s-%s-%s", c.cfg.IndexPrefix, docType, region, now.Format("2006.01"))
 }
@@ -307,18 +425,85 @@ type esSearchResponse struct {
 		} `json:"total"`
 		Hits []esHit `json:"hits"`
 	} `json:"hits"`
+	Profile *esProfile                  `json:"profile,omitempty"`
+	Suggest map[string][]esSuggestEntry `json:"suggest,omitempty"`
+}
+
+type esSuggestEntry struct {
+	Text    string `json:"text"`
+	Options []struct {
+		Text  string  `json:"text"`
+		Score float64 `json:"score"`
+	} `json:"options"`
+}
+
+type esProfile struct {
+	Shards []struct {
+		ID       string `json:"id"`
+		Searches []struct {
+			Query []struct {
+				Type        string `json:"type"`
+				TimeInNanos int64  `json:"time_in_nanos"`
+			} `json:"query"`
+			RewriteTime int64 `json:"rewrite_time"`
+			Collector   []struct {
+				Name        string `json:"name"`
+				TimeInNanos int64  `json:"time_in_nanos"`
+			} `json:"collector"`
+		} `json:"searches"`
+	} `json:"shards"`
 }
 
 type esHit struct {
-	Index     string              `json:"_index"`
-	ID        string              `json:"_id"`
-	Score     float64             `json:"_score"`
-	Source    map[string]any      `json:"_source"`
-	Highlight map[string][]string `json:"highlight,omitempty"`
+	Index       string              `json:"_index"`
+	ID          string              `json:"_id"`
+	Score       float64             `json:"_score"`
+	Source      map[string]any      `json:"_source"`
+	Highlight   map[string][]string `json:"highlight,omitempty"`
+	Explanation map[string]any      `json:"_explanation,omitempty"`
+}
+
+// BulkItemResult is Elasticsearch's outcome for a single bulk action.
+type BulkItemResult struct {
+	ID        string
+	Status    int
+	ErrorType string
+	Reason    string
+}
+
+// Failed reports whether Elasticsearch rejected the action. A delete of a
+// missing document is a 404 without an error and counts as applied.
+func (r BulkItemResult) Failed() bool {
+	return r.ErrorType != ""
+}
+
+// VersionConflict reports whether the action was skipped because the index
+// already holds a newer version of the document.
+func (r BulkItemResult) VersionConflict() bool {
+	return r.Status == http.StatusConflict
+}
+
+// Retriable reports whether a failed action may succeed if sent again: the
+// cluster pushed back (429) or was unavailable (503). Anything else, such as
+// a 400 mapping error, fails the same way every time.
+func (r BulkItemResult) Retriable() bool {
+	return r.Status == http.StatusTooManyRequests || r.Status == http.StatusServiceUnavailable
+}
+
+// IndexNotFound reports whether the target index does not exist. For an
+// action written through an alias, a reindex step has removed the alias
+// since the aliases were last refreshed.
+func (r BulkItemResult) IndexNotFound() bool {
+	return r.ErrorType == "index_not_found_exception"
+}
+
+// Err describes a failed action, including Elasticsearch's reason.
+func (r BulkItemResult) Err() error {
+	return fmt.Errorf("elasticsearch rejected id=%s status=%d %s: %s", r.ID, r.Status, r.ErrorType, r.Reason)
 }
 
 type bulkResponse struct {
-	Errors bool `json:"errors"`
+	Errors bool                        `json:"errors"`
 	Items  []map[string]bulkItemResult `json:"items"`
 }
 
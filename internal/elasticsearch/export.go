@@ -0,0 +1,156 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// ExportHit is a raw document returned by a point-in-time page.
+type ExportHit struct {
+	ID     string
+	Source map[string]any
+	Sort   []any
+}
+
+// ExportPage is one search_after page read from a point in time.
+type ExportPage struct {
+	// PITID may differ from the ID the page was requested with; callers must
+	// use the latest one for subsequent pages.
+	PITID string
+	Hits  []ExportHit
+}
+
+// OpenPointInTime opens a PIT over index that stays alive for keepAlive
+// between requests.
+func (c *Client) OpenPointInTime(ctx context.Context, index string, keepAlive time.Duration) (string, error) {
+	res, err := c.es.OpenPointInTime(
+		[]string{index},
+		fmt.Sprintf("%dms", keepAlive.Milliseconds()),
+		c.es.OpenPointInTime.WithContext(ctx),
+	)
+	if err != nil {
+		return "", fmt.Errorf("opening point in time (index=%s): %w", index, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return "", fmt.Errorf("open point in time error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+
+	var pit struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&pit); err != nil {
+		return "", fmt.Errorf("decoding point in time response: %w", err)
+	}
+	return pit.ID, nil
+}
+
+// ClosePointInTime releases the resources held by a PIT.
+func (c *Client) ClosePointInTime(ctx context.Context, pitID string) error {
+	body, err := json.Marshal(map[string]string{"id": pitID})
+	if err != nil {
+		return fmt.Errorf("marshaling close pit body: %w", err)
+	}
+
+	res, err := c.es.ClosePointInTime(
+		c.es.ClosePointInTime.WithContext(ctx),
+		c.es.ClosePointInTime.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return fmt.Errorf("closing point in time: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("close point in time error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+	return nil
+}
+
+// ErrPointInTimeExpired is returned by SearchAfter when the PIT no longer exists.
+var ErrPointInTimeExpired = errors.New("point in time expired")
+
+// SearchAfter reads one page of a PIT search. query must carry the "pit"
+// clause and no index, as required by the ES PIT API.
+func (c *Client) SearchAfter(ctx context.Context, query map[string]any) (*ExportPage, error) {
+	ctx, span := observability.StartSpan(ctx, "es.search_after")
+	defer span.End()
+
+	start := time.Now()
+
+	cbResult, err := c.cb.Execute(func() (any, error) {
+		return c.executeSearchAfter(ctx, query)
+	})
+	if err != nil {
+		observability.ESQueryDuration.WithLabelValues("_pit", "error").Observe(time.Since(start).Seconds())
+		return nil, fmt.Errorf("es search_after: %w", err)
+	}
+	observability.ESQueryDuration.WithLabelValues("_pit", "success").Observe(time.Since(start).Seconds())
+
+	page, ok := cbResult.(*ExportPage)
+	if !ok || page == nil {
+		return nil, fmt.Errorf("es search_after: unexpected nil result from circuit breaker")
+	}
+	return page, nil
+}
+
+func (c *Client) executeSearchAfter(ctx context.Context, query map[string]any) (*ExportPage, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling es query: %w", err)
+	}
+
+	res, err := c.es.Search(
+		c.es.Search.WithContext(ctx),
+		c.es.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("executing es search_after: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		if res.StatusCode == 404 && strings.Contains(string(bodyBytes), "search_context_missing_exception") {
+			return nil, ErrPointInTimeExpired
+		}
+		return nil, fmt.Errorf("es search_after error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+
+	var esResp struct {
+		PITID string `json:"pit_id"`
+		Hits  struct {
+			Hits []struct {
+				ID     string         `json:"_id"`
+				Source map[string]any `json:"_source"`
+				Sort   []any          `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	// Sort values are longs that must round-trip exactly through search_after.
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&esResp); err != nil {
+		return nil, fmt.Errorf("decoding es search_after response: %w", err)
+	}
+
+	page := &ExportPage{
+		PITID: esResp.PITID,
+		Hits:  make([]ExportHit, 0, len(esResp.Hits.Hits)),
+	}
+	for _, h := range esResp.Hits.Hits {
+		page.Hits = append(page.Hits, ExportHit{ID: h.ID, Source: h.Source, Sort: h.Sort})
+	}
+	return page, nil
+}
@@ -0,0 +1,148 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+const (
+	// latencyWindow is the number of recent search latencies the hedge delay
+	// is computed from.
+	latencyWindow = 1000
+	// quantileRefreshEvery bounds how often the window is re-sorted.
+	quantileRefreshEvery = 50
+)
+
+// latencyTracker keeps a sliding window of successful search latencies and
+// reports a quantile of it, recomputed every quantileRefreshEvery samples.
+type latencyTracker struct {
+	mu         sync.Mutex
+	percentile float64
+	minSamples int
+	samples    []time.Duration
+	next       int
+	filled     bool
+	stale      int
+	cached     time.Duration
+}
+
+func newLatencyTracker(percentile float64, minSamples int) *latencyTracker {
+	return &latencyTracker{
+		percentile: percentile,
+		minSamples: minSamples,
+		samples:    make([]time.Duration, latencyWindow),
+	}
+}
+
+func (t *latencyTracker) observe(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples[t.next] = d
+	t.next++
+	if t.next == len(t.samples) {
+		t.next = 0
+		t.filled = true
+	}
+	t.stale++
+}
+
+// quantile returns the tracked percentile, or false until minSamples
+// latencies have been observed.
+func (t *latencyTracker) quantile() (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := t.next
+	if t.filled {
+		n = len(t.samples)
+	}
+	if n < t.minSamples || n == 0 {
+		return 0, false
+	}
+	if t.cached == 0 || t.stale >= quantileRefreshEvery {
+		sorted := make([]time.Duration, n)
+		copy(sorted, t.samples[:n])
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		idx := int(float64(n-1) * t.percentile)
+		t.cached = sorted[idx]
+		t.stale = 0
+	}
+	return t.cached, true
+}
+
+type searchAttempt struct {
+	result *SearchResult
+	err    error
+	hedge  bool
+	took   time.Duration
+}
+
+// hedgedSearch sends the search and, if it has not answered once the tracked
+// percentile latency has passed, sends a second copy with a random shard
+// preference so it is likely served by different replicas. The first success
+// wins and the other request is cancelled. Until enough latencies have been
+// observed no hedge is sent.
+func (c *Client) hedgedSearch(ctx context.Context, index string, query map[string]any) (*SearchResult, error) {
+	if c.latency == nil {
+		return c.executeSearch(ctx, index, query, "")
+	}
+	delay, ok := c.latency.quantile()
+	if !ok {
+		start := time.Now()
+		result, err := c.executeSearch(ctx, index, query, "")
+		if err == nil {
+			c.latency.observe(time.Since(start))
+		}
+		return result, err
+	}
+	if delay < c.hedgeMinDelay {
+		delay = c.hedgeMinDelay
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so the losing attempt never blocks after we return.
+	attempts := make(chan searchAttempt, 2)
+	launch := func(preference string, hedge bool) {
+		go func() {
+			start := time.Now()
+			result, err := c.executeSearch(ctx, index, query, preference)
+			attempts <- searchAttempt{result: result, err: err, hedge: hedge, took: time.Since(start)}
+		}()
+	}
+
+	launch("", false)
+	pending := 1
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-timer.C:
+			observability.ESHedgedRequests.WithLabelValues("sent").Inc()
+			launch(fmt.Sprintf("hedge-%d", rand.Uint64()), true)
+			pending++
+		case a := <-attempts:
+			pending--
+			if a.err == nil {
+				c.latency.observe(a.took)
+				if a.hedge {
+					observability.ESHedgedRequests.WithLabelValues("won").Inc()
+				}
+				return a.result, nil
+			}
+			lastErr = a.err
+			if pending == 0 {
+				return nil, lastErr
+			}
+		}
+	}
+}
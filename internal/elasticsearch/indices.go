@@ -0,0 +1,106 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IndexInfo is one index of the inventory.
+type IndexInfo struct {
+	Name      string    `json:"name"`
+	Health    string    `json:"health"`
+	Status    string    `json:"status"`
+	Docs      int64     `json:"docs"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+	// ReadOnly reports the write block the lifecycle policy sets once a
+	// month is closed.
+	ReadOnly bool     `json:"read_only"`
+	Aliases  []string `json:"aliases,omitempty"`
+}
+
+// Inventory lists the monthly indices and reindex generations, with the
+// service aliases pointing at each.
+func (c *Client) Inventory(ctx context.Context) ([]IndexInfo, error) {
+	prefix := c.cfg.IndexPrefix
+	indices, err := c.Indices(ctx, prefix+"-*", prefix+"_gen_*")
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := c.Aliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byIndex := make(map[string][]string)
+	for _, alias := range sortedKeys(aliases) {
+		for _, index := range aliases[alias] {
+			byIndex[index] = append(byIndex[index], alias)
+		}
+	}
+	for i := range indices {
+		indices[i].Aliases = byIndex[indices[i].Name]
+	}
+	return indices, nil
+}
+
+// Indices lists the indices matching patterns, sorted by name, with their
+// document count and store size.
+func (c *Client) Indices(ctx context.Context, patterns ...string) ([]IndexInfo, error) {
+	res, err := c.es.Cat.Indices(
+		c.es.Cat.Indices.WithContext(ctx),
+		c.es.Cat.Indices.WithIndex(patterns...),
+		c.es.Cat.Indices.WithFormat("json"),
+		c.es.Cat.Indices.WithBytes("b"),
+		c.es.Cat.Indices.WithH("index", "health", "status", "docs.count", "store.size", "creation.date"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing indices: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("cat indices error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+
+	// _cat reports numbers as strings, and null for closed indices.
+	var rows []map[string]*string
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("decoding cat indices response: %w", err)
+	}
+	settings, err := c.liveSettings(ctx, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	value := func(row map[string]*string, key string) string {
+		if v := row[key]; v != nil {
+			return *v
+		}
+		return ""
+	}
+	indices := make([]IndexInfo, 0, len(rows))
+	for _, row := range rows {
+		info := IndexInfo{
+			Name:   value(row, "index"),
+			Health: value(row, "health"),
+			Status: value(row, "status"),
+		}
+		info.Docs, _ = strconv.ParseInt(value(row, "docs.count"), 10, 64)
+		info.SizeBytes, _ = strconv.ParseInt(value(row, "store.size"), 10, 64)
+		if ms, err := strconv.ParseInt(value(row, "creation.date"), 10, 64); err == nil {
+			info.CreatedAt = time.UnixMilli(ms).UTC()
+		}
+		info.ReadOnly = strings.EqualFold(fmt.Sprint(settings[info.Name]["index.blocks.write"]), "true")
+		indices = append(indices, info)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i].Name < indices[j].Name })
+	return indices, nil
+}
@@ -0,0 +1,142 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+	"github.com/shubhsaxena/high-scale-search/internal/resilience"
+)
+
+// MultiSearchItem is a single search within a _msearch batch.
+type MultiSearchItem struct {
+	Index string
+	Query map[string]any
+}
+
+// MultiSearchItemResult holds the outcome of one batch item. Exactly one of
+// Result and Err is set.
+type MultiSearchItemResult struct {
+	Result *SearchResult
+	Err    error
+}
+
+// MultiSearch executes items in a single _msearch round trip. The returned
+// error is only set when the request as a whole failed; per-item failures are
+// reported in the corresponding MultiSearchItemResult.
+func (c *Client) MultiSearch(ctx context.Context, items []MultiSearchItem) ([]MultiSearchItemResult, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	ctx, span := observability.StartSpan(ctx, "es.msearch",
+		attribute.Int("batch_size", len(items)),
+	)
+	defer span.End()
+
+	start := time.Now()
+
+	cbResult, err := c.cb.Execute(func() (any, error) {
+		var retryResult []MultiSearchItemResult
+		retryErr := resilience.Retry(ctx, c.retryCfg, func() error {
+			var execErr error
+			retryResult, execErr = c.executeMultiSearch(ctx, items)
+			return execErr
+		})
+		return retryResult, retryErr
+	})
+
+	duration := time.Since(start)
+	if err != nil {
+		observability.ESQueryDuration.WithLabelValues("_msearch", "error").Observe(duration.Seconds())
+		return nil, fmt.Errorf("es msearch (items=%d): %w", len(items), err)
+	}
+
+	results, ok := cbResult.([]MultiSearchItemResult)
+	if !ok || len(results) != len(items) {
+		observability.ESQueryDuration.WithLabelValues("_msearch", "error").Observe(duration.Seconds())
+		return nil, fmt.Errorf("es msearch (items=%d): unexpected result from circuit breaker", len(items))
+	}
+	observability.ESQueryDuration.WithLabelValues("_msearch", "success").Observe(duration.Seconds())
+
+	return results, nil
+}
+
+func (c *Client) executeMultiSearch(ctx context.Context, items []MultiSearchItem) ([]MultiSearchItemResult, error) {
+	var buf bytes.Buffer
+	for _, item := range items {
+		header, err := json.Marshal(map[string]any{"index": item.Index})
+		if err != nil {
+			return nil, fmt.Errorf("marshaling msearch header: %w", err)
+		}
+		buf.Write(header)
+		buf.WriteByte('\n')
+
+		// Per-search options that Search passes as URL parameters must go in
+		// each body for _msearch. Copy so the caller's query is not mutated.
+		body := make(map[string]any, len(item.Query)+2)
+		for k, v := range item.Query {
+			body[k] = v
+		}
+		body["timeout"] = fmt.Sprintf("%dms", c.cfg.RequestTimeout.Milliseconds())
+		body["track_total_hits"] = true
+
+		bodyLine, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling msearch body: %w", err)
+		}
+		buf.Write(bodyLine)
+		buf.WriteByte('\n')
+	}
+
+	res, err := c.es.Msearch(
+		bytes.NewReader(buf.Bytes()),
+		c.es.Msearch.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("executing es msearch: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("es msearch error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+
+	var msResp esMultiSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&msResp); err != nil {
+		return nil, fmt.Errorf("decoding es msearch response: %w", err)
+	}
+	if len(msResp.Responses) != len(items) {
+		return nil, fmt.Errorf("es msearch returned %d responses for %d items", len(msResp.Responses), len(items))
+	}
+
+	results := make([]MultiSearchItemResult, len(items))
+	for i := range msResp.Responses {
+		r := &msResp.Responses[i]
+		if r.Error != nil {
+			results[i].Err = fmt.Errorf("es msearch item (index=%s) status=%d: %s: %s",
+				items[i].Index, r.Status, r.Error.Type, r.Error.Reason)
+			continue
+		}
+		results[i].Result = toSearchResult(&r.esSearchResponse)
+	}
+	return results, nil
+}
+
+type esMultiSearchResponse struct {
+	Responses []struct {
+		esSearchResponse
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error,omitempty"`
+	} `json:"responses"`
+}
@@ -0,0 +1,793 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// SchemaVersion versions the templates and lifecycle policy below. Bump it
+// with every change to them: servers install a newer version over an older
+// one at startup and never downgrade, so a rolling deploy settles on the
+// newest. Settings taken from the configuration are compared as well, so a
+// configuration change is applied without a new version. Existing indices
+// keep their mappings; reindex to apply a change.
+const SchemaVersion = 1
+
+// Schema resources, as reported in SchemaDrift.
+const (
+	ResourceComponentTemplate = "component_template"
+	ResourceIndexTemplate     = "index_template"
+	ResourceLifecyclePolicy   = "lifecycle_policy"
+	ResourceIndexMapping      = "index_mapping"
+	ResourceIndexSettings     = "index_settings"
+)
+
+// ComponentTemplateName returns the template holding the settings, analyzers
+// and mappings shared by every search index.
+func ComponentTemplateName(prefix string) string { return prefix + "-documents" }
+
+// MonthlyTemplateName returns the index template for the monthly indices.
+func MonthlyTemplateName(prefix string) string { return prefix + "-monthly" }
+
+// GenerationTemplateName returns the index template for reindex generations.
+func GenerationTemplateName(prefix string) string { return prefix + "-generations" }
+
+// LifecyclePolicyName returns the ILM policy of the monthly indices.
+func LifecyclePolicyName(prefix string) string { return prefix + "-monthly" }
+
+// SchemaDrift is one difference between what this build expects and what the
+// cluster holds.
+type SchemaDrift struct {
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	Field    string `json:"field,omitempty"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// indexMappings is the mapping the queries rely on: weighted full-text
+// fields, the title.suggest shingles behind spell suggestions, the
+// title.autocomplete completion field, and doc values for popularity
+// scoring, sorting and filters.
+func indexMappings() map[string]any {
+	return map[string]any{
+		"properties": map[string]any{
+			"title": map[string]any{
+				"type":     "text",
+				"analyzer": "search_text",
+				"fields": map[string]any{
+					"keyword":      map[string]any{"type": "keyword", "ignore_above": 256},
+					"suggest":      map[string]any{"type": "text", "analyzer": "shingle_text"},
+					"autocomplete": map[string]any{"type": "completion", "analyzer": "simple"},
+				},
+			},
+			"description": map[string]any{"type": "text", "analyzer": "search_text"},
+			"category":    map[string]any{"type": "keyword"},
+			"tags": map[string]any{
+				"type":     "text",
+				"analyzer": "search_text",
+				"fields": map[string]any{
+					"keyword": map[string]any{"type": "keyword", "ignore_above": 256},
+				},
+			},
+			"region":           map[string]any{"type": "keyword"},
+			"created_at":       map[string]any{"type": "date"},
+			"updated_at":       map[string]any{"type": "date"},
+			"popularity_score": map[string]any{"type": "float"},
+			"geo_point":        map[string]any{"type": "geo_point"},
+		},
+	}
+}
+
+// indexAnalysis defines the analyzers referenced by indexMappings.
+func indexAnalysis() map[string]any {
+	return map[string]any{
+		"filter": map[string]any{
+			// Trigram shingles for the phrase suggester (gram_size 3).
+			"shingle_filter": map[string]any{
+				"type":             "shingle",
+				"min_shingle_size": 2,
+				"max_shingle_size": 3,
+			},
+		},
+		"analyzer": map[string]any{
+			"search_text": map[string]any{
+				"type":      "custom",
+				"tokenizer": "standard",
+				"filter":    []string{"lowercase", "asciifolding"},
+			},
+			"shingle_text": map[string]any{
+				"type":      "custom",
+				"tokenizer": "standard",
+				"filter":    []string{"lowercase", "shingle_filter"},
+			},
+		},
+	}
+}
+
+func schemaMeta() map[string]any {
+	return map[string]any{"managed_by": "high-scale-search", "schema_version": SchemaVersion}
+}
+
+func (c *Client) componentTemplate() map[string]any {
+	return map[string]any{
+		"version": SchemaVersion,
+		"_meta":   schemaMeta(),
+		"template": map[string]any{
+			"settings": map[string]any{
+				"number_of_shards":   c.cfg.NumShards,
+				"number_of_replicas": c.cfg.NumReplicas,
+				"refresh_interval":   c.cfg.RefreshInterval,
+				"analysis":           indexAnalysis(),
+			},
+			"mappings": indexMappings(),
+		},
+	}
+}
+
+// indexTemplates returns the monthly and generation index templates. Only the
+// monthly indices follow the lifecycle policy: a generation stays live for as
+// long as the aliases point at it.
+func (c *Client) indexTemplates() map[string]map[string]any {
+	prefix := c.cfg.IndexPrefix
+	return map[string]map[string]any{
+		MonthlyTemplateName(prefix): {
+			"index_patterns": []string{prefix + "-*"},
+			"composed_of":    []string{ComponentTemplateName(prefix)},
+			"priority":       200,
+			"version":        SchemaVersion,
+			"_meta":          schemaMeta(),
+			"template": map[string]any{
+				"settings": map[string]any{"index.lifecycle.name": LifecyclePolicyName(prefix)},
+			},
+		},
+		GenerationTemplateName(prefix): {
+			"index_patterns": []string{prefix + "_gen_*"},
+			"composed_of":    []string{ComponentTemplateName(prefix)},
+			"priority":       200,
+			"version":        SchemaVersion,
+			"_meta":          schemaMeta(),
+		},
+	}
+}
+
+// lifecyclePhases are the ILM phases the policy may hold, in order.
+var lifecyclePhases = []string{"hot", "warm", "cold", "delete"}
+
+// lifecycleMonthDays returns the age in days at which a monthly index's
+// month has been over for months months. ILM counts from the index's
+// creation, early in its month, so every month counts as 31 days and one is
+// added for the month itself; the phase may start a little late, never
+// early.
+func lifecycleMonthDays(months int) int {
+	return (months + 1) * 31
+}
+
+// lifecyclePolicy moves monthly indices down the storage tiers: hot for the
+// first three months, warm until a year, then cold. With the lifecycle
+// enabled, indices go warm once their month has been over for
+// ReadOnlyAfterMonths, being force-merged and write-blocked on the way, and
+// are deleted once it has been over for more than RetentionMonths.
+func (c *Client) lifecyclePolicy() map[string]any {
+	lc := c.cfg.Lifecycle
+	warmDays, coldDays, deleteDays := 90, 365, 0
+	warmActions := map[string]any{"set_priority": map[string]any{"priority": 50}}
+	if lc.Enabled {
+		warmDays = lifecycleMonthDays(lc.ReadOnlyAfterMonths)
+		warmActions["readonly"] = map[string]any{}
+		warmActions["forcemerge"] = map[string]any{"max_num_segments": lc.MaxSegments}
+		if lc.RetentionMonths > 0 {
+			deleteDays = lifecycleMonthDays(lc.RetentionMonths)
+		}
+	}
+
+	phases := map[string]any{
+		"hot": map[string]any{
+			"actions": map[string]any{"set_priority": map[string]any{"priority": 100}},
+		},
+		"warm": map[string]any{
+			"min_age": fmt.Sprintf("%dd", warmDays),
+			"actions": warmActions,
+		},
+	}
+	// ILM requires phases to start in order; a cold tier that would start
+	// before warm or after deletion is left out.
+	if coldDays > warmDays && (deleteDays == 0 || coldDays < deleteDays) {
+		phases["cold"] = map[string]any{
+			"min_age": fmt.Sprintf("%dd", coldDays),
+			"actions": map[string]any{"set_priority": map[string]any{"priority": 0}},
+		}
+	}
+	if deleteDays > 0 {
+		phases["delete"] = map[string]any{
+			"min_age": fmt.Sprintf("%dd", deleteDays),
+			"actions": map[string]any{"delete": map[string]any{}},
+		}
+	}
+	return map[string]any{
+		"policy": map[string]any{
+			"_meta":  schemaMeta(),
+			"phases": phases,
+		},
+	}
+}
+
+// configuredSettings returns the settings of a schema resource that come from
+// the configuration rather than the code, keyed as in flattenSettings and
+// flattenPhases. They can change without a new SchemaVersion. For the
+// lifecycle policy these are when each phase starts, "missing" for a phase
+// it leaves out, and the force-merge segment count.
+func (c *Client) configuredSettings(resource string) map[string]string {
+	switch resource {
+	case ResourceComponentTemplate:
+		return map[string]string{
+			"index.number_of_shards":   strconv.Itoa(c.cfg.NumShards),
+			"index.number_of_replicas": strconv.Itoa(c.cfg.NumReplicas),
+			"index.refresh_interval":   c.cfg.RefreshInterval,
+		}
+	case ResourceLifecyclePolicy:
+		phases := flattenPhases(c.lifecyclePolicy()["policy"].(map[string]any)["phases"].(map[string]any))
+		out := make(map[string]string)
+		for _, phase := range lifecyclePhases[1:] {
+			key := "phases." + phase + ".min_age"
+			if age, ok := phases[key]; ok {
+				out[key] = age
+			} else {
+				out[key] = "missing"
+			}
+		}
+		key := "phases.warm.actions.forcemerge.max_num_segments"
+		if segments, ok := phases[key]; ok {
+			out[key] = segments
+		} else {
+			out[key] = "missing"
+		}
+		return out
+	}
+	return nil
+}
+
+// installedResource is a schema resource as the cluster holds it.
+type installedResource struct {
+	// version is the SchemaVersion it was installed with, 0 if it is
+	// missing or was not installed by this service.
+	version int
+	// settings are its flattened settings.
+	settings map[string]string
+}
+
+// EnsureSchema installs the lifecycle policy and templates, or upgrades them
+// when the cluster holds an older SchemaVersion or settings that differ from
+// the configuration. Newer versions, installed by a newer build, are left
+// alone.
+func (c *Client) EnsureSchema(ctx context.Context) error {
+	prefix := c.cfg.IndexPrefix
+
+	policy := LifecyclePolicyName(prefix)
+	if err := c.ensureResource(ctx, ResourceLifecyclePolicy, policy, c.lifecyclePolicy()); err != nil {
+		return err
+	}
+	if err := c.attachLifecyclePolicy(ctx); err != nil {
+		return err
+	}
+	// Index templates reference the component template, so it goes first.
+	component := ComponentTemplateName(prefix)
+	if err := c.ensureResource(ctx, ResourceComponentTemplate, component, c.componentTemplate()); err != nil {
+		return err
+	}
+	templates := c.indexTemplates()
+	for _, name := range []string{MonthlyTemplateName(prefix), GenerationTemplateName(prefix)} {
+		if err := c.ensureResource(ctx, ResourceIndexTemplate, name, templates[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachLifecyclePolicy puts the monthly indices without a lifecycle policy
+// under the monthly one. Templates apply only when an index is created, so
+// indices created before the monthly template carried the policy would
+// otherwise never be write-blocked or deleted.
+func (c *Client) attachLifecyclePolicy(ctx context.Context) error {
+	prefix := c.cfg.IndexPrefix
+	settings, err := c.liveSettings(ctx, []string{prefix + "-*"})
+	if err != nil {
+		return err
+	}
+	var unmanaged []string
+	for _, index := range sortedKeys(settings) {
+		if name, _ := settings[index]["index.lifecycle.name"].(string); name == "" {
+			unmanaged = append(unmanaged, index)
+		}
+	}
+	if len(unmanaged) == 0 {
+		return nil
+	}
+
+	policy := LifecyclePolicyName(prefix)
+	body, err := json.Marshal(map[string]any{"index.lifecycle.name": policy})
+	if err != nil {
+		return fmt.Errorf("marshaling lifecycle setting: %w", err)
+	}
+	res, err := c.es.Indices.PutSettings(
+		bytes.NewReader(body),
+		c.es.Indices.PutSettings.WithContext(ctx),
+		c.es.Indices.PutSettings.WithIndex(unmanaged...),
+	)
+	if err != nil {
+		return fmt.Errorf("attaching lifecycle policy: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("attach lifecycle policy error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+	c.logger.Info("lifecycle policy attached to existing monthly indices",
+		zap.String("policy", policy),
+		zap.Strings("indices", unmanaged),
+	)
+	return nil
+}
+
+func (c *Client) ensureResource(ctx context.Context, resource, name string, body map[string]any) error {
+	installed, err := c.installedResource(ctx, resource, name)
+	if err != nil {
+		return err
+	}
+	version := installed.version
+	changed := configuredDrift(resource, name, c.configuredSettings(resource), installed.settings)
+	switch {
+	case version == SchemaVersion && len(changed) == 0:
+		return nil
+	case version > SchemaVersion:
+		c.logger.Warn("elasticsearch schema is newer than this build, leaving it",
+			zap.String("resource", resource),
+			zap.String("name", name),
+			zap.Int("version", version),
+			zap.Int("expected", SchemaVersion),
+		)
+		return nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshaling %s %s: %w", resource, name, err)
+	}
+	var res *esapi.Response
+	switch resource {
+	case ResourceLifecyclePolicy:
+		res, err = c.es.ILM.PutLifecycle(name,
+			c.es.ILM.PutLifecycle.WithContext(ctx),
+			c.es.ILM.PutLifecycle.WithBody(bytes.NewReader(data)),
+		)
+	case ResourceComponentTemplate:
+		res, err = c.es.Cluster.PutComponentTemplate(name, bytes.NewReader(data),
+			c.es.Cluster.PutComponentTemplate.WithContext(ctx),
+		)
+	default:
+		res, err = c.es.Indices.PutIndexTemplate(name, bytes.NewReader(data),
+			c.es.Indices.PutIndexTemplate.WithContext(ctx),
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("installing %s %s: %w", resource, name, err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("install %s %s error status=%s body=%s", resource, name, res.Status(), string(bodyBytes))
+	}
+	fields := make([]string, len(changed))
+	for i, d := range changed {
+		fields[i] = d.Field
+	}
+	c.logger.Info("elasticsearch schema installed",
+		zap.String("resource", resource),
+		zap.String("name", name),
+		zap.Int("previous_version", version),
+		zap.Int("version", SchemaVersion),
+		zap.Strings("changed_settings", fields),
+	)
+	return nil
+}
+
+// installedResource returns a schema resource as the cluster holds it; a
+// missing resource has version 0 and no settings.
+func (c *Client) installedResource(ctx context.Context, resource, name string) (installedResource, error) {
+	var (
+		res *esapi.Response
+		err error
+	)
+	switch resource {
+	case ResourceLifecyclePolicy:
+		res, err = c.es.ILM.GetLifecycle(
+			c.es.ILM.GetLifecycle.WithContext(ctx),
+			c.es.ILM.GetLifecycle.WithPolicy(name),
+		)
+	case ResourceComponentTemplate:
+		res, err = c.es.Cluster.GetComponentTemplate(
+			c.es.Cluster.GetComponentTemplate.WithContext(ctx),
+			c.es.Cluster.GetComponentTemplate.WithName(name),
+		)
+	default:
+		res, err = c.es.Indices.GetIndexTemplate(
+			c.es.Indices.GetIndexTemplate.WithContext(ctx),
+			c.es.Indices.GetIndexTemplate.WithName(name),
+		)
+	}
+	if err != nil {
+		return installedResource{}, fmt.Errorf("getting %s %s: %w", resource, name, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return installedResource{}, nil
+	}
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return installedResource{}, fmt.Errorf("get %s %s error status=%s body=%s", resource, name, res.Status(), string(bodyBytes))
+	}
+
+	type meta struct {
+		Meta struct {
+			SchemaVersion int `json:"schema_version"`
+		} `json:"_meta"`
+	}
+	type template struct {
+		meta
+		Template struct {
+			Settings map[string]any `json:"settings"`
+		} `json:"template"`
+	}
+	switch resource {
+	case ResourceLifecyclePolicy:
+		var body map[string]struct {
+			Policy struct {
+				meta
+				Phases map[string]any `json:"phases"`
+			} `json:"policy"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			return installedResource{}, fmt.Errorf("decoding %s %s: %w", resource, name, err)
+		}
+		policy := body[name].Policy
+		return installedResource{version: policy.Meta.SchemaVersion, settings: flattenPhases(policy.Phases)}, nil
+	case ResourceComponentTemplate:
+		var body struct {
+			ComponentTemplates []struct {
+				ComponentTemplate template `json:"component_template"`
+			} `json:"component_templates"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			return installedResource{}, fmt.Errorf("decoding %s %s: %w", resource, name, err)
+		}
+		if len(body.ComponentTemplates) == 0 {
+			return installedResource{}, nil
+		}
+		t := body.ComponentTemplates[0].ComponentTemplate
+		return installedResource{version: t.Meta.SchemaVersion, settings: flattenSettings(t.Template.Settings)}, nil
+	default:
+		var body struct {
+			IndexTemplates []struct {
+				IndexTemplate template `json:"index_template"`
+			} `json:"index_templates"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			return installedResource{}, fmt.Errorf("decoding %s %s: %w", resource, name, err)
+		}
+		if len(body.IndexTemplates) == 0 {
+			return installedResource{}, nil
+		}
+		t := body.IndexTemplates[0].IndexTemplate
+		return installedResource{version: t.Meta.SchemaVersion, settings: flattenSettings(t.Template.Settings)}, nil
+	}
+}
+
+// CheckDrift compares the installed policy and templates, and the mappings
+// and shard settings of every search index, with what this build expects.
+// The result is also exported as the es_schema_drift gauge.
+func (c *Client) CheckDrift(ctx context.Context) ([]SchemaDrift, error) {
+	prefix := c.cfg.IndexPrefix
+	var drift []SchemaDrift
+
+	for _, r := range []struct{ resource, name string }{
+		{ResourceLifecyclePolicy, LifecyclePolicyName(prefix)},
+		{ResourceComponentTemplate, ComponentTemplateName(prefix)},
+		{ResourceIndexTemplate, MonthlyTemplateName(prefix)},
+		{ResourceIndexTemplate, GenerationTemplateName(prefix)},
+	} {
+		installed, err := c.installedResource(ctx, r.resource, r.name)
+		if err != nil {
+			return nil, err
+		}
+		version := installed.version
+		if version != SchemaVersion {
+			actual := strconv.Itoa(version)
+			if version == 0 {
+				actual = "missing"
+			}
+			drift = append(drift, SchemaDrift{
+				Resource: r.resource,
+				Name:     r.name,
+				Field:    "schema_version",
+				Expected: strconv.Itoa(SchemaVersion),
+				Actual:   actual,
+			})
+		}
+		if version != 0 {
+			drift = append(drift, configuredDrift(r.resource, r.name, c.configuredSettings(r.resource), installed.settings)...)
+		}
+	}
+
+	indices := []string{prefix + "-*", prefix + "_gen_*"}
+	mappings, err := c.liveMappings(ctx, indices)
+	if err != nil {
+		return nil, err
+	}
+	expected := flattenMappings(indexMappings())
+	for _, index := range sortedKeys(mappings) {
+		drift = append(drift, mappingDrift(index, expected, mappings[index])...)
+	}
+
+	settings, err := c.liveSettings(ctx, indices)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range sortedKeys(settings) {
+		drift = append(drift, c.settingsDrift(index, settings[index])...)
+	}
+
+	counts := map[string]int{
+		ResourceComponentTemplate: 0,
+		ResourceIndexTemplate:     0,
+		ResourceLifecyclePolicy:   0,
+		ResourceIndexMapping:      0,
+		ResourceIndexSettings:     0,
+	}
+	for _, d := range drift {
+		counts[d.Resource]++
+	}
+	for resource, n := range counts {
+		observability.ESSchemaDrift.WithLabelValues(resource).Set(float64(n))
+	}
+	return drift, nil
+}
+
+// liveMappings returns the flattened field types of each index matching
+// patterns.
+func (c *Client) liveMappings(ctx context.Context, patterns []string) (map[string]map[string]string, error) {
+	res, err := c.es.Indices.GetMapping(
+		c.es.Indices.GetMapping.WithContext(ctx),
+		c.es.Indices.GetMapping.WithIndex(patterns...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("getting mappings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("get mappings error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+	var body map[string]struct {
+		Mappings map[string]any `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding mappings: %w", err)
+	}
+
+	out := make(map[string]map[string]string, len(body))
+	for index, m := range body {
+		out[index] = flattenMappings(m.Mappings)
+	}
+	return out, nil
+}
+
+// liveSettings returns the flat settings of each index matching patterns.
+func (c *Client) liveSettings(ctx context.Context, patterns []string) (map[string]map[string]any, error) {
+	res, err := c.es.Indices.GetSettings(
+		c.es.Indices.GetSettings.WithContext(ctx),
+		c.es.Indices.GetSettings.WithIndex(patterns...),
+		c.es.Indices.GetSettings.WithFlatSettings(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("getting settings: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		bodyBytes, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("get settings error status=%s body=%s", res.Status(), string(bodyBytes))
+	}
+	var body map[string]struct {
+		Settings map[string]any `json:"settings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding settings: %w", err)
+	}
+
+	out := make(map[string]map[string]any, len(body))
+	for index, s := range body {
+		out[index] = s.Settings
+	}
+	return out, nil
+}
+
+// flattenSettings returns index settings as text keyed by dotted path under
+// the index. prefix Elasticsearch adds: {"index": {"number_of_shards": "2"}}
+// and {"number_of_shards": 2} both become index.number_of_shards=2.
+func flattenSettings(settings map[string]any) map[string]string {
+	out := make(map[string]string)
+	for key, v := range flattenValues("", settings) {
+		if !strings.HasPrefix(key, "index.") {
+			key = "index." + key
+		}
+		out[key] = v
+	}
+	return out
+}
+
+// flattenPhases returns the phases of a lifecycle policy as text keyed by
+// dotted path under phases., such as phases.warm.min_age.
+func flattenPhases(phases map[string]any) map[string]string {
+	return flattenValues("phases.", phases)
+}
+
+// flattenValues returns the leaves of nested maps as text keyed by their
+// dotted path after prefix.
+func flattenValues(prefix string, m map[string]any) map[string]string {
+	out := make(map[string]string)
+	var walk func(path string, m map[string]any)
+	walk = func(path string, m map[string]any) {
+		for k, v := range m {
+			if sub, ok := v.(map[string]any); ok {
+				walk(path+k+".", sub)
+				continue
+			}
+			out[path+k] = fmt.Sprint(v)
+		}
+	}
+	walk(prefix, m)
+	return out
+}
+
+// configuredDrift reports the configured settings a schema resource holds
+// with another value.
+func configuredDrift(resource, name string, expected, actual map[string]string) []SchemaDrift {
+	var drift []SchemaDrift
+	for _, key := range sortedKeys(expected) {
+		got, ok := actual[key]
+		if !ok {
+			got = "missing"
+		}
+		if got != expected[key] {
+			drift = append(drift, SchemaDrift{
+				Resource: resource,
+				Name:     name,
+				Field:    key,
+				Expected: expected[key],
+				Actual:   got,
+			})
+		}
+	}
+	return drift
+}
+
+// flattenMappings returns the type of every field in a mapping, multi-fields
+// included, keyed by dotted path.
+func flattenMappings(mappings map[string]any) map[string]string {
+	out := make(map[string]string)
+	var walk func(path string, props map[string]any)
+	walk = func(path string, props map[string]any) {
+		for name, v := range props {
+			field, ok := v.(map[string]any)
+			if !ok {
+				continue
+			}
+			full := path + name
+			typ, _ := field["type"].(string)
+			if typ == "" {
+				typ = "object"
+			}
+			out[full] = typ
+			if sub, ok := field["properties"].(map[string]any); ok {
+				walk(full+".", sub)
+			}
+			if sub, ok := field["fields"].(map[string]any); ok {
+				walk(full+".", sub)
+			}
+		}
+	}
+	if props, ok := mappings["properties"].(map[string]any); ok {
+		walk("", props)
+	}
+	return out
+}
+
+// mappingDrift reports the expected fields an index lacks or maps with a
+// different type. Extra fields are not drift.
+func mappingDrift(index string, expected, actual map[string]string) []SchemaDrift {
+	var drift []SchemaDrift
+	for _, field := range sortedKeys(expected) {
+		if got := actual[field]; got != expected[field] {
+			if got == "" {
+				got = "missing"
+			}
+			drift = append(drift, SchemaDrift{
+				Resource: ResourceIndexMapping,
+				Name:     index,
+				Field:    field,
+				Expected: expected[field],
+				Actual:   got,
+			})
+		}
+	}
+	return drift
+}
+
+// settingsDrift reports shard and replica counts that differ from the
+// configuration, and monthly indices outside the monthly lifecycle policy.
+// Shard counts are fixed at creation; a mismatch needs a reindex.
+func (c *Client) settingsDrift(index string, settings map[string]any) []SchemaDrift {
+	var drift []SchemaDrift
+	for _, s := range []struct {
+		key  string
+		want int
+	}{
+		{"index.number_of_shards", c.cfg.NumShards},
+		{"index.number_of_replicas", c.cfg.NumReplicas},
+	} {
+		got := fmt.Sprint(settings[s.key])
+		if got != strconv.Itoa(s.want) {
+			drift = append(drift, SchemaDrift{
+				Resource: ResourceIndexSettings,
+				Name:     index,
+				Field:    s.key,
+				Expected: strconv.Itoa(s.want),
+				Actual:   got,
+			})
+		}
+	}
+
+	prefix := c.cfg.IndexPrefix
+	if strings.HasPrefix(index, prefix+"-") {
+		policy := LifecyclePolicyName(prefix)
+		got, _ := settings["index.lifecycle.name"].(string)
+		if got != policy {
+			if got == "" {
+				got = "missing"
+			}
+			drift = append(drift, SchemaDrift{
+				Resource: ResourceIndexSettings,
+				Name:     index,
+				Field:    "index.lifecycle.name",
+				Expected: policy,
+				Actual:   got,
+			})
+		}
+	}
+	return drift
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,224 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+)
+
+func TestFlattenMappings_IncludesMultiFields(t *testing.T) {
+	fields := flattenMappings(indexMappings())
+
+	// Fields the query builder depends on.
+	for field, typ := range map[string]string{
+		"title":              "text",
+		"title.suggest":      "text",
+		"title.autocomplete": "completion",
+		"popularity_score":   "float",
+		"region":             "keyword",
+	} {
+		if fields[field] != typ {
+			t.Errorf("expected %s to be %s, got %q", field, typ, fields[field])
+		}
+	}
+}
+
+func TestMappingDrift(t *testing.T) {
+	expected := flattenMappings(indexMappings())
+
+	// A monthly index created by dynamic mapping before the template existed.
+	dynamic := flattenMappings(map[string]any{
+		"properties": map[string]any{
+			"title": map[string]any{
+				"type":   "text",
+				"fields": map[string]any{"keyword": map[string]any{"type": "keyword"}},
+			},
+			"popularity_score": map[string]any{"type": "long"},
+		},
+	})
+
+	drift := mappingDrift("search-general-us-2026.01", expected, dynamic)
+	byField := make(map[string]SchemaDrift, len(drift))
+	for _, d := range drift {
+		byField[d.Field] = d
+	}
+	if d := byField["title.suggest"]; d.Actual != "missing" {
+		t.Errorf("expected title.suggest to be reported missing, got %+v", d)
+	}
+	if d := byField["popularity_score"]; d.Expected != "float" || d.Actual != "long" {
+		t.Errorf("expected popularity_score type drift, got %+v", d)
+	}
+	if _, ok := byField["title"]; ok {
+		t.Error("title matches and should not be reported")
+	}
+
+	if drift := mappingDrift("search_gen_1", expected, expected); len(drift) != 0 {
+		t.Errorf("expected no drift for the template's own mapping, got %v", drift)
+	}
+}
+
+func TestSettingsDrift(t *testing.T) {
+	c := &Client{cfg: config.ElasticsearchConfig{IndexPrefix: "search", NumShards: 2, NumReplicas: 2}}
+
+	drift := c.settingsDrift("search-general-us-2026.01", map[string]any{
+		"index.number_of_shards":   "2",
+		"index.number_of_replicas": "1",
+		"index.lifecycle.name":     "search-monthly",
+	})
+	if len(drift) != 1 || drift[0].Field != "index.number_of_replicas" || drift[0].Actual != "1" {
+		t.Errorf("expected replica drift only, got %+v", drift)
+	}
+
+	// A monthly index created before the template carried the policy.
+	drift = c.settingsDrift("search-general-us-2025.06", map[string]any{
+		"index.number_of_shards":   "2",
+		"index.number_of_replicas": "2",
+	})
+	if len(drift) != 1 || drift[0].Field != "index.lifecycle.name" || drift[0].Actual != "missing" {
+		t.Errorf("expected the missing lifecycle policy reported, got %+v", drift)
+	}
+
+	// Generations stay live as long as the aliases point at them.
+	if drift := c.settingsDrift("search_gen_20260101000000", map[string]any{
+		"index.number_of_shards":   "2",
+		"index.number_of_replicas": "2",
+	}); len(drift) != 0 {
+		t.Errorf("expected no lifecycle drift for a generation, got %+v", drift)
+	}
+}
+
+func TestAttachLifecyclePolicy(t *testing.T) {
+	var attached []string
+	var setting map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, `{
+				"search-general-us-2025.06": {"settings": {"index.number_of_shards": "2"}},
+				"search-general-us-2026.01": {"settings": {"index.lifecycle.name": "search-monthly"}},
+				"search-general-eu-2025.07": {"settings": {"index.number_of_shards": "2"}}
+			}`)
+		case http.MethodPut:
+			attached = strings.Split(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/_settings"), ",")
+			json.NewDecoder(r.Body).Decode(&setting)
+			fmt.Fprint(w, `{"acknowledged": true}`)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	es, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{srv.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{es: es, cfg: config.ElasticsearchConfig{IndexPrefix: "search"}, logger: zap.NewNop()}
+
+	if err := c.attachLifecyclePolicy(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"search-general-eu-2025.07", "search-general-us-2025.06"}
+	if !reflect.DeepEqual(attached, want) {
+		t.Errorf("expected the policy attached to %v, got %v", want, attached)
+	}
+	if setting["index.lifecycle.name"] != "search-monthly" {
+		t.Errorf("expected the monthly policy, got %v", setting)
+	}
+}
+
+func TestConfiguredDrift_ComponentTemplate(t *testing.T) {
+	c := &Client{cfg: config.ElasticsearchConfig{NumShards: 3, NumReplicas: 1, RefreshInterval: "5s"}}
+
+	// Installed with an earlier configuration, as Elasticsearch returns it.
+	installed := flattenSettings(map[string]any{
+		"index": map[string]any{
+			"number_of_shards":   "2",
+			"number_of_replicas": "1",
+			"refresh_interval":   "1s",
+			"analysis":           map[string]any{"analyzer": map[string]any{"search_text": map[string]any{"type": "custom"}}},
+		},
+	})
+
+	drift := configuredDrift(ResourceComponentTemplate, "search-documents", c.configuredSettings(ResourceComponentTemplate), installed)
+	if len(drift) != 2 {
+		t.Fatalf("expected shard and refresh interval drift, got %+v", drift)
+	}
+	if drift[0].Field != "index.number_of_shards" || drift[0].Expected != "3" || drift[0].Actual != "2" {
+		t.Errorf("unexpected shard drift %+v", drift[0])
+	}
+	if drift[1].Field != "index.refresh_interval" || drift[1].Expected != "5s" || drift[1].Actual != "1s" {
+		t.Errorf("unexpected refresh interval drift %+v", drift[1])
+	}
+
+	rendered := flattenSettings(c.componentTemplate()["template"].(map[string]any)["settings"].(map[string]any))
+	if drift := configuredDrift(ResourceComponentTemplate, "search-documents", c.configuredSettings(ResourceComponentTemplate), rendered); len(drift) != 0 {
+		t.Errorf("expected no drift for the rendered template, got %+v", drift)
+	}
+}
+
+func TestLifecyclePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		lifecycle config.IndexLifecycleConfig
+		want      map[string]string
+	}{
+		{"disabled", config.IndexLifecycleConfig{}, map[string]string{
+			"phases.warm.min_age":                             "90d",
+			"phases.cold.min_age":                             "365d",
+			"phases.delete.min_age":                           "missing",
+			"phases.warm.actions.forcemerge.max_num_segments": "missing",
+		}},
+		{"kept forever", config.IndexLifecycleConfig{Enabled: true, ReadOnlyAfterMonths: 1, MaxSegments: 1}, map[string]string{
+			"phases.warm.min_age":                             "62d",
+			"phases.cold.min_age":                             "365d",
+			"phases.delete.min_age":                           "missing",
+			"phases.warm.actions.forcemerge.max_num_segments": "1",
+		}},
+		{"deleted before the cold tier", config.IndexLifecycleConfig{Enabled: true, ReadOnlyAfterMonths: 2, MaxSegments: 4, RetentionMonths: 6}, map[string]string{
+			"phases.warm.min_age":                             "93d",
+			"phases.cold.min_age":                             "missing",
+			"phases.delete.min_age":                           "217d",
+			"phases.warm.actions.forcemerge.max_num_segments": "4",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{cfg: config.ElasticsearchConfig{Lifecycle: tt.lifecycle}}
+			got := c.configuredSettings(ResourceLifecyclePolicy)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConfiguredDrift_LifecyclePolicy(t *testing.T) {
+	installed := &Client{cfg: config.ElasticsearchConfig{}}
+	c := &Client{cfg: config.ElasticsearchConfig{Lifecycle: config.IndexLifecycleConfig{
+		Enabled: true, ReadOnlyAfterMonths: 1, MaxSegments: 1, RetentionMonths: 12,
+	}}}
+
+	phases := flattenPhases(installed.lifecyclePolicy()["policy"].(map[string]any)["phases"].(map[string]any))
+	drift := configuredDrift(ResourceLifecyclePolicy, "search-monthly", c.configuredSettings(ResourceLifecyclePolicy), phases)
+
+	fields := make([]string, len(drift))
+	for i, d := range drift {
+		fields[i] = d.Field
+	}
+	want := []string{"phases.delete.min_age", "phases.warm.actions.forcemerge.max_num_segments", "phases.warm.min_age"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("expected drift in %v, got %+v", want, drift)
+	}
+}
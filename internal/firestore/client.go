@@ -181,12 +181,20 @@ func (cl *ChangeListener) Listen(ctx context.Context) error {
 				eventType = "DELETE"
 			}
 
+			// The update time orders changes to one document; a removal has
+			// no update time of its own, so it takes the snapshot's read time.
+			version := change.Doc.UpdateTime
+			if change.Kind == firestore.DocumentRemoved {
+				version = snap.ReadTime
+			}
+
 			event := &models.ChangeEvent{
 				Type:       eventType,
 				DocumentID: change.Doc.Ref.ID,
 				Collection: cl.collection,
 				Document:   change.Doc.Data(),
 				Timestamp:  time.Now().UTC(),
+				Version:    version.UnixNano(),
 			}
 
 			if err := cl.handler(ctx, event); err != nil {
@@ -0,0 +1,60 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/api/iterator"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// Page returns up to limit documents of collection in document ID order,
+// starting after afterID ("" for the first page), as CREATE change events
+// versioned by update time and routed by the document's region. Fewer than
+// limit documents means the scan is complete.
+func (c *Client) Page(ctx context.Context, collection, afterID string, limit int) ([]*models.ChangeEvent, error) {
+	ctx, span := observability.StartSpan(ctx, "firestore.page",
+		attribute.String("collection", collection),
+		attribute.String("after_id", afterID),
+		attribute.Int("limit", limit),
+	)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.RequestTimeout)
+	defer cancel()
+
+	query := c.client.Collection(collection).OrderBy(firestore.DocumentID, firestore.Asc).Limit(limit)
+	if afterID != "" {
+		query = query.StartAfter(afterID)
+	}
+
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	var events []*models.ChangeEvent
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("firestore page %s after %q: %w", collection, afterID, err)
+		}
+		data := doc.Data()
+		region, _ := data["region"].(string)
+		events = append(events, &models.ChangeEvent{
+			Type:       "CREATE",
+			DocumentID: doc.Ref.ID,
+			Collection: collection,
+			Document:   data,
+			Region:     region,
+			Timestamp:  doc.UpdateTime,
+			Version:    doc.UpdateTime.UnixNano(),
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,278 @@
+package indexing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// ErrCheckpointMismatch is returned when the checkpoint on disk belongs to a
+// backfill of a different collection or index.
+var ErrCheckpointMismatch = errors.New("backfill checkpoint belongs to another backfill")
+
+// BackfillSource pages through the documents of a collection in a stable
+// order. firestore.Client implements it.
+type BackfillSource interface {
+	Page(ctx context.Context, collection, afterID string, limit int) ([]*models.ChangeEvent, error)
+}
+
+// BackfillOptions controls a backfill run.
+type BackfillOptions struct {
+	Collection string
+	PageSize   int
+	// Rate caps documents indexed per second; zero means unthrottled.
+	Rate float64
+	// CheckpointPath is where progress is saved after every page so an
+	// interrupted run resumes where it stopped. Empty disables checkpoints.
+	CheckpointPath string
+	// Index, when set, receives every document instead of the index the
+	// live pipeline would resolve, e.g. to populate a new index.
+	Index string
+	// MaxRetries bounds resends of items Elasticsearch pushed back on.
+	MaxRetries int
+}
+
+// BackfillCheckpoint is the progress of a backfill, saved after each page.
+type BackfillCheckpoint struct {
+	Collection string    `json:"collection"`
+	Index      string    `json:"index,omitempty"`
+	LastID     string    `json:"last_id"`
+	Indexed    int64     `json:"indexed"`
+	Superseded int64     `json:"superseded"`
+	Failed     int64     `json:"failed"`
+	Done       bool      `json:"done"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Backfiller indexes every document of a collection, for populating a new
+// index or applying a mapping change. Documents go through the same
+// transformation as live change events and carry their update time as the
+// external version, so a backfill running alongside the live pipeline never
+// overwrites a newer change.
+type Backfiller struct {
+	source    BackfillSource
+	transform func(*models.ChangeEvent) (*models.IndexAction, error)
+	bulk      func(context.Context, []models.IndexAction) ([]elasticsearch.BulkItemResult, error)
+	opts      BackfillOptions
+	logger    *zap.Logger
+
+	// retryBackoff is the wait before the first resend of pushed-back items;
+	// it doubles on each further attempt.
+	retryBackoff time.Duration
+}
+
+func NewBackfiller(esClient *elasticsearch.Client, source BackfillSource, opts BackfillOptions, pipelines Pipelines, logger *zap.Logger) *Backfiller {
+	// Only the transformation is used; the processor's buffer and flush
+	// loop are not started.
+	sp := &StreamProcessor{esClient: esClient, pipelines: pipelines}
+	return &Backfiller{
+		source:       source,
+		transform:    sp.transformEvent,
+		bulk:         esClient.BulkIndex,
+		opts:         opts,
+		logger:       logger,
+		retryBackoff: time.Second,
+	}
+}
+
+// Run backfills from the saved checkpoint, if any, until the collection is
+// exhausted or ctx is cancelled. The page in progress when ctx is cancelled
+// is indexed again on resume.
+func (b *Backfiller) Run(ctx context.Context) (BackfillCheckpoint, error) {
+	cp, err := b.loadCheckpoint()
+	if err != nil {
+		return cp, err
+	}
+	if cp.Done {
+		b.logger.Info("backfill already complete", zap.String("collection", cp.Collection))
+		return cp, nil
+	}
+	if cp.LastID != "" {
+		b.logger.Info("resuming backfill",
+			zap.String("collection", cp.Collection),
+			zap.String("after_id", cp.LastID),
+			zap.Int64("indexed", cp.Indexed),
+		)
+	}
+
+	for {
+		start := time.Now()
+		events, err := b.source.Page(ctx, b.opts.Collection, cp.LastID, b.opts.PageSize)
+		if err != nil {
+			return cp, fmt.Errorf("reading backfill page: %w", err)
+		}
+
+		if len(events) > 0 {
+			if err := b.indexPage(ctx, events, &cp); err != nil {
+				return cp, err
+			}
+			cp.LastID = events[len(events)-1].DocumentID
+		}
+		cp.Done = len(events) < b.opts.PageSize
+		if err := b.saveCheckpoint(&cp); err != nil {
+			return cp, err
+		}
+
+		b.logger.Info("backfill page indexed",
+			zap.String("last_id", cp.LastID),
+			zap.Int("documents", len(events)),
+			zap.Int64("indexed", cp.Indexed),
+			zap.Int64("superseded", cp.Superseded),
+			zap.Int64("failed", cp.Failed),
+		)
+		if cp.Done {
+			return cp, nil
+		}
+
+		if err := b.throttle(ctx, len(events), time.Since(start)); err != nil {
+			return cp, err
+		}
+	}
+}
+
+// indexPage indexes one page and adds its outcome to cp. Items pushed back
+// by Elasticsearch are resent up to MaxRetries times before counting as
+// failed.
+func (b *Backfiller) indexPage(ctx context.Context, events []*models.ChangeEvent, cp *BackfillCheckpoint) error {
+	pending := make([]bufferedAction, 0, len(events))
+	for _, event := range events {
+		action, err := b.transform(event)
+		if err != nil {
+			b.logger.Warn("backfill transform failed", zap.String("doc_id", event.DocumentID), zap.Error(err))
+			cp.Failed++
+			observability.IndexingEventsTotal.WithLabelValues("backfill", "error").Inc()
+			continue
+		}
+		if b.opts.Index != "" {
+			action.Index = b.opts.Index
+		}
+		pending = append(pending, bufferedAction{action: *action})
+	}
+
+	backoff := b.retryBackoff
+	for attempt := 0; len(pending) > 0; attempt++ {
+		actions := make([]models.IndexAction, len(pending))
+		for i := range pending {
+			actions[i] = pending[i].action
+		}
+		results, err := b.bulk(ctx, actions)
+		if err != nil {
+			return fmt.Errorf("backfill bulk index: %w", err)
+		}
+
+		applied, superseded, retry, rejected := splitBulkResults(pending, results)
+		cp.Indexed += int64(len(applied))
+		cp.Superseded += int64(len(superseded))
+		cp.Failed += int64(len(rejected))
+		observability.IndexingEventsTotal.WithLabelValues("backfill", "success").Add(float64(len(applied)))
+		observability.IndexingEventsTotal.WithLabelValues("backfill", "version_conflict").Add(float64(len(superseded)))
+		observability.IndexingEventsTotal.WithLabelValues("backfill", "rejected").Add(float64(len(rejected)))
+		for _, r := range rejected {
+			b.logger.Warn("backfill item rejected", zap.String("doc_id", r.item.action.ID), zap.Error(r.err))
+		}
+
+		pending = retry
+		if len(pending) == 0 {
+			break
+		}
+		if attempt >= b.opts.MaxRetries {
+			cp.Failed += int64(len(pending))
+			observability.IndexingEventsTotal.WithLabelValues("backfill", "rejected").Add(float64(len(pending)))
+			b.logger.Warn("backfill items still pushed back after retries", zap.Int("count", len(pending)))
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return nil
+}
+
+// throttle waits out the remainder of the time n documents may take at the
+// configured rate.
+func (b *Backfiller) throttle(ctx context.Context, n int, elapsed time.Duration) error {
+	if b.opts.Rate <= 0 {
+		return nil
+	}
+	wait := time.Duration(float64(n)/b.opts.Rate*float64(time.Second)) - elapsed
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+func (b *Backfiller) loadCheckpoint() (BackfillCheckpoint, error) {
+	fresh := BackfillCheckpoint{Collection: b.opts.Collection, Index: b.opts.Index}
+	if b.opts.CheckpointPath == "" {
+		return fresh, nil
+	}
+	data, err := os.ReadFile(b.opts.CheckpointPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fresh, nil
+	}
+	if err != nil {
+		return fresh, fmt.Errorf("reading backfill checkpoint: %w", err)
+	}
+
+	var cp BackfillCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return fresh, fmt.Errorf("decoding backfill checkpoint: %w", err)
+	}
+	if cp.Collection != fresh.Collection || cp.Index != fresh.Index {
+		return fresh, fmt.Errorf("%w: checkpoint is for collection %q index %q", ErrCheckpointMismatch, cp.Collection, cp.Index)
+	}
+	return cp, nil
+}
+
+// saveCheckpoint writes cp atomically so a crash mid-write never leaves a
+// truncated checkpoint behind.
+func (b *Backfiller) saveCheckpoint(cp *BackfillCheckpoint) error {
+	if b.opts.CheckpointPath == "" {
+		return nil
+	}
+	cp.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("encoding backfill checkpoint: %w", err)
+	}
+
+	dir := filepath.Dir(b.opts.CheckpointPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating backfill checkpoint dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".backfill-*")
+	if err != nil {
+		return fmt.Errorf("creating backfill checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing backfill checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing backfill checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.opts.CheckpointPath); err != nil {
+		return fmt.Errorf("replacing backfill checkpoint: %w", err)
+	}
+	return nil
+}
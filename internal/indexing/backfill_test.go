@@ -0,0 +1,175 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+// fakeSource serves the IDs doc-00 .. doc-(n-1) in order.
+type fakeSource struct {
+	n     int
+	fail  bool
+	pages int
+}
+
+func (s *fakeSource) Page(ctx context.Context, collection, afterID string, limit int) ([]*models.ChangeEvent, error) {
+	if s.fail {
+		return nil, errors.New("firestore unavailable")
+	}
+	s.pages++
+	var events []*models.ChangeEvent
+	for i := 0; i < s.n && len(events) < limit; i++ {
+		id := fmt.Sprintf("doc-%02d", i)
+		if id > afterID {
+			events = append(events, &models.ChangeEvent{Type: "CREATE", DocumentID: id, Version: 1})
+		}
+	}
+	return events, nil
+}
+
+func newTestBackfiller(source BackfillSource, opts BackfillOptions, bulk func(context.Context, []models.IndexAction) ([]elasticsearch.BulkItemResult, error)) *Backfiller {
+	return &Backfiller{
+		source: source,
+		transform: func(event *models.ChangeEvent) (*models.IndexAction, error) {
+			return &models.IndexAction{Action: "index", Index: "live", ID: event.DocumentID, Version: event.Version}, nil
+		},
+		bulk:   bulk,
+		opts:   opts,
+		logger: zap.NewNop(),
+	}
+}
+
+func okBulk(indexed *[]models.IndexAction) func(context.Context, []models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+	return func(ctx context.Context, actions []models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+		*indexed = append(*indexed, actions...)
+		results := make([]elasticsearch.BulkItemResult, len(actions))
+		for i, a := range actions {
+			results[i] = elasticsearch.BulkItemResult{ID: a.ID, Status: 201}
+		}
+		return results, nil
+	}
+}
+
+func TestBackfill_IndexesAllPages(t *testing.T) {
+	var indexed []models.IndexAction
+	source := &fakeSource{n: 7}
+	b := newTestBackfiller(source, BackfillOptions{Collection: "documents", PageSize: 3, Index: "search-v2"}, okBulk(&indexed))
+
+	cp, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cp.Done || cp.Indexed != 7 || cp.LastID != "doc-06" {
+		t.Errorf("unexpected checkpoint %+v", cp)
+	}
+	if source.pages != 3 {
+		t.Errorf("expected 3 pages, got %d", source.pages)
+	}
+	for _, a := range indexed {
+		if a.Index != "search-v2" {
+			t.Errorf("expected index override, got %q", a.Index)
+		}
+	}
+}
+
+func TestBackfill_ResumesFromCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cp.json")
+	opts := BackfillOptions{Collection: "documents", PageSize: 2, CheckpointPath: path}
+
+	// The first run fails on its second page.
+	calls := 0
+	var indexed []models.IndexAction
+	ok := okBulk(&indexed)
+	first := newTestBackfiller(&fakeSource{n: 5}, opts, func(ctx context.Context, actions []models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+		calls++
+		if calls == 2 {
+			return nil, errors.New("cluster unavailable")
+		}
+		return ok(ctx, actions)
+	})
+	if _, err := first.Run(context.Background()); err == nil {
+		t.Fatal("expected the first run to fail")
+	}
+
+	indexed = nil
+	second := newTestBackfiller(&fakeSource{n: 5}, opts, okBulk(&indexed))
+	cp, err := second.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(indexed) == 0 || indexed[0].ID != "doc-02" {
+		t.Errorf("expected resume after doc-01, got %+v", indexed)
+	}
+	if !cp.Done || cp.Indexed != 5 {
+		t.Errorf("unexpected checkpoint %+v", cp)
+	}
+
+	// A completed backfill is not run again.
+	indexed = nil
+	if _, err := second.Run(context.Background()); err != nil || len(indexed) != 0 {
+		t.Errorf("expected completed backfill to be a no-op, indexed %d, err %v", len(indexed), err)
+	}
+}
+
+func TestBackfill_CheckpointMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cp.json")
+	var indexed []models.IndexAction
+	b := newTestBackfiller(&fakeSource{n: 1}, BackfillOptions{Collection: "documents", PageSize: 2, CheckpointPath: path}, okBulk(&indexed))
+	if _, err := b.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	other := newTestBackfiller(&fakeSource{n: 1}, BackfillOptions{Collection: "products", PageSize: 2, CheckpointPath: path}, okBulk(&indexed))
+	if _, err := other.Run(context.Background()); !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("expected ErrCheckpointMismatch, got %v", err)
+	}
+}
+
+func TestBackfill_RetriesPushedBackItems(t *testing.T) {
+	attempts := map[string]int{}
+	b := newTestBackfiller(&fakeSource{n: 3}, BackfillOptions{Collection: "documents", PageSize: 10, MaxRetries: 2},
+		func(ctx context.Context, actions []models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+			results := make([]elasticsearch.BulkItemResult, len(actions))
+			for i, a := range actions {
+				attempts[a.ID]++
+				switch {
+				case a.ID == "doc-00":
+					results[i] = elasticsearch.BulkItemResult{ID: a.ID, Status: 400, ErrorType: "mapper_parsing_exception"}
+				case a.ID == "doc-01" && attempts[a.ID] == 1:
+					results[i] = elasticsearch.BulkItemResult{ID: a.ID, Status: 429, ErrorType: "es_rejected_execution_exception"}
+				case a.ID == "doc-02":
+					results[i] = elasticsearch.BulkItemResult{ID: a.ID, Status: 409, ErrorType: "version_conflict_engine_exception"}
+				default:
+					results[i] = elasticsearch.BulkItemResult{ID: a.ID, Status: 201}
+				}
+			}
+			return results, nil
+		})
+
+	cp, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cp.Indexed != 1 || cp.Superseded != 1 || cp.Failed != 1 {
+		t.Errorf("unexpected checkpoint %+v", cp)
+	}
+	if attempts["doc-01"] != 2 || attempts["doc-00"] != 1 {
+		t.Errorf("expected only the pushed-back item resent, got %v", attempts)
+	}
+}
+
+func TestBackfill_SourceError(t *testing.T) {
+	var indexed []models.IndexAction
+	b := newTestBackfiller(&fakeSource{fail: true}, BackfillOptions{Collection: "documents", PageSize: 2}, okBulk(&indexed))
+	if _, err := b.Run(context.Background()); err == nil {
+		t.Error("expected source error")
+	}
+}
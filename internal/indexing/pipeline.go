@@ -0,0 +1,183 @@
+package indexing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// defaultSearchFields are the source fields indexed when a pipeline does not
+// list its own.
+var defaultSearchFields = []string{
+	"title", "description", "category", "tags",
+	"region", "created_at", "popularity_score", "geo_point",
+}
+
+// defaultPipeline indexes the standard search fields unchanged. It applies
+// to collections without a configured pipeline.
+var defaultPipeline = &Pipeline{
+	typeField:   "type",
+	regionField: "region",
+	fields:      defaultSearchFields,
+}
+
+// Pipelines are the configured pipelines by collection.
+type Pipelines map[string]*Pipeline
+
+// For returns the pipeline for collection, or the default one.
+func (ps Pipelines) For(collection string) *Pipeline {
+	if p, ok := ps[collection]; ok {
+		return p
+	}
+	return defaultPipeline
+}
+
+// Pipeline turns a collection's source documents into search documents.
+type Pipeline struct {
+	collection  string
+	typeField   string
+	regionField string
+	// fields are the indexed fields: the configured ones plus those the
+	// stages write.
+	fields []string
+	stages []Stage
+}
+
+// NewPipelines builds the configured pipelines. Enrichment files are read
+// here, so a missing or malformed one fails at startup.
+func NewPipelines(cfg config.IndexingConfig) (Pipelines, error) {
+	ps := make(Pipelines, len(cfg.Pipelines))
+	for collection, pc := range cfg.Pipelines {
+		p, err := newPipeline(collection, pc)
+		if err != nil {
+			return nil, fmt.Errorf("indexing pipeline %q: %w", collection, err)
+		}
+		ps[collection] = p
+	}
+	return ps, nil
+}
+
+func newPipeline(collection string, pc config.PipelineConfig) (*Pipeline, error) {
+	p := &Pipeline{
+		collection:  collection,
+		typeField:   pc.TypeField,
+		regionField: pc.RegionField,
+	}
+	if p.typeField == "" {
+		p.typeField = "type"
+	}
+	if p.regionField == "" {
+		p.regionField = "region"
+	}
+
+	fields := pc.Fields
+	if len(fields) == 0 {
+		fields = defaultSearchFields
+	}
+	seen := make(map[string]bool)
+	addField := func(f string) {
+		if !seen[f] {
+			seen[f] = true
+			p.fields = append(p.fields, f)
+		}
+	}
+	for _, f := range fields {
+		addField(f)
+	}
+
+	for i, sc := range pc.Stages {
+		st, err := newStage(sc)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		p.stages = append(p.stages, st)
+		switch sc.Type {
+		case "rename":
+			addField(sc.To)
+		case "compute", "enrich":
+			addField(sc.Field)
+		}
+	}
+	return p, nil
+}
+
+// newStage builds the stage sc describes; sc has passed config validation.
+func newStage(sc config.PipelineStageConfig) (Stage, error) {
+	switch sc.Type {
+	case "rename":
+		return renameStage{from: sc.From, to: sc.To}, nil
+	case "coerce":
+		return coerceStage{field: sc.Field, as: sc.As}, nil
+	case "strip_html":
+		return stripHTMLStage{fields: sc.Fields}, nil
+	case "compute":
+		return computeStage{field: sc.Field, source: sc.Source, fn: sc.Fn, factor: sc.Factor}, nil
+	case "enrich":
+		values := sc.Values
+		if sc.File != "" {
+			data, err := os.ReadFile(sc.File)
+			if err != nil {
+				return nil, fmt.Errorf("reading enrichment file: %w", err)
+			}
+			if err := json.Unmarshal(data, &values); err != nil {
+				return nil, fmt.Errorf("parsing enrichment file %s: %w", sc.File, err)
+			}
+		}
+		return enrichStage{field: sc.Field, source: sc.Source, values: values}, nil
+	case "drop":
+		in := make(map[string]bool, len(sc.In))
+		for _, v := range sc.In {
+			in[valueText(v)] = true
+		}
+		return dropStage{field: sc.Field, in: in, missing: sc.Missing}, nil
+	}
+	return nil, fmt.Errorf("unknown stage type %q", sc.Type)
+}
+
+// Process runs the stages on a copy of doc, leaving the source document
+// intact for analytics and cache invalidation. dropped reports that a drop
+// stage matched, in which case the remaining stages are skipped.
+func (p *Pipeline) Process(doc map[string]any) (out map[string]any, dropped bool, err error) {
+	out = make(map[string]any, len(doc))
+	for k, v := range doc {
+		out[k] = v
+	}
+
+	for _, st := range p.stages {
+		res, err := st.Apply(out)
+		if err != nil {
+			p.observe(st, "error")
+			return nil, false, fmt.Errorf("pipeline stage %s: %w", st.Name(), err)
+		}
+		switch res {
+		case StageChanged:
+			p.observe(st, "changed")
+		case StageDropped:
+			p.observe(st, "dropped")
+			return out, true, nil
+		}
+	}
+	return out, false, nil
+}
+
+func (p *Pipeline) observe(st Stage, outcome string) {
+	observability.IndexingPipelineDocuments.WithLabelValues(p.collection, st.Name(), outcome).Inc()
+}
+
+// searchFields returns the indexed fields of a processed document, stamped
+// with the indexing time.
+func (p *Pipeline) searchFields(doc map[string]any) map[string]any {
+	fields := map[string]any{
+		"updated_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, field := range p.fields {
+		if v, ok := doc[field]; ok {
+			fields[field] = v
+		}
+	}
+	return fields
+}
@@ -0,0 +1,188 @@
+package indexing
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+func TestStages(t *testing.T) {
+	firestoreTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		stage Stage
+		doc   map[string]any
+		want  map[string]any
+		res   StageResult
+	}{
+		{"rename", renameStage{from: "name", to: "title"},
+			map[string]any{"name": "Lamp"}, map[string]any{"title": "Lamp"}, StageChanged},
+		{"rename absent", renameStage{from: "name", to: "title"},
+			map[string]any{"title": "Lamp"}, map[string]any{"title": "Lamp"}, StageUnchanged},
+		{"coerce float from text", coerceStage{field: "price", as: "float"},
+			map[string]any{"price": " 12.5"}, map[string]any{"price": 12.5}, StageChanged},
+		{"coerce int keeps json number", coerceStage{field: "stock", as: "int"},
+			map[string]any{"stock": float64(3)}, map[string]any{"stock": float64(3)}, StageUnchanged},
+		{"coerce bool", coerceStage{field: "active", as: "bool"},
+			map[string]any{"active": "true"}, map[string]any{"active": true}, StageChanged},
+		{"coerce date from millis", coerceStage{field: "created_at", as: "date"},
+			map[string]any{"created_at": float64(1767225600000)}, map[string]any{"created_at": "2026-01-01T00:00:00Z"}, StageChanged},
+		{"coerce date from text", coerceStage{field: "created_at", as: "date"},
+			map[string]any{"created_at": "2026-01-01"}, map[string]any{"created_at": "2026-01-01T00:00:00Z"}, StageChanged},
+		{"coerce date from firestore timestamp", coerceStage{field: "created_at", as: "date"},
+			map[string]any{"created_at": time.Date(2026, 1, 1, 1, 0, 0, 0, time.FixedZone("CET", 3600))}, map[string]any{"created_at": "2026-01-01T00:00:00Z"}, StageChanged},
+		{"coerce date from time pointer", coerceStage{field: "created_at", as: "date"},
+			map[string]any{"created_at": &firestoreTime}, map[string]any{"created_at": "2026-01-01T00:00:00Z"}, StageChanged},
+		{"coerce string list", coerceStage{field: "tags", as: "string_list"},
+			map[string]any{"tags": "red, blue,,green"}, map[string]any{"tags": []any{"red", "blue", "green"}}, StageChanged},
+		{"strip html", stripHTMLStage{fields: []string{"description", "missing"}},
+			map[string]any{"description": "<p>Fast &amp; <b>quiet</b></p><script>track()</script>"},
+			map[string]any{"description": "Fast & quiet"}, StageChanged},
+		{"strip html plain text", stripHTMLStage{fields: []string{"description"}},
+			map[string]any{"description": "Fast and quiet"}, map[string]any{"description": "Fast and quiet"}, StageUnchanged},
+		{"compute length", computeStage{field: "title_length", source: "title", fn: "length"},
+			map[string]any{"title": "Café"}, map[string]any{"title": "Café", "title_length": 4}, StageChanged},
+		{"compute scale", computeStage{field: "price", source: "price_cents", fn: "scale", factor: 0.01},
+			map[string]any{"price_cents": float64(1250)}, map[string]any{"price_cents": float64(1250), "price": 12.5}, StageChanged},
+		{"compute absent source", computeStage{field: "price", source: "price_cents", fn: "scale", factor: 0.01},
+			map[string]any{}, map[string]any{}, StageUnchanged},
+		{"enrich", enrichStage{field: "category_name", source: "category", values: map[string]any{"7": "Lighting"}},
+			map[string]any{"category": float64(7)}, map[string]any{"category": float64(7), "category_name": "Lighting"}, StageChanged},
+		{"enrich unknown key", enrichStage{field: "category_name", source: "category", values: map[string]any{"7": "Lighting"}},
+			map[string]any{"category": float64(8)}, map[string]any{"category": float64(8)}, StageUnchanged},
+		{"drop by value", dropStage{field: "status", in: map[string]bool{"draft": true}},
+			map[string]any{"status": "draft"}, map[string]any{"status": "draft"}, StageDropped},
+		{"drop missing", dropStage{field: "title", missing: true},
+			map[string]any{}, map[string]any{}, StageDropped},
+		{"drop no match", dropStage{field: "status", in: map[string]bool{"draft": true}, missing: true},
+			map[string]any{"status": "published"}, map[string]any{"status": "published"}, StageUnchanged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.stage.Apply(tt.doc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res != tt.res {
+				t.Errorf("expected result %d, got %d", tt.res, res)
+			}
+			if !reflect.DeepEqual(tt.doc, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, tt.doc)
+			}
+		})
+	}
+}
+
+func TestStages_Errors(t *testing.T) {
+	for name, st := range map[string]Stage{
+		"coerce int":  coerceStage{field: "v", as: "int"},
+		"coerce date": coerceStage{field: "v", as: "date"},
+		"scale":       computeStage{field: "x", source: "v", fn: "scale", factor: 2},
+	} {
+		doc := map[string]any{"v": "soon"}
+		if _, err := st.Apply(doc); err == nil {
+			t.Errorf("%s: expected an error for %v", name, doc["v"])
+		}
+	}
+}
+
+func TestPipeline_Process(t *testing.T) {
+	ps, err := NewPipelines(config.IndexingConfig{Pipelines: map[string]config.PipelineConfig{
+		"products": {
+			TypeField: "kind",
+			Fields:    []string{"title", "region"},
+			Stages: []config.PipelineStageConfig{
+				{Type: "rename", From: "name", To: "title"},
+				{Type: "drop", Field: "status", In: []any{"draft"}},
+				{Type: "compute", Field: "price", Source: "price_cents", Fn: "scale", Factor: 0.01},
+			},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("building pipelines: %v", err)
+	}
+	p := ps.For("products")
+	if ps.For("documents") != defaultPipeline {
+		t.Error("expected collections without a pipeline to use the default one")
+	}
+
+	src := map[string]any{"name": "Lamp", "price_cents": float64(1250), "secret": "x"}
+	doc, dropped, err := p.Process(src)
+	if err != nil || dropped {
+		t.Fatalf("unexpected result dropped=%v err=%v", dropped, err)
+	}
+	if _, ok := src["title"]; ok {
+		t.Error("the source document must not be modified")
+	}
+
+	fields := p.searchFields(doc)
+	delete(fields, "updated_at")
+	want := map[string]any{"title": "Lamp", "price": 12.5}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("expected configured and computed fields %v, got %v", want, fields)
+	}
+
+	if _, dropped, _ := p.Process(map[string]any{"name": "Lamp", "status": "draft"}); !dropped {
+		t.Error("expected draft to be dropped")
+	}
+}
+
+func TestNewPipelines_EnrichmentFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "categories.json")
+	if err := os.WriteFile(path, []byte(`{"7": "Lighting"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.IndexingConfig{Pipelines: map[string]config.PipelineConfig{
+		"products": {Stages: []config.PipelineStageConfig{
+			{Type: "enrich", Field: "category_name", Source: "category", File: path},
+		}},
+	}}
+
+	ps, err := NewPipelines(cfg)
+	if err != nil {
+		t.Fatalf("building pipelines: %v", err)
+	}
+	doc, _, _ := ps.For("products").Process(map[string]any{"category": "7"})
+	if doc["category_name"] != "Lighting" {
+		t.Errorf("expected enrichment from file, got %v", doc)
+	}
+
+	cfg.Pipelines["products"].Stages[0].File = filepath.Join(t.TempDir(), "missing.json")
+	if _, err := NewPipelines(cfg); err == nil {
+		t.Error("expected an error for a missing enrichment file")
+	}
+}
+
+func TestTransformEvent_DroppedDocumentIsDeleted(t *testing.T) {
+	ps, err := NewPipelines(config.IndexingConfig{Pipelines: map[string]config.PipelineConfig{
+		"products": {Stages: []config.PipelineStageConfig{
+			{Type: "drop", Field: "status", In: []any{"archived"}},
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("building pipelines: %v", err)
+	}
+	sp := &StreamProcessor{esClient: &elasticsearch.Client{}, pipelines: ps}
+
+	action, err := sp.transformEvent(&models.ChangeEvent{
+		Type:       "UPDATE",
+		DocumentID: "p-1",
+		Collection: "products",
+		Region:     "us",
+		Document:   map[string]any{"title": "Lamp", "status": "archived"},
+		Timestamp:  time.Now(),
+		Version:    3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if action.Action != "delete" || action.Body != nil || action.Version != 3 {
+		t.Errorf("expected a versioned delete, got %+v", action)
+	}
+}
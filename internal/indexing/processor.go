@@ -17,7 +17,8 @@ import (
 )
 
 const (
-	// maxBufferSize prevents unbounded buffer growth on repeated flush failures.
+	// maxBufferSize bounds actions awaiting bulk indexing. Once reached,
+	// HandleEvent blocks until a flush succeeds instead of dropping events.
 	maxBufferSize = 50000
 	// maxAsyncWorkers bounds concurrent background goroutines for CH writes and cache invalidation.
 	maxAsyncWorkers = 128
@@ -30,12 +31,25 @@ type StreamProcessor struct {
 	esCfg    config.ElasticsearchConfig
 	logger   *zap.Logger
 
-	// Bulk buffer
-	mu     sync.Mutex
-	buffer []models.IndexAction
-	ticker *time.Ticker
-	done   chan struct{}
-	loopWg sync.WaitGroup // tracks flushLoop goroutine lifetime
+	// pipelines transform each collection's documents before indexing.
+	pipelines      Pipelines
+	bulk           func(context.Context, []models.IndexAction) ([]elasticsearch.BulkItemResult, error)
+	refreshAliases func(context.Context) error
+
+	// refreshDelay defers tag-based cache eviction until flushed documents
+	// are visible to search.
+	refreshDelay time.Duration
+
+	// Bulk buffer. slots holds one token per buffered action and is what
+	// producers block on when the buffer is full; flushMu keeps flushes in
+	// order so a retried batch never lands after a newer one.
+	mu      sync.Mutex
+	buffer  []bufferedAction
+	slots   chan struct{}
+	flushMu sync.Mutex
+	ticker  *time.Ticker
+	done    chan struct{}
+	loopWg  sync.WaitGroup // tracks flushLoop goroutine lifetime
 
 	// Semaphore to bound background goroutines
 	asyncSem chan struct{}
@@ -46,18 +60,29 @@ func NewStreamProcessor(
 	chClient *clickhouse.Client,
 	cache *cache.RedisCache,
 	esCfg config.ElasticsearchConfig,
+	pipelines Pipelines,
 	logger *zap.Logger,
 ) *StreamProcessor {
 	sp := &StreamProcessor{
-		esClient: esClient,
-		chClient: chClient,
-		cache:    cache,
-		esCfg:    esCfg,
-		logger:   logger,
-		buffer:   make([]models.IndexAction, 0, esCfg.BulkSize),
-		ticker:   time.NewTicker(esCfg.BulkFlushInterval),
-		done:     make(chan struct{}),
-		asyncSem: make(chan struct{}, maxAsyncWorkers),
+		esClient:       esClient,
+		chClient:       chClient,
+		cache:          cache,
+		esCfg:          esCfg,
+		pipelines:      pipelines,
+		bulk:           esClient.BulkIndex,
+		refreshAliases: esClient.RefreshAliases,
+		logger:         logger,
+		buffer:         make([]bufferedAction, 0, esCfg.BulkSize),
+		slots:          make(chan struct{}, maxBufferSize),
+		ticker:         time.NewTicker(esCfg.BulkFlushInterval),
+		done:           make(chan struct{}),
+		asyncSem:       make(chan struct{}, maxAsyncWorkers),
+	}
+
+	// "-1" (refresh disabled) and other non-duration values fail to parse;
+	// evict immediately in that case.
+	if d, err := time.ParseDuration(esCfg.RefreshInterval); err == nil && d > 0 {
+		sp.refreshDelay = d
 	}
 
 	sp.loopWg.Add(1)
@@ -69,21 +94,73 @@ func NewStreamProcessor(
 	return sp
 }
 
-func (sp *StreamProcessor) HandleEvent(ctx context.Context, event *models.ChangeEvent) error {
+// bufferedAction is an action awaiting bulk indexing, with the callback that
+// reports its outcome to the event's source.
+type bufferedAction struct {
+	action models.IndexAction
+	target indexTarget
+	ack    func(error)
+	// scopeChanged reports that the change adds the document to or removes
+	// it from its category and region: a create or a delete. An update in
+	// place only affects the responses holding the document.
+	scopeChanged bool
+}
+
+// indexTarget is what an action's index was resolved from, so it can be
+// resolved again when a reindex moves the aliases under it.
+type indexTarget struct {
+	docType, region string
+	// mirror marks the copy of a change written to the mirror alias.
+	mirror bool
+	// alias reports that the index resolved to one of the aliases.
+	alias bool
+}
+
+// HandleEvent buffers event for bulk indexing. ack, if not nil, is called once
+// Elasticsearch has applied the action (nil) or rejected it permanently.
+// HandleEvent blocks while the buffer is full, and returns an error only if
+// the event was not buffered.
+func (sp *StreamProcessor) HandleEvent(ctx context.Context, event *models.ChangeEvent, ack func(error)) error {
 	if sp.esClient == nil {
 		return fmt.Errorf("elasticsearch client unavailable, cannot index")
 	}
 
 	// Transform to index action
-	action, err := sp.transformEvent(event)
+	action, target, err := sp.transform(event)
 	if err != nil {
 		return fmt.Errorf("transforming event: %w", err)
 	}
 
+	scopeChanged := event.Type == "CREATE" || action.Action == "delete"
+	pending := []bufferedAction{{action: *action, target: target, ack: ack, scopeChanged: scopeChanged}}
+
+	// During a reindex every change is also written to the mirror index so
+	// it catches up with the live one. The copy is not acknowledged: the
+	// event's outcome is that of the live write.
+	if mirror := sp.esClient.MirrorIndex(); mirror != "" && mirror != action.Index {
+		copied := *action
+		copied.Index = mirror
+		pending = append(pending, bufferedAction{action: copied, target: indexTarget{mirror: true, alias: true}, scopeChanged: scopeChanged})
+	}
+
+	// Wait for room in the buffer; a stalled Elasticsearch slows consumers
+	// down instead of losing their events.
+	for i := range pending {
+		select {
+		case sp.slots <- struct{}{}:
+		case <-ctx.Done():
+			for ; i > 0; i-- {
+				<-sp.slots
+			}
+			return ctx.Err()
+		}
+	}
+
 	// Buffer for bulk indexing
 	sp.mu.Lock()
-	sp.buffer = append(sp.buffer, *action)
+	sp.buffer = append(sp.buffer, pending...)
 	shouldFlush := len(sp.buffer) >= sp.esCfg.BulkSize
+	observability.IndexingBufferedActions.Set(float64(len(sp.buffer)))
 	sp.mu.Unlock()
 
 	if shouldFlush {
@@ -107,6 +184,9 @@ func (sp *StreamProcessor) HandleEvent(ctx context.Context, event *models.Change
 	}
 
 	// Invalidate relevant caches (async, bounded, targeted keys)
+	if sp.cache == nil {
+		return nil
+	}
 	sp.asyncDo(func() {
 		cacheCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
@@ -137,55 +217,85 @@ func (sp *StreamProcessor) asyncDo(fn func()) {
 }
 
 func (sp *StreamProcessor) transformEvent(event *models.ChangeEvent) (*models.IndexAction, error) {
+	action, _, err := sp.transform(event)
+	return action, err
+}
+
+// transform turns event into an index action, returning what its index was
+// resolved from.
+func (sp *StreamProcessor) transform(event *models.ChangeEvent) (*models.IndexAction, indexTarget, error) {
 	action := &models.IndexAction{
 		ID:        event.DocumentID,
 		Routing:   event.Region,
+		Version:   event.Version,
 		Timestamp: event.Timestamp,
 	}
 
+	pipeline := sp.pipelines.For(event.Collection)
+	doc := event.Document
+	dropped := false
+	if event.Type == "CREATE" || event.Type == "UPDATE" {
+		var err error
+		if doc, dropped, err = pipeline.Process(event.Document); err != nil {
+			return nil, indexTarget{}, err
+		}
+	}
+
 	// Resolve index name
 	docType := "general"
-	if t, ok := event.Document["type"].(string); ok {
+	if t, ok := doc[pipeline.typeField].(string); ok {
 		docType = t
 	}
 	region := event.Region
 	if region == "" {
-		if r, ok := event.Document["region"].(string); ok {
+		if r, ok := doc[pipeline.regionField].(string); ok {
 			region = r
 		}
 	}
-	action.Index = sp.esClient.ResolveIndex(docType, region)
+	target := indexTarget{docType: docType, region: region}
+	action.Index = sp.resolveIndex(&target)
 
 	switch event.Type {
 	case "CREATE", "UPDATE":
+		if dropped {
+			// The document may have been indexed before it matched a drop
+			// rule, so it is removed rather than skipped.
+			action.Action = "delete"
+			break
+		}
 		action.Action = "index"
-		action.Body = sp.extractSearchFields(event.Document)
+		action.Body = pipeline.searchFields(doc)
+		// Behind the aliases all regions share one index, so searches
+		// filter on the field rather than the index name.
+		if _, ok := action.Body["region"]; !ok && region != "" {
+			action.Body["region"] = region
+		}
 	case "DELETE":
 		action.Action = "delete"
 	default:
-		return nil, fmt.Errorf("unknown event type: %s", event.Type)
+		return nil, indexTarget{}, fmt.Errorf("unknown event type: %s", event.Type)
 	}
 
-	return action, nil
+	return action, target, nil
 }
 
-func (sp *StreamProcessor) extractSearchFields(doc map[string]any) map[string]any {
-	fields := map[string]any{
-		"updated_at": time.Now().UTC().Format(time.RFC3339),
-	}
-
-	searchableFields := []string{
-		"title", "description", "category", "tags",
-		"region", "created_at", "popularity_score", "geo_point",
-	}
-
-	for _, field := range searchableFields {
-		if v, ok := doc[field]; ok {
-			fields[field] = v
-		}
+// resolveIndex returns the index for target under the current aliases and
+// records whether it is an alias. A mirror copy resolves to "" once nothing
+// is mirrored any more.
+func (sp *StreamProcessor) resolveIndex(target *indexTarget) string {
+	var index string
+	if target.mirror {
+		index = sp.esClient.MirrorIndex()
+	} else {
+		index = sp.esClient.ResolveIndex(target.docType, target.region)
 	}
+	target.alias = index != "" && sp.esClient.IsAlias(index)
+	return index
+}
 
-	return fields
+// extractSearchFields returns the standard search fields of doc.
+func (sp *StreamProcessor) extractSearchFields(doc map[string]any) map[string]any {
+	return defaultPipeline.searchFields(doc)
 }
 
 func (sp *StreamProcessor) flushLoop() {
@@ -204,43 +314,176 @@ func (sp *StreamProcessor) flushLoop() {
 }
 
 func (sp *StreamProcessor) flush(ctx context.Context) error {
+	rejected, err := sp.flushBatch(ctx)
+	// Rejections are reported once flushMu is released: their source
+	// dead-letters each one, which must not hold up the next flush.
+	if len(rejected) > 0 {
+		sp.release(nil, rejected)
+	}
+	return err
+}
+
+// flushBatch sends the buffered actions to Elasticsearch and settles all but
+// the rejected ones, which it returns with their buffer slots still held.
+func (sp *StreamProcessor) flushBatch(ctx context.Context) ([]rejectedAction, error) {
+	sp.flushMu.Lock()
+	defer sp.flushMu.Unlock()
+
 	sp.mu.Lock()
 	if len(sp.buffer) == 0 {
 		sp.mu.Unlock()
-		return nil
+		return nil, nil
 	}
-	batch := make([]models.IndexAction, len(sp.buffer))
+	batch := make([]bufferedAction, len(sp.buffer))
 	copy(batch, sp.buffer)
 	sp.buffer = sp.buffer[:0]
 	sp.mu.Unlock()
 
-	start := time.Now()
-	if err := sp.esClient.BulkIndex(ctx, batch); err != nil {
-		// Put failed items back into buffer, but cap to prevent unbounded growth
-		sp.mu.Lock()
-		combined := append(batch, sp.buffer...)
-		if len(combined) > maxBufferSize {
-			dropped := len(combined) - maxBufferSize
-			combined = combined[dropped:]
-			sp.logger.Error("buffer overflow, dropping oldest events",
-				zap.Int("dropped", dropped),
-				zap.Int("buffer_size", maxBufferSize),
-			)
-		}
-		sp.buffer = combined
-		sp.mu.Unlock()
+	actions := make([]models.IndexAction, len(batch))
+	for i := range batch {
+		actions[i] = batch[i].action
+	}
 
+	start := time.Now()
+	results, err := sp.bulk(ctx, actions)
+	if err != nil {
+		// Put the batch back ahead of newer actions. Its buffer slots are
+		// still held, so producers block rather than the buffer growing.
+		sp.requeue(batch)
 		observability.IndexingEventsTotal.WithLabelValues("bulk", "error").Inc()
-		return fmt.Errorf("bulk index flush: %w", err)
+		return nil, fmt.Errorf("bulk index flush: %w", err)
+	}
+
+	applied, superseded, retry, rejected := splitBulkResults(batch, results)
+	if len(retry) > 0 {
+		if aliasRemoved(batch, results) {
+			retry = sp.reroute(ctx, retry)
+		}
+		sp.requeue(retry)
+	}
+	for _, r := range rejected {
+		sp.logger.Warn("bulk item rejected",
+			zap.String("doc_id", r.item.action.ID),
+			zap.String("index", r.item.action.Index),
+			zap.Error(r.err),
+		)
 	}
+	sp.release(applied, nil)
+	sp.release(superseded, nil)
 
-	observability.IndexingEventsTotal.WithLabelValues("bulk", "success").Add(float64(len(batch)))
+	observability.IndexingEventsTotal.WithLabelValues("bulk", "success").Add(float64(len(applied)))
+	observability.IndexingEventsTotal.WithLabelValues("bulk", "version_conflict").Add(float64(len(superseded)))
+	observability.IndexingEventsTotal.WithLabelValues("bulk", "retry").Add(float64(len(retry)))
+	observability.IndexingEventsTotal.WithLabelValues("bulk", "rejected").Add(float64(len(rejected)))
+
+	sp.invalidateAfterRefresh(applied)
 	sp.logger.Info("bulk flush completed",
 		zap.Int("count", len(batch)),
+		zap.Int("applied", len(applied)),
+		zap.Int("superseded", len(superseded)),
+		zap.Int("retry", len(retry)),
+		zap.Int("rejected", len(rejected)),
 		zap.Duration("duration", time.Since(start)),
 	)
 
-	return nil
+	return rejected, nil
+}
+
+// rejectedAction is a buffered action Elasticsearch refused permanently.
+type rejectedAction struct {
+	item bufferedAction
+	err  error
+}
+
+// splitBulkResults sorts a flushed batch by outcome: actions Elasticsearch
+// applied, actions skipped because the index already holds a newer version,
+// actions to send again because the cluster pushed back, and actions it
+// rejected for good, such as mapping errors.
+func splitBulkResults(batch []bufferedAction, results []elasticsearch.BulkItemResult) (applied, superseded, retry []bufferedAction, rejected []rejectedAction) {
+	for i, item := range batch {
+		res := results[i]
+		switch {
+		case !res.Failed():
+			applied = append(applied, item)
+		case res.VersionConflict():
+			// A delayed event arriving after a newer one: nothing to do, and
+			// nothing to evict from the cache.
+			superseded = append(superseded, item)
+		case res.Retriable(), res.IndexNotFound() && item.target.alias:
+			retry = append(retry, item)
+		default:
+			rejected = append(rejected, rejectedAction{item: item, err: res.Err()})
+		}
+	}
+	return applied, superseded, retry, rejected
+}
+
+// aliasRemoved reports whether a write through an alias failed because the
+// alias no longer exists.
+func aliasRemoved(batch []bufferedAction, results []elasticsearch.BulkItemResult) bool {
+	for i, res := range results {
+		if res.IndexNotFound() && batch[i].target.alias {
+			return true
+		}
+	}
+	return false
+}
+
+// reroute reloads the aliases after a reindex step removed one and resolves
+// the indices of the actions to retry again. Mirror copies are dropped once
+// nothing is mirrored any more. If the aliases cannot be reloaded, the
+// actions are retried as they are and rerouted on the next flush.
+func (sp *StreamProcessor) reroute(ctx context.Context, retry []bufferedAction) []bufferedAction {
+	if err := sp.refreshAliases(ctx); err != nil {
+		sp.logger.Warn("refreshing aliases after a write to a missing alias failed", zap.Error(err))
+		return retry
+	}
+
+	kept := make([]bufferedAction, 0, len(retry))
+	var dropped []bufferedAction
+	for _, b := range retry {
+		index := sp.resolveIndex(&b.target)
+		if index == "" {
+			dropped = append(dropped, b)
+			continue
+		}
+		b.action.Index = index
+		kept = append(kept, b)
+	}
+	if len(dropped) > 0 {
+		sp.logger.Info("dropped writes to a mirror that was removed", zap.Int("count", len(dropped)))
+		sp.release(dropped, nil)
+	}
+	return kept
+}
+
+// requeue puts actions back at the head of the buffer, ahead of newer ones.
+// Their buffer slots stay held.
+func (sp *StreamProcessor) requeue(actions []bufferedAction) {
+	sp.mu.Lock()
+	sp.buffer = append(actions, sp.buffer...)
+	sp.mu.Unlock()
+}
+
+// release frees the buffer slots held by finished actions and reports each
+// outcome: applied actions succeed, rejected ones fail with Elasticsearch's
+// reason so their source can dead-letter them.
+func (sp *StreamProcessor) release(applied []bufferedAction, rejected []rejectedAction) {
+	for _, b := range applied {
+		<-sp.slots
+		if b.ack != nil {
+			b.ack(nil)
+		}
+	}
+	for _, r := range rejected {
+		<-sp.slots
+		if r.item.ack != nil {
+			r.item.ack(r.err)
+		}
+	}
+	sp.mu.Lock()
+	observability.IndexingBufferedActions.Set(float64(len(sp.buffer)))
+	sp.mu.Unlock()
 }
 
 func (sp *StreamProcessor) Stop() error {
@@ -250,13 +493,88 @@ func (sp *StreamProcessor) Stop() error {
 	// Wait for flushLoop to fully exit so we don't race with a concurrent flush.
 	sp.loopWg.Wait()
 
-	// Final flush of any remaining buffered events
+	// Final flush of any remaining buffered events. Events still buffered if
+	// it fails are never acknowledged, so their source redelivers them.
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	return sp.flush(ctx)
 }
 
+// invalidateAfterRefresh evicts the cached search responses that depend on a
+// flushed batch. Eviction waits for the next index refresh: evicting earlier
+// would let a concurrent search re-cache the pre-change results.
+func (sp *StreamProcessor) invalidateAfterRefresh(batch []bufferedAction) {
+	tags := buildInvalidationTags(batch)
+	if len(tags) == 0 || sp.cache == nil {
+		return
+	}
+
+	evict := func() {
+		sp.asyncDo(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			evicted, err := sp.cache.InvalidateTags(ctx, tags)
+			if err != nil {
+				sp.logger.Warn("tag cache invalidation failed",
+					zap.Int("tag_count", len(tags)),
+					zap.Error(err),
+				)
+				return
+			}
+			sp.logger.Debug("tag cache invalidation completed",
+				zap.Int("tag_count", len(tags)),
+				zap.Int("evicted", evicted),
+			)
+		})
+	}
+
+	if sp.refreshDelay == 0 {
+		evict()
+		return
+	}
+	time.AfterFunc(sp.refreshDelay, evict)
+}
+
+// buildInvalidationTags returns the deduplicated cache tags touched by a
+// batch: each document, plus the category and region of documents created or
+// deleted, which change the hits and counts of responses scoped to them. An
+// update in place leaves those responses alone unless they hold the
+// document; one that moves it to another category or region reaches the new
+// scope's responses when they expire.
+func buildInvalidationTags(batch []bufferedAction) []string {
+	seen := make(map[string]struct{})
+	var tags []string
+	add := func(tag string) {
+		if _, ok := seen[tag]; !ok {
+			seen[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+
+	for i := range batch {
+		action := &batch[i].action
+		add(cache.DocTag(action.ID))
+		if !batch[i].scopeChanged {
+			continue
+		}
+
+		if category, ok := action.Body["category"].(string); ok && category != "" {
+			add(cache.CategoryTag(category))
+		}
+
+		region := action.Routing
+		if region == "" {
+			region, _ = action.Body["region"].(string)
+		}
+		if region != "" {
+			add(cache.RegionTag(region))
+		}
+	}
+
+	return tags
+}
+
 // buildInvalidationKeys returns specific cache keys to delete rather than
 // wildcard patterns, avoiding O(N) SCAN operations on large keyspaces.
 func buildInvalidationKeys(event *models.ChangeEvent) []string {
@@ -1,9 +1,18 @@
 package indexing
 
 import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
 	"github.com/shubhsaxena/high-scale-search/internal/models"
 )
 
@@ -249,3 +258,342 @@ func TestMaxAsyncWorkers(t *testing.T) {
 		t.Errorf("expected maxAsyncWorkers 128, got %d", maxAsyncWorkers)
 	}
 }
+
+func TestBuildInvalidationTags(t *testing.T) {
+	batch := []bufferedAction{
+		{action: models.IndexAction{
+			Action:  "index",
+			ID:      "doc-1",
+			Routing: "us-east",
+			Body:    map[string]any{"category": "electronics", "region": "us-east"},
+		}, scopeChanged: true},
+		{action: models.IndexAction{
+			Action: "index",
+			ID:     "doc-2",
+			Body:   map[string]any{"category": "electronics", "region": "eu-west"},
+		}, scopeChanged: true},
+		{action: models.IndexAction{
+			Action:  "delete",
+			ID:      "doc-3",
+			Routing: "ap-south",
+		}, scopeChanged: true},
+	}
+
+	tags := buildInvalidationTags(batch)
+
+	expected := []string{
+		"tag:doc:doc-1", "tag:cat:electronics", "tag:region:us-east",
+		"tag:doc:doc-2", "tag:region:eu-west",
+		"tag:doc:doc-3", "tag:region:ap-south",
+	}
+	if len(tags) != len(expected) {
+		t.Fatalf("expected %d tags, got %d: %v", len(expected), len(tags), tags)
+	}
+	for i, tag := range expected {
+		if tags[i] != tag {
+			t.Errorf("tag %d: expected %q, got %q", i, tag, tags[i])
+		}
+	}
+}
+
+func TestHandleEvent_UpdateInPlaceKeepsScopedResponses(t *testing.T) {
+	sp := newTestProcessor(&fakeBulk{}, 10)
+	ctx := context.Background()
+
+	created := testEvent("a")
+	created.Type = "CREATE"
+	sp.HandleEvent(ctx, created, nil)
+	sp.HandleEvent(ctx, testEvent("b"), nil)
+	deleted := testEvent("c")
+	deleted.Type = "DELETE"
+	sp.HandleEvent(ctx, deleted, nil)
+
+	// Only the create and the delete change what the us region holds; the
+	// update evicts just the responses holding document b.
+	tags := buildInvalidationTags(sp.buffer)
+	want := []string{"tag:doc:a", "tag:region:us", "tag:doc:b", "tag:doc:c"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected tags %v, got %v", want, tags)
+	}
+
+	update := buildInvalidationTags(sp.buffer[1:2])
+	if !reflect.DeepEqual(update, []string{"tag:doc:b"}) {
+		t.Errorf("expected an update in place to leave region-scoped responses alone, got %v", update)
+	}
+}
+
+func TestBuildInvalidationTags_Empty(t *testing.T) {
+	if tags := buildInvalidationTags(nil); len(tags) != 0 {
+		t.Errorf("expected no tags for empty batch, got %v", tags)
+	}
+}
+
+func TestSplitBulkResults(t *testing.T) {
+	batch := []bufferedAction{
+		{action: models.IndexAction{ID: "ok"}},
+		{action: models.IndexAction{ID: "missing", Action: "delete"}},
+		{action: models.IndexAction{ID: "stale", Version: 3}},
+		{action: models.IndexAction{ID: "throttled"}},
+		{action: models.IndexAction{ID: "unavailable"}},
+		{action: models.IndexAction{ID: "bad-mapping"}},
+		{action: models.IndexAction{ID: "moved"}, target: indexTarget{alias: true}},
+		{action: models.IndexAction{ID: "no-index"}},
+	}
+	results := []elasticsearch.BulkItemResult{
+		{ID: "ok", Status: 201},
+		{ID: "missing", Status: 404},
+		{ID: "stale", Status: 409, ErrorType: "version_conflict_engine_exception", Reason: "current version [5] is higher than provided [3]"},
+		{ID: "throttled", Status: 429, ErrorType: "es_rejected_execution_exception", Reason: "queue full"},
+		{ID: "unavailable", Status: 503, ErrorType: "unavailable_shards_exception", Reason: "primary shard is not active"},
+		{ID: "bad-mapping", Status: 400, ErrorType: "mapper_parsing_exception", Reason: "failed to parse field [popularity_score]"},
+		{ID: "moved", Status: 404, ErrorType: "index_not_found_exception", Reason: "no such index [search_write]"},
+		{ID: "no-index", Status: 404, ErrorType: "index_not_found_exception", Reason: "no such index [search-general-us-2026.01]"},
+	}
+
+	applied, superseded, retry, rejected := splitBulkResults(batch, results)
+
+	if len(applied) != 2 || applied[0].action.ID != "ok" || applied[1].action.ID != "missing" {
+		t.Errorf("expected ok and missing applied, got %+v", applied)
+	}
+	if len(superseded) != 1 || superseded[0].action.ID != "stale" {
+		t.Errorf("expected stale superseded, got %+v", superseded)
+	}
+	if len(retry) != 3 || retry[0].action.ID != "throttled" || retry[1].action.ID != "unavailable" || retry[2].action.ID != "moved" {
+		t.Errorf("expected throttled, unavailable and moved retried, got %+v", retry)
+	}
+	if len(rejected) != 2 || rejected[0].item.action.ID != "bad-mapping" || rejected[1].item.action.ID != "no-index" {
+		t.Fatalf("expected bad-mapping and no-index rejected, got %+v", rejected)
+	}
+	if !strings.Contains(rejected[0].err.Error(), "failed to parse field") {
+		t.Errorf("expected rejection to carry the ES reason, got %q", rejected[0].err)
+	}
+}
+
+// fakeBulk records the batches sent to Elasticsearch and answers each with
+// the next queued outcome, applying everything once the queue is empty.
+type fakeBulk struct {
+	mu       sync.Mutex
+	batches  [][]string
+	outcomes []func([]models.IndexAction) ([]elasticsearch.BulkItemResult, error)
+}
+
+func (f *fakeBulk) bulk(ctx context.Context, actions []models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make([]string, len(actions))
+	for i, a := range actions {
+		ids[i] = a.ID
+	}
+	f.batches = append(f.batches, ids)
+	if len(f.outcomes) > 0 {
+		next := f.outcomes[0]
+		f.outcomes = f.outcomes[1:]
+		return next(actions)
+	}
+	results := make([]elasticsearch.BulkItemResult, len(actions))
+	for i, a := range actions {
+		results[i] = elasticsearch.BulkItemResult{ID: a.ID, Status: 200}
+	}
+	return results, nil
+}
+
+// newTestProcessor returns a processor with room for slots buffered actions
+// and no flush loop; tests flush explicitly.
+func newTestProcessor(bulk *fakeBulk, slots int) *StreamProcessor {
+	return &StreamProcessor{
+		esClient: &elasticsearch.Client{},
+		bulk:     bulk.bulk,
+		esCfg:    config.ElasticsearchConfig{BulkSize: 1000},
+		logger:   zap.NewNop(),
+		slots:    make(chan struct{}, slots),
+		asyncSem: make(chan struct{}, maxAsyncWorkers),
+	}
+}
+
+func testEvent(id string) *models.ChangeEvent {
+	return &models.ChangeEvent{
+		Type:       "UPDATE",
+		DocumentID: id,
+		Region:     "us",
+		Document:   map[string]any{"title": id},
+		Timestamp:  time.Now(),
+		Version:    1,
+	}
+}
+
+func TestHandleEvent_AcknowledgesAfterFlush(t *testing.T) {
+	bulk := &fakeBulk{}
+	sp := newTestProcessor(bulk, 10)
+
+	var acked []error
+	if err := sp.HandleEvent(context.Background(), testEvent("a"), func(err error) { acked = append(acked, err) }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(acked) != 0 {
+		t.Fatal("expected no ack before the action is flushed")
+	}
+
+	if err := sp.flush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if len(acked) != 1 || acked[0] != nil {
+		t.Errorf("expected one successful ack, got %v", acked)
+	}
+	if len(sp.slots) != 0 {
+		t.Errorf("expected the buffer slot to be released, %d held", len(sp.slots))
+	}
+}
+
+func TestHandleEvent_RejectedItemAcknowledgedWithError(t *testing.T) {
+	bulk := &fakeBulk{outcomes: []func([]models.IndexAction) ([]elasticsearch.BulkItemResult, error){
+		func(actions []models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+			return []elasticsearch.BulkItemResult{{ID: "a", Status: 400, ErrorType: "mapper_parsing_exception"}}, nil
+		},
+	}}
+	sp := newTestProcessor(bulk, 10)
+
+	var acked []error
+	sp.HandleEvent(context.Background(), testEvent("a"), func(err error) { acked = append(acked, err) })
+	sp.flush(context.Background())
+
+	if len(acked) != 1 || acked[0] == nil || !strings.Contains(acked[0].Error(), "mapper_parsing_exception") {
+		t.Errorf("expected the rejection to be reported, got %v", acked)
+	}
+}
+
+func TestHandleEvent_BlocksWhenBufferFull(t *testing.T) {
+	sp := newTestProcessor(&fakeBulk{}, 1)
+	if err := sp.HandleEvent(context.Background(), testEvent("a"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := sp.HandleEvent(ctx, testEvent("b"), nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the full buffer to block until the deadline, got %v", err)
+	}
+	if len(sp.buffer) != 1 {
+		t.Errorf("expected the blocked event not to be buffered, got %d actions", len(sp.buffer))
+	}
+
+	// A flush frees the slot.
+	sp.flush(context.Background())
+	if err := sp.HandleEvent(context.Background(), testEvent("b"), nil); err != nil {
+		t.Errorf("expected room after the flush, got %v", err)
+	}
+}
+
+func TestFlush_RequeueKeepsOrder(t *testing.T) {
+	bulk := &fakeBulk{outcomes: []func([]models.IndexAction) ([]elasticsearch.BulkItemResult, error){
+		// The whole request fails.
+		func([]models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+			return nil, errors.New("connection refused")
+		},
+		// The cluster pushes back on the second item only.
+		func(actions []models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+			return []elasticsearch.BulkItemResult{
+				{ID: "a", Status: 200},
+				{ID: "b", Status: 429, ErrorType: "es_rejected_execution_exception"},
+				{ID: "c", Status: 200},
+			}, nil
+		},
+	}}
+	sp := newTestProcessor(bulk, 10)
+	ctx := context.Background()
+
+	sp.HandleEvent(ctx, testEvent("a"), nil)
+	sp.HandleEvent(ctx, testEvent("b"), nil)
+	if err := sp.flush(ctx); err == nil {
+		t.Fatal("expected the failed flush to be reported")
+	}
+	sp.HandleEvent(ctx, testEvent("c"), nil)
+	sp.flush(ctx)
+	sp.HandleEvent(ctx, testEvent("d"), nil)
+	sp.flush(ctx)
+
+	want := [][]string{{"a", "b"}, {"a", "b", "c"}, {"b", "d"}}
+	if !reflect.DeepEqual(bulk.batches, want) {
+		t.Errorf("expected batches %v, got %v", want, bulk.batches)
+	}
+	if len(sp.slots) != 0 {
+		t.Errorf("expected every slot released, %d held", len(sp.slots))
+	}
+}
+
+func TestFlush_ReportsRejectionsOutsideFlushLock(t *testing.T) {
+	bulk := &fakeBulk{outcomes: []func([]models.IndexAction) ([]elasticsearch.BulkItemResult, error){
+		func(actions []models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+			return []elasticsearch.BulkItemResult{{ID: "a", Status: 400, ErrorType: "mapper_parsing_exception"}}, nil
+		},
+	}}
+	sp := newTestProcessor(bulk, 10)
+
+	// Dead-lettering a rejection may be slow; the next flush must not wait.
+	locked := true
+	sp.HandleEvent(context.Background(), testEvent("a"), func(err error) {
+		if sp.flushMu.TryLock() {
+			locked = false
+			sp.flushMu.Unlock()
+		}
+	})
+	sp.flush(context.Background())
+	if locked {
+		t.Error("expected the rejection to be reported after the flush lock is released")
+	}
+}
+
+func TestFlush_ReroutesWritesToRemovedAlias(t *testing.T) {
+	var indices []string
+	bulk := &fakeBulk{outcomes: []func([]models.IndexAction) ([]elasticsearch.BulkItemResult, error){
+		// A reindex step removed the write and mirror aliases.
+		func(actions []models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+			results := make([]elasticsearch.BulkItemResult, len(actions))
+			for i, a := range actions {
+				results[i] = elasticsearch.BulkItemResult{ID: a.ID, Status: 404, ErrorType: "index_not_found_exception"}
+			}
+			return results, nil
+		},
+		func(actions []models.IndexAction) ([]elasticsearch.BulkItemResult, error) {
+			results := make([]elasticsearch.BulkItemResult, len(actions))
+			for i, a := range actions {
+				indices = append(indices, a.Index)
+				results[i] = elasticsearch.BulkItemResult{ID: a.ID, Status: 200}
+			}
+			return results, nil
+		},
+	}}
+	sp := newTestProcessor(bulk, 10)
+	refreshed := 0
+	sp.refreshAliases = func(context.Context) error {
+		refreshed++
+		return nil
+	}
+
+	var acked []error
+	action := models.IndexAction{Action: "index", ID: "a", Index: elasticsearch.WriteAlias("")}
+	mirrored := action
+	mirrored.Index = elasticsearch.MirrorAlias("")
+	sp.slots <- struct{}{}
+	sp.slots <- struct{}{}
+	sp.buffer = []bufferedAction{
+		{action: action, target: indexTarget{docType: "general", region: "us", alias: true}, ack: func(err error) { acked = append(acked, err) }},
+		{action: mirrored, target: indexTarget{mirror: true, alias: true}},
+	}
+
+	sp.flush(context.Background())
+	sp.flush(context.Background())
+
+	if refreshed != 1 {
+		t.Errorf("expected the aliases to be refreshed once, got %d", refreshed)
+	}
+	want := []string{sp.esClient.ResolveIndex("general", "us")}
+	if !reflect.DeepEqual(indices, want) {
+		t.Errorf("expected the write resolved again and the mirror copy dropped, got %v", indices)
+	}
+	if len(acked) != 1 || acked[0] != nil {
+		t.Errorf("expected one successful ack, got %v", acked)
+	}
+	if len(sp.slots) != 0 {
+		t.Errorf("expected every slot released, %d held", len(sp.slots))
+	}
+}
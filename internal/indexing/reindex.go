@@ -0,0 +1,371 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+)
+
+var (
+	// ErrReindexInProgress is returned when starting a reindex while the
+	// mirror alias still points at another generation.
+	ErrReindexInProgress = errors.New("a reindex is already in progress")
+	// ErrNoReindex is returned by steps that need a reindex in progress.
+	ErrNoReindex = errors.New("no reindex in progress")
+	// ErrAlreadySwapped is returned when verifying or swapping after the
+	// aliases already point at the new generation.
+	ErrAlreadySwapped = errors.New("aliases already point at the new index")
+	// ErrNothingToRollback is returned when no previous index is left to
+	// swap back to.
+	ErrNothingToRollback = errors.New("no previous index to roll back to")
+)
+
+// Reindex phases, as reported by ReindexStatus.
+const (
+	// PhaseMonthly: no aliases, searches and writes use the monthly indices.
+	PhaseMonthly = "monthly"
+	// PhaseAliased: searches and writes go through the aliases.
+	PhaseAliased = "aliased"
+	// PhaseCatchingUp: a new generation receives a copy of every live write
+	// while it is backfilled; the aliases still point at the old one.
+	PhaseCatchingUp = "catching_up"
+	// PhaseSwapped: the aliases point at the new generation and the old one
+	// keeps receiving a copy of every write, so a rollback loses nothing.
+	PhaseSwapped = "swapped"
+)
+
+// ReindexCluster is the part of the Elasticsearch client a reindex drives.
+// elasticsearch.Client implements it.
+type ReindexCluster interface {
+	EnsureSchema(ctx context.Context) error
+	Aliases(ctx context.Context) (map[string][]string, error)
+	UpdateAliases(ctx context.Context, actions []map[string]any) error
+	CreateIndex(ctx context.Context, index string, body map[string]any) error
+	DeleteIndex(ctx context.Context, index string) error
+	Count(ctx context.Context, target string) (int64, error)
+}
+
+// ReindexStatus is where the aliases point.
+type ReindexStatus struct {
+	Phase string `json:"phase"`
+	// Live is the index behind the read and write aliases; empty while the
+	// monthly indices are in use.
+	Live string `json:"live,omitempty"`
+	// Mirror is the index receiving a copy of every write, if any.
+	Mirror string `json:"mirror,omitempty"`
+}
+
+// ReindexVerification compares the documents searches currently see with
+// those in the new generation.
+type ReindexVerification struct {
+	Source      string `json:"source"`
+	SourceCount int64  `json:"source_count"`
+	Target      string `json:"target"`
+	TargetCount int64  `json:"target_count"`
+	OK          bool   `json:"ok"`
+}
+
+// Reindexer moves the search indices to a new generation without downtime:
+//
+//  1. Start creates the generation and points the mirror alias at it, so the
+//     stream processor copies every live change into it;
+//  2. a Backfiller populates it from the source of truth;
+//  3. Verify compares document counts with what searches see today;
+//  4. Swap atomically moves the read and write aliases to it, pointing the
+//     mirror at the old generation;
+//  5. Finish drops the mirror, optionally deleting the old generation.
+//
+// Rollback undoes a Swap until Finish runs; Finish before Swap aborts.
+//
+// Servers follow alias changes on their next alias refresh. Writes through
+// an alias that has since been removed fail and are resolved again, so a
+// step may remove an alias at once unless servers that have not yet seen it
+// would lose writes, as after a swap from the monthly indices.
+type Reindexer struct {
+	cluster ReindexCluster
+	cfg     config.ElasticsearchConfig
+	logger  *zap.Logger
+	now     func() time.Time
+	// sleep waits d or until ctx is done; replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewReindexer(cluster ReindexCluster, cfg config.ElasticsearchConfig, logger *zap.Logger) *Reindexer {
+	return &Reindexer{
+		cluster: cluster,
+		cfg:     cfg,
+		logger:  logger,
+		now:     time.Now,
+		sleep: func(ctx context.Context, d time.Duration) error {
+			t := time.NewTimer(d)
+			defer t.Stop()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-t.C:
+				return nil
+			}
+		},
+	}
+}
+
+// AwaitAliasRefresh waits until every server has followed an alias change:
+// two alias refresh intervals, covering the next poll and the refresh it
+// runs, plus a bulk flush interval for writes buffered before it.
+func (r *Reindexer) AwaitAliasRefresh(ctx context.Context) error {
+	wait := 2*r.cfg.AliasRefreshInterval + r.cfg.BulkFlushInterval
+	r.logger.Info("waiting for servers to follow the alias change", zap.Duration("wait", wait))
+	return r.sleep(ctx, wait)
+}
+
+// Status reports where the aliases currently point.
+func (r *Reindexer) Status(ctx context.Context) (ReindexStatus, error) {
+	aliases, err := r.cluster.Aliases(ctx)
+	if err != nil {
+		return ReindexStatus{}, err
+	}
+	prefix := r.cfg.IndexPrefix
+
+	var st ReindexStatus
+	for _, target := range []struct {
+		alias string
+		index *string
+	}{
+		{elasticsearch.ReadAlias(prefix), &st.Live},
+		{elasticsearch.MirrorAlias(prefix), &st.Mirror},
+	} {
+		indices := aliases[target.alias]
+		if len(indices) > 1 {
+			return st, fmt.Errorf("alias %s points at %d indices, expected one", target.alias, len(indices))
+		}
+		if len(indices) == 1 {
+			*target.index = indices[0]
+		}
+	}
+
+	switch {
+	case st.Mirror == "" && st.Live == "":
+		st.Phase = PhaseMonthly
+	case st.Mirror == "" || st.Mirror == st.Live:
+		// A mirror on the live generation is left by a swap from the
+		// monthly indices that has not finished draining.
+		st.Phase = PhaseAliased
+	case st.Live == "" || st.Mirror > st.Live:
+		// Generation names sort by creation time.
+		st.Phase = PhaseCatchingUp
+	default:
+		st.Phase = PhaseSwapped
+	}
+	return st, nil
+}
+
+// Start creates a new generation and starts mirroring writes into it. It
+// returns the new index name.
+func (r *Reindexer) Start(ctx context.Context) (string, error) {
+	st, err := r.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+	if st.Mirror != "" {
+		return "", fmt.Errorf("%w: mirror alias points at %s", ErrReindexInProgress, st.Mirror)
+	}
+
+	// The generation takes its settings and mappings from the index
+	// template, so make sure this build's version is installed.
+	if err := r.cluster.EnsureSchema(ctx); err != nil {
+		return "", fmt.Errorf("installing index templates: %w", err)
+	}
+	index := elasticsearch.GenerationIndex(r.cfg.IndexPrefix, r.now())
+	if err := r.cluster.CreateIndex(ctx, index, nil); err != nil {
+		return "", err
+	}
+	if err := r.cluster.UpdateAliases(ctx, []map[string]any{
+		aliasAction("add", index, elasticsearch.MirrorAlias(r.cfg.IndexPrefix)),
+	}); err != nil {
+		return "", fmt.Errorf("adding mirror alias to %s: %w", index, err)
+	}
+
+	r.logger.Info("reindex started", zap.String("index", index), zap.String("live", st.Live))
+	return index, nil
+}
+
+// Verify compares the document count searches see today with that of the
+// new generation. The counts match when they differ by at most tolerance,
+// a fraction of the live count, which absorbs changes still in flight.
+func (r *Reindexer) Verify(ctx context.Context, tolerance float64) (ReindexVerification, error) {
+	st, err := r.Status(ctx)
+	if err != nil {
+		return ReindexVerification{}, err
+	}
+	switch st.Phase {
+	case PhaseCatchingUp:
+	case PhaseSwapped:
+		return ReindexVerification{}, ErrAlreadySwapped
+	default:
+		return ReindexVerification{}, ErrNoReindex
+	}
+
+	v := ReindexVerification{Source: st.Live, Target: st.Mirror}
+	if v.Source == "" {
+		v.Source = r.cfg.IndexPrefix + "-*"
+	}
+	if v.SourceCount, err = r.cluster.Count(ctx, v.Source); err != nil {
+		return v, err
+	}
+	if v.TargetCount, err = r.cluster.Count(ctx, v.Target); err != nil {
+		return v, err
+	}
+
+	diff := v.SourceCount - v.TargetCount
+	if diff < 0 {
+		diff = -diff
+	}
+	v.OK = float64(diff) <= tolerance*float64(v.SourceCount)
+	return v, nil
+}
+
+// Swap atomically points the read and write aliases at the new generation.
+// The mirror alias moves to the previous generation so it stays current for
+// a rollback. Coming from the monthly indices, which cannot be written
+// through one alias, the mirror is dropped instead, once servers that still
+// write to the monthly indices have followed the swap; if the wait is cut
+// short, Finish drops it.
+func (r *Reindexer) Swap(ctx context.Context) (ReindexStatus, error) {
+	st, err := r.Status(ctx)
+	if err != nil {
+		return st, err
+	}
+	switch st.Phase {
+	case PhaseCatchingUp:
+	case PhaseSwapped:
+		return st, ErrAlreadySwapped
+	default:
+		return st, ErrNoReindex
+	}
+
+	if err := r.cluster.UpdateAliases(ctx, r.pointAliases(st.Live, st.Mirror)); err != nil {
+		return st, fmt.Errorf("swapping aliases to %s: %w", st.Mirror, err)
+	}
+	r.logger.Info("reindex swapped", zap.String("live", st.Mirror), zap.String("previous", st.Live))
+
+	if st.Live == "" {
+		if err := r.AwaitAliasRefresh(ctx); err != nil {
+			return st, fmt.Errorf("waiting for servers to follow the swap, run finish to drop the mirror: %w", err)
+		}
+		if err := r.cluster.UpdateAliases(ctx, []map[string]any{
+			aliasAction("remove", st.Mirror, elasticsearch.MirrorAlias(r.cfg.IndexPrefix)),
+		}); err != nil {
+			return st, fmt.Errorf("removing mirror alias from %s: %w", st.Mirror, err)
+		}
+	}
+	return r.Status(ctx)
+}
+
+// Rollback points the read and write aliases back at the previous
+// generation. After a swap from the monthly indices the aliases are removed
+// instead, returning to those indices; changes made since the swap exist
+// only in the generation that was live and must be backfilled again.
+func (r *Reindexer) Rollback(ctx context.Context) (ReindexStatus, error) {
+	st, err := r.Status(ctx)
+	if err != nil {
+		return st, err
+	}
+	prefix := r.cfg.IndexPrefix
+
+	var actions []map[string]any
+	switch st.Phase {
+	case PhaseSwapped:
+		actions = r.pointAliases(st.Live, st.Mirror)
+	case PhaseAliased:
+		monthly, err := r.cluster.Count(ctx, prefix+"-*")
+		if err != nil {
+			return st, err
+		}
+		if monthly == 0 {
+			return st, ErrNothingToRollback
+		}
+		actions = []map[string]any{
+			aliasAction("remove", st.Live, elasticsearch.ReadAlias(prefix)),
+			aliasAction("remove", st.Live, elasticsearch.WriteAlias(prefix)),
+		}
+		r.logger.Warn("rolling back to the monthly indices; changes since the swap are only in the generation index",
+			zap.String("index", st.Live),
+		)
+	default:
+		return st, ErrNothingToRollback
+	}
+
+	if err := r.cluster.UpdateAliases(ctx, actions); err != nil {
+		return st, fmt.Errorf("rolling back aliases: %w", err)
+	}
+
+	r.logger.Info("reindex rolled back", zap.String("live", st.Mirror), zap.String("previous", st.Live))
+	return r.Status(ctx)
+}
+
+// Finish stops mirroring and, if deleteIndex is set, deletes the mirrored
+// index: the previous generation after a swap, or the abandoned new one
+// before it. It returns the index that was mirrored.
+func (r *Reindexer) Finish(ctx context.Context, deleteIndex bool) (string, error) {
+	st, err := r.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+	if st.Mirror == "" {
+		return "", ErrNoReindex
+	}
+	if deleteIndex && st.Mirror == st.Live {
+		return "", fmt.Errorf("mirrored index %s is live, not deleting it", st.Mirror)
+	}
+
+	if err := r.cluster.UpdateAliases(ctx, []map[string]any{
+		aliasAction("remove", st.Mirror, elasticsearch.MirrorAlias(r.cfg.IndexPrefix)),
+	}); err != nil {
+		return "", fmt.Errorf("removing mirror alias from %s: %w", st.Mirror, err)
+	}
+	if deleteIndex {
+		if err := r.cluster.DeleteIndex(ctx, st.Mirror); err != nil {
+			return st.Mirror, err
+		}
+	}
+
+	r.logger.Info("reindex finished",
+		zap.String("phase", st.Phase),
+		zap.String("mirror", st.Mirror),
+		zap.Bool("deleted", deleteIndex),
+	)
+	return st.Mirror, nil
+}
+
+// pointAliases returns the actions that move the read and write aliases from
+// current to next and the mirror from next to current. An empty current is
+// the monthly layout: there is nothing to remove, and the mirror stays on
+// next until servers writing to the monthly indices follow the swap.
+func (r *Reindexer) pointAliases(current, next string) []map[string]any {
+	prefix := r.cfg.IndexPrefix
+	read, write, mirror := elasticsearch.ReadAlias(prefix), elasticsearch.WriteAlias(prefix), elasticsearch.MirrorAlias(prefix)
+
+	actions := []map[string]any{
+		aliasAction("add", next, read),
+		aliasAction("add", next, write),
+	}
+	if current != "" {
+		actions = append(actions,
+			aliasAction("remove", next, mirror),
+			aliasAction("remove", current, read),
+			aliasAction("remove", current, write),
+			aliasAction("add", current, mirror),
+		)
+	}
+	return actions
+}
+
+func aliasAction(op, index, alias string) map[string]any {
+	return map[string]any{op: map[string]any{"index": index, "alias": alias}}
+}
@@ -0,0 +1,230 @@
+package indexing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+)
+
+// fakeCluster keeps indices and aliases in memory and applies alias actions
+// the way Elasticsearch does.
+type fakeCluster struct {
+	indices map[string]int64 // index -> document count
+	aliases map[string]map[string]bool
+	created map[string]map[string]any
+	schemas int
+}
+
+func newFakeCluster() *fakeCluster {
+	return &fakeCluster{
+		indices: make(map[string]int64),
+		aliases: make(map[string]map[string]bool),
+		created: make(map[string]map[string]any),
+	}
+}
+
+func (c *fakeCluster) EnsureSchema(ctx context.Context) error {
+	c.schemas++
+	return nil
+}
+
+func (c *fakeCluster) Aliases(ctx context.Context) (map[string][]string, error) {
+	out := make(map[string][]string)
+	for alias, indices := range c.aliases {
+		for index := range indices {
+			out[alias] = append(out[alias], index)
+		}
+	}
+	return out, nil
+}
+
+func (c *fakeCluster) UpdateAliases(ctx context.Context, actions []map[string]any) error {
+	for _, action := range actions {
+		for op, v := range action {
+			args := v.(map[string]any)
+			index, alias := args["index"].(string), args["alias"].(string)
+			if _, ok := c.indices[index]; !ok {
+				return errors.New("no such index " + index)
+			}
+			if c.aliases[alias] == nil {
+				c.aliases[alias] = make(map[string]bool)
+			}
+			if op == "add" {
+				c.aliases[alias][index] = true
+			} else {
+				delete(c.aliases[alias], index)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *fakeCluster) CreateIndex(ctx context.Context, index string, body map[string]any) error {
+	c.indices[index] = 0
+	c.created[index] = body
+	return nil
+}
+
+func (c *fakeCluster) DeleteIndex(ctx context.Context, index string) error {
+	delete(c.indices, index)
+	return nil
+}
+
+func (c *fakeCluster) Count(ctx context.Context, target string) (int64, error) {
+	if target == "search-*" {
+		return c.indices["search-general-us-2026.01"], nil
+	}
+	if indices := c.aliases[target]; len(indices) > 0 {
+		var n int64
+		for index := range indices {
+			n += c.indices[index]
+		}
+		return n, nil
+	}
+	return c.indices[target], nil
+}
+
+func newTestReindexer(cluster ReindexCluster, at time.Time) *Reindexer {
+	r := NewReindexer(cluster, config.ElasticsearchConfig{
+		IndexPrefix:     "search",
+		NumShards:       2,
+		NumReplicas:     1,
+		RefreshInterval: "1s",
+	}, zap.NewNop())
+	r.now = func() time.Time { return at }
+	r.sleep = func(context.Context, time.Duration) error { return nil }
+	return r
+}
+
+func TestReindex_FromMonthlyIndices(t *testing.T) {
+	ctx := context.Background()
+	cluster := newFakeCluster()
+	cluster.indices["search-general-us-2026.01"] = 100
+	r := newTestReindexer(cluster, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	index, err := r.Start(ctx)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if index != "search_gen_20260301120000" {
+		t.Errorf("unexpected index name %q", index)
+	}
+	if cluster.schemas != 1 || cluster.created[index] != nil {
+		t.Errorf("expected the index to be created from the installed template")
+	}
+	if st, _ := r.Status(ctx); st.Phase != PhaseCatchingUp || st.Mirror != index || st.Live != "" {
+		t.Errorf("unexpected status after start %+v", st)
+	}
+	if _, err := r.Start(ctx); !errors.Is(err, ErrReindexInProgress) {
+		t.Errorf("expected ErrReindexInProgress, got %v", err)
+	}
+
+	cluster.indices[index] = 99
+	v, err := r.Verify(ctx, 0.05)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !v.OK || v.Source != "search-*" || v.SourceCount != 100 || v.TargetCount != 99 {
+		t.Errorf("unexpected verification %+v", v)
+	}
+	if v, _ := r.Verify(ctx, 0); v.OK {
+		t.Error("expected verification to fail without tolerance")
+	}
+
+	// Monthly indices cannot be mirrored to, so the mirror is dropped, but
+	// only once servers still writing to them have followed the swap.
+	var drained ReindexStatus
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		drained, _ = r.Status(ctx)
+		return nil
+	}
+	st, err := r.Swap(ctx)
+	if err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if st.Phase != PhaseAliased || st.Live != index || st.Mirror != "" {
+		t.Errorf("unexpected status after swap %+v", st)
+	}
+	if drained.Live != index || drained.Mirror != index {
+		t.Errorf("expected the mirror to stay on the new index while servers follow the swap, got %+v", drained)
+	}
+
+	// Rolling back removes the aliases, returning to the monthly indices.
+	st, err = r.Rollback(ctx)
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if st.Phase != PhaseMonthly {
+		t.Errorf("expected monthly phase after rollback, got %+v", st)
+	}
+}
+
+func TestReindex_SwapAndRollbackBetweenGenerations(t *testing.T) {
+	ctx := context.Background()
+	cluster := newFakeCluster()
+	old := "search_gen_20260101000000"
+	cluster.indices[old] = 10
+	cluster.aliases["search_read"] = map[string]bool{old: true}
+	cluster.aliases["search_write"] = map[string]bool{old: true}
+	r := newTestReindexer(cluster, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	next, err := r.Start(ctx)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	st, err := r.Swap(ctx)
+	if err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if st.Phase != PhaseSwapped || st.Live != next || st.Mirror != old {
+		t.Errorf("unexpected status after swap %+v", st)
+	}
+	if _, err := r.Swap(ctx); !errors.Is(err, ErrAlreadySwapped) {
+		t.Errorf("expected ErrAlreadySwapped, got %v", err)
+	}
+
+	st, err = r.Rollback(ctx)
+	if err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if st.Phase != PhaseCatchingUp || st.Live != old || st.Mirror != next {
+		t.Errorf("unexpected status after rollback %+v", st)
+	}
+
+	// Swap again and finish, deleting the previous generation.
+	if _, err := r.Swap(ctx); err != nil {
+		t.Fatalf("second swap: %v", err)
+	}
+	mirrored, err := r.Finish(ctx, true)
+	if err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if mirrored != old {
+		t.Errorf("expected %s to be finished, got %s", old, mirrored)
+	}
+	if _, ok := cluster.indices[old]; ok {
+		t.Error("expected previous generation to be deleted")
+	}
+	if st, _ := r.Status(ctx); st.Phase != PhaseAliased || st.Live != next {
+		t.Errorf("unexpected status after finish %+v", st)
+	}
+	if _, err := r.Finish(ctx, false); !errors.Is(err, ErrNoReindex) {
+		t.Errorf("expected ErrNoReindex, got %v", err)
+	}
+}
+
+func TestReindex_RollbackWithoutPreviousIndex(t *testing.T) {
+	r := newTestReindexer(newFakeCluster(), time.Now())
+	if _, err := r.Rollback(context.Background()); !errors.Is(err, ErrNothingToRollback) {
+		t.Errorf("expected ErrNothingToRollback, got %v", err)
+	}
+	if _, err := r.Verify(context.Background(), 0); !errors.Is(err, ErrNoReindex) {
+		t.Errorf("expected ErrNoReindex, got %v", err)
+	}
+}
@@ -0,0 +1,361 @@
+package indexing
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// StageResult is what a stage did to a document.
+type StageResult int
+
+const (
+	StageUnchanged StageResult = iota
+	StageChanged
+	// StageDropped keeps the document out of the index.
+	StageDropped
+)
+
+// Stage is one step of a Pipeline. Apply transforms doc in place; it may
+// replace top-level values but must not modify nested ones, which are shared
+// with the source document.
+type Stage interface {
+	// Name identifies the stage in metrics and errors.
+	Name() string
+	Apply(doc map[string]any) (StageResult, error)
+}
+
+// renameStage moves a field to a new name, replacing any value there.
+type renameStage struct {
+	from, to string
+}
+
+func (s renameStage) Name() string { return "rename:" + s.from }
+
+func (s renameStage) Apply(doc map[string]any) (StageResult, error) {
+	v, ok := doc[s.from]
+	if !ok {
+		return StageUnchanged, nil
+	}
+	doc[s.to] = v
+	delete(doc, s.from)
+	return StageChanged, nil
+}
+
+// coerceStage converts a field to a type. Absent and null fields are left
+// alone; a value that does not convert fails the document.
+type coerceStage struct {
+	field, as string
+}
+
+func (s coerceStage) Name() string { return "coerce:" + s.field }
+
+func (s coerceStage) Apply(doc map[string]any) (StageResult, error) {
+	v, ok := doc[s.field]
+	if !ok || v == nil {
+		return StageUnchanged, nil
+	}
+
+	var out any
+	var err error
+	switch s.as {
+	case "string":
+		out = valueText(v)
+	case "int":
+		out, err = coerceInt(v)
+	case "float":
+		f, ok := toFloat(v)
+		if !ok {
+			err = fmt.Errorf("%v is not a number", v)
+		}
+		out = f
+	case "bool":
+		out, err = coerceBool(v)
+	case "date":
+		out, err = coerceDate(v)
+	case "string_list":
+		out = coerceStringList(v)
+	default:
+		err = fmt.Errorf("unknown type %q", s.as)
+	}
+	if err != nil {
+		return StageUnchanged, fmt.Errorf("field %s: %w", s.field, err)
+	}
+
+	if sameValue(v, out) {
+		return StageUnchanged, nil
+	}
+	doc[s.field] = out
+	return StageChanged, nil
+}
+
+func coerceInt(v any) (int64, error) {
+	f, ok := toFloat(v)
+	if !ok || f != math.Trunc(f) {
+		return 0, fmt.Errorf("%v is not an integer", v)
+	}
+	return int64(f), nil
+}
+
+func coerceBool(v any) (bool, error) {
+	if s, ok := v.(string); ok {
+		return strconv.ParseBool(strings.TrimSpace(s))
+	}
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0, nil
+	}
+	return false, fmt.Errorf("%v is not a boolean", v)
+}
+
+// dateLayouts are the text forms a date coerces from.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// coerceDate returns v as RFC 3339 text in UTC. Numbers are Unix seconds, or
+// milliseconds when too large to be seconds; times, as Firestore returns
+// timestamps, are taken as they are.
+func coerceDate(v any) (string, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t.UTC().Format(time.RFC3339), nil
+	case *time.Time:
+		if t == nil {
+			return "", fmt.Errorf("%v is not a date", v)
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	if s, ok := v.(string); ok {
+		s = strings.TrimSpace(s)
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.UTC().Format(time.RFC3339), nil
+			}
+		}
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return "", fmt.Errorf("%q is not a date", s)
+		}
+	}
+	f, ok := toFloat(v)
+	if !ok {
+		return "", fmt.Errorf("%v is not a date", v)
+	}
+	if math.Abs(f) >= 1e11 {
+		return time.UnixMilli(int64(f)).UTC().Format(time.RFC3339), nil
+	}
+	return time.Unix(int64(f), 0).UTC().Format(time.RFC3339), nil
+}
+
+// coerceStringList splits comma-separated text into a list; the elements of
+// a list become text.
+func coerceStringList(v any) []any {
+	var out []any
+	switch x := v.(type) {
+	case []any:
+		out = make([]any, 0, len(x))
+		for _, e := range x {
+			out = append(out, valueText(e))
+		}
+	case []string:
+		out = make([]any, 0, len(x))
+		for _, e := range x {
+			out = append(out, e)
+		}
+	default:
+		out = []any{}
+		for _, part := range strings.Split(valueText(v), ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+var (
+	htmlBlockRe = regexp.MustCompile(`(?is)<script\b.*?</script\s*>|<style\b.*?</style\s*>|<!--.*?-->`)
+	htmlTagRe   = regexp.MustCompile(`<[^>]*>`)
+	spaceRe     = regexp.MustCompile(`\s+`)
+)
+
+// stripHTMLStage reduces markup in text fields to its plain text: tags,
+// scripts, styles and comments are removed and entities decoded.
+type stripHTMLStage struct {
+	fields []string
+}
+
+func (s stripHTMLStage) Name() string { return "strip_html:" + strings.Join(s.fields, ",") }
+
+func (s stripHTMLStage) Apply(doc map[string]any) (StageResult, error) {
+	res := StageUnchanged
+	for _, field := range s.fields {
+		text, ok := doc[field].(string)
+		if !ok {
+			continue
+		}
+		if stripped := stripHTML(text); stripped != text {
+			doc[field] = stripped
+			res = StageChanged
+		}
+	}
+	return res, nil
+}
+
+func stripHTML(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return s
+	}
+	s = htmlBlockRe.ReplaceAllString(s, " ")
+	s = htmlTagRe.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(spaceRe.ReplaceAllString(s, " "))
+}
+
+// computeStage derives a field from another one. An absent source leaves the
+// field unset.
+type computeStage struct {
+	field, source, fn string
+	factor            float64
+}
+
+func (s computeStage) Name() string { return "compute:" + s.field }
+
+func (s computeStage) Apply(doc map[string]any) (StageResult, error) {
+	v, ok := doc[s.source]
+	if !ok || v == nil {
+		return StageUnchanged, nil
+	}
+
+	var out any
+	switch s.fn {
+	case "length":
+		out = utf8.RuneCountInString(valueText(v))
+	case "lowercase":
+		out = strings.ToLower(valueText(v))
+	case "scale":
+		f, ok := toFloat(v)
+		if !ok {
+			return StageUnchanged, fmt.Errorf("field %s: %v is not a number", s.source, v)
+		}
+		out = f * s.factor
+	default:
+		return StageUnchanged, fmt.Errorf("unknown fn %q", s.fn)
+	}
+
+	if old, ok := doc[s.field]; ok && sameValue(old, out) {
+		return StageUnchanged, nil
+	}
+	doc[s.field] = out
+	return StageChanged, nil
+}
+
+// enrichStage sets a field from a lookup table keyed by another field's
+// value. Values missing from the table leave the field as it is.
+type enrichStage struct {
+	field, source string
+	values        map[string]any
+}
+
+func (s enrichStage) Name() string { return "enrich:" + s.field }
+
+func (s enrichStage) Apply(doc map[string]any) (StageResult, error) {
+	v, ok := doc[s.source]
+	if !ok || v == nil {
+		return StageUnchanged, nil
+	}
+	out, ok := s.values[valueText(v)]
+	if !ok {
+		return StageUnchanged, nil
+	}
+	if old, ok := doc[s.field]; ok && sameValue(old, out) {
+		return StageUnchanged, nil
+	}
+	doc[s.field] = out
+	return StageChanged, nil
+}
+
+// dropStage keeps documents out of the index by a field's value, or by its
+// absence.
+type dropStage struct {
+	field   string
+	in      map[string]bool
+	missing bool
+}
+
+func (s dropStage) Name() string { return "drop:" + s.field }
+
+func (s dropStage) Apply(doc map[string]any) (StageResult, error) {
+	v, ok := doc[s.field]
+	if !ok {
+		if s.missing {
+			return StageDropped, nil
+		}
+		return StageUnchanged, nil
+	}
+	if s.in[valueText(v)] {
+		return StageDropped, nil
+	}
+	return StageUnchanged, nil
+}
+
+// valueText formats a document value as text, writing whole numbers without
+// a fraction so 1.0 decoded from JSON matches 1 from YAML.
+func valueText(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32)
+	}
+	return fmt.Sprint(v)
+}
+
+// sameValue reports whether a and b are equal, comparing numbers by value
+// whatever their Go type.
+func sameValue(a, b any) bool {
+	if fa, ok := number(a); ok {
+		fb, ok := number(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat returns v as a number, parsing numeric text.
+func toFloat(v any) (float64, bool) {
+	if s, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return f, err == nil
+	}
+	return number(v)
+}
+
+// number returns v as a float64 if it holds a number.
+func number(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	}
+	return 0, false
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"strconv"
 	"sync"
 	"time"
 
@@ -15,7 +17,24 @@ import (
 	"github.com/shubhsaxena/high-scale-search/internal/observability"
 )
 
-type MessageHandler func(ctx context.Context, event *models.ChangeEvent) error
+// drainTimeout bounds how long Stop waits for in-flight messages to be
+// acknowledged before closing the reader. Unacknowledged messages are not
+// committed and are redelivered after a restart.
+const drainTimeout = 15 * time.Second
+
+// workerQueueSize is the number of dispatched messages each worker may have
+// waiting; a full queue stalls the fetch loop.
+const workerQueueSize = 64
+
+// dlqBatchTimeout is how long a DLQ write waits for other messages to share
+// its batch.
+const dlqBatchTimeout = 10 * time.Millisecond
+
+// MessageHandler processes a change event. A nil error means the event was
+// accepted; ack is then called exactly once when it has been durably applied
+// (nil) or has permanently failed. The message's offset is only committed
+// after ack, so accepted events survive a crash.
+type MessageHandler func(ctx context.Context, event *models.ChangeEvent, ack func(error)) error
 
 type Consumer struct {
 	reader     *kafka.Reader
@@ -25,6 +44,17 @@ type Consumer struct {
 	logger     *zap.Logger
 	wg         sync.WaitGroup
 	cancelFunc context.CancelFunc
+
+	// workers each own a queue; messages with the same key always go to the
+	// same worker so changes to one document are applied in order.
+	workers  []chan kafka.Message
+	workerWg sync.WaitGroup
+
+	// offsets holds the per-partition commit watermarks; inflight bounds
+	// fetched but unacknowledged messages so a stalled sink stops fetching
+	// instead of buffering without limit.
+	offsets  *offsetTracker
+	inflight chan struct{}
 }
 
 func NewConsumer(cfg config.KafkaConfig, handler MessageHandler, logger *zap.Logger) *Consumer {
@@ -39,16 +69,20 @@ func NewConsumer(cfg config.KafkaConfig, handler MessageHandler, logger *zap.Log
 		StartOffset:    kafka.LastOffset,
 	})
 
+	// Dead-lettering is one synchronous write per event, so the writer must
+	// not wait the default second for more messages to batch with.
 	dlqWriter := &kafka.Writer{
-		Addr:     kafka.TCP(cfg.Brokers...),
-		Topic:    cfg.TopicDLQ,
-		Balancer: &kafka.Hash{},
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.TopicDLQ,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: dlqBatchTimeout,
 	}
 
 	logger.Info("kafka consumer created",
 		zap.Strings("brokers", cfg.Brokers),
 		zap.String("topic", cfg.TopicChanges),
 		zap.String("group", cfg.ConsumerGroup),
+		zap.Int("workers", cfg.Workers),
 	)
 
 	return &Consumer{
@@ -57,6 +91,8 @@ func NewConsumer(cfg config.KafkaConfig, handler MessageHandler, logger *zap.Log
 		handler:   handler,
 		cfg:       cfg,
 		logger:    logger,
+		offsets:   newOffsetTracker(),
+		inflight:  make(chan struct{}, cfg.MaxInFlight),
 	}
 }
 
@@ -64,9 +100,27 @@ func (c *Consumer) Start(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	c.cancelFunc = cancel
 
+	c.workers = make([]chan kafka.Message, c.cfg.Workers)
+	for i := range c.workers {
+		queue := make(chan kafka.Message, workerQueueSize)
+		c.workers[i] = queue
+		c.workerWg.Add(1)
+		go func() {
+			defer c.workerWg.Done()
+			for msg := range queue {
+				c.processMessage(ctx, msg)
+			}
+		}()
+	}
+
 	c.wg.Add(1)
 	go func() {
 		defer c.wg.Done()
+		defer func() {
+			for _, queue := range c.workers {
+				close(queue)
+			}
+		}()
 		c.consumeLoop(ctx)
 	}()
 
@@ -80,11 +134,12 @@ func (c *Consumer) consumeLoop(ctx context.Context) {
 		case <-ctx.Done():
 			c.logger.Info("kafka consumer shutting down")
 			return
-		default:
+		case c.inflight <- struct{}{}:
 		}
 
 		msg, err := c.reader.FetchMessage(ctx)
 		if err != nil {
+			<-c.inflight
 			if ctx.Err() != nil {
 				return
 			}
@@ -97,8 +152,32 @@ func (c *Consumer) consumeLoop(ctx context.Context) {
 			continue
 		}
 
-		c.processMessage(ctx, msg)
+		// Track before dispatch: workers finish out of order, but the
+		// watermark needs each partition's offsets in fetch order.
+		c.offsets.track(msg.Partition, msg.Offset)
+		observability.KafkaConsumerLag.WithLabelValues(msg.Topic, strconv.Itoa(msg.Partition)).
+			Set(float64(max(msg.HighWaterMark-msg.Offset-1, 0)))
+
+		select {
+		case c.workers[c.workerFor(msg)] <- msg:
+		case <-ctx.Done():
+			// Tracked but never completed, so it stays uncommitted.
+			c.abandon()
+			return
+		}
+	}
+}
+
+// workerFor picks the worker for msg by its key, the document ID, falling
+// back to the partition for unkeyed messages.
+func (c *Consumer) workerFor(msg kafka.Message) int {
+	h := fnv.New32a()
+	if len(msg.Key) > 0 {
+		h.Write(msg.Key)
+	} else {
+		h.Write([]byte(strconv.Itoa(msg.Partition)))
 	}
+	return int(h.Sum32() % uint32(len(c.workers)))
 }
 
 func (c *Consumer) processMessage(ctx context.Context, msg kafka.Message) {
@@ -112,7 +191,7 @@ func (c *Consumer) processMessage(ctx context.Context, msg kafka.Message) {
 			zap.Int("partition", msg.Partition),
 		)
 		c.sendToDLQ(ctx, msg, fmt.Sprintf("unmarshal error: %v", err))
-		c.commitMessage(ctx, msg)
+		c.complete(msg)
 		return
 	}
 
@@ -120,9 +199,14 @@ func (c *Consumer) processMessage(ctx context.Context, msg kafka.Message) {
 	lag := time.Since(event.Timestamp)
 	observability.IndexingLag.Set(lag.Seconds())
 
+	var once sync.Once
+	ack := func(err error) {
+		once.Do(func() { c.acknowledge(msg, &event, err) })
+	}
+
 	var lastErr error
 	for attempt := 0; attempt < c.cfg.MaxRetries; attempt++ {
-		if err := c.handler(ctx, &event); err != nil {
+		if err := c.handler(ctx, &event, ack); err != nil {
 			lastErr = err
 			c.logger.Warn("handler error, retrying",
 				zap.Error(err),
@@ -132,6 +216,8 @@ func (c *Consumer) processMessage(ctx context.Context, msg kafka.Message) {
 			backoff := time.Duration(1<<uint(attempt)) * 100 * time.Millisecond
 			select {
 			case <-ctx.Done():
+				// Left uncommitted so it is redelivered after a restart.
+				c.abandon()
 				return
 			case <-time.After(backoff):
 			}
@@ -148,17 +234,53 @@ func (c *Consumer) processMessage(ctx context.Context, msg kafka.Message) {
 		)
 		observability.IndexingEventsTotal.WithLabelValues(event.Type, "dlq").Inc()
 		c.sendToDLQ(ctx, msg, fmt.Sprintf("handler error after retries: %v", lastErr))
+		c.complete(msg)
+		return
+	}
+
+	c.logger.Debug("message accepted",
+		zap.String("doc_id", event.DocumentID),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+// acknowledge finishes a message once the handler reports its outcome. A
+// permanent failure goes to the DLQ; either way the message is done and its
+// offset may be committed.
+func (c *Consumer) acknowledge(msg kafka.Message, event *models.ChangeEvent, err error) {
+	if err != nil {
+		c.logger.Error("event failed permanently, sending to DLQ",
+			zap.Error(err),
+			zap.String("doc_id", event.DocumentID),
+		)
+		observability.IndexingEventsTotal.WithLabelValues(event.Type, "dlq").Inc()
+		dlqCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		c.sendToDLQ(dlqCtx, msg, err.Error())
+		cancel()
 	} else {
 		observability.IndexingEventsTotal.WithLabelValues(event.Type, "success").Inc()
 	}
+	c.complete(msg)
+}
 
-	c.commitMessage(ctx, msg)
+// abandon gives up a message's in-flight slot without completing it: its
+// offset stays uncommitted, so it is redelivered, but shutdown need not wait
+// for it.
+func (c *Consumer) abandon() {
+	<-c.inflight
+}
 
-	duration := time.Since(start)
-	c.logger.Debug("message processed",
-		zap.String("doc_id", event.DocumentID),
-		zap.Duration("duration", duration),
-	)
+// complete marks msg done and commits its partition's watermark if it moved.
+func (c *Consumer) complete(msg kafka.Message) {
+	defer func() { <-c.inflight }()
+
+	watermark, advanced := c.offsets.complete(msg.Partition, msg.Offset)
+	if !advanced {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c.commitMessage(ctx, kafka.Message{Topic: msg.Topic, Partition: msg.Partition, Offset: watermark})
 }
 
 func (c *Consumer) sendToDLQ(ctx context.Context, msg kafka.Message, reason string) {
@@ -166,10 +288,10 @@ func (c *Consumer) sendToDLQ(ctx context.Context, msg kafka.Message, reason stri
 	headers := make([]kafka.Header, len(msg.Headers), len(msg.Headers)+4)
 	copy(headers, msg.Headers)
 	headers = append(headers,
-		kafka.Header{Key: "dlq_reason", Value: []byte(reason)},
-		kafka.Header{Key: "original_topic", Value: []byte(c.cfg.TopicChanges)},
-		kafka.Header{Key: "original_partition", Value: []byte(fmt.Sprintf("%d", msg.Partition))},
-		kafka.Header{Key: "original_offset", Value: []byte(fmt.Sprintf("%d", msg.Offset))},
+		kafka.Header{Key: headerDLQReason, Value: []byte(reason)},
+		kafka.Header{Key: headerOriginalTopic, Value: []byte(c.cfg.TopicChanges)},
+		kafka.Header{Key: headerOriginalPartition, Value: []byte(fmt.Sprintf("%d", msg.Partition))},
+		kafka.Header{Key: headerOriginalOffset, Value: []byte(fmt.Sprintf("%d", msg.Offset))},
 	)
 	dlqMsg := kafka.Message{
 		Key:     msg.Key,
@@ -194,6 +316,21 @@ func (c *Consumer) commitMessage(ctx context.Context, msg kafka.Message) {
 	}
 }
 
+// drain waits up to drainTimeout for accepted messages to be acknowledged so
+// their offsets are committed before the reader closes.
+func (c *Consumer) drain() {
+	deadline := time.Now().Add(drainTimeout)
+	for len(c.inflight) > 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if n := len(c.inflight); n > 0 {
+		c.logger.Warn("stopping with unacknowledged messages, they will be redelivered",
+			zap.Int("in_flight", n),
+			zap.Any("by_partition", c.offsets.inFlight()),
+		)
+	}
+}
+
 func (c *Consumer) HealthCheck(ctx context.Context) error {
 	conn, err := kafka.DialContext(ctx, "tcp", c.cfg.Brokers[0])
 	if err != nil {
@@ -213,6 +350,8 @@ func (c *Consumer) Stop() error {
 		c.cancelFunc()
 	}
 	c.wg.Wait()
+	c.workerWg.Wait()
+	c.drain()
 
 	var errs []error
 	if err := c.reader.Close(); err != nil {
@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+func TestWorkerFor_SameKeySameWorker(t *testing.T) {
+	c := &Consumer{workers: make([]chan kafka.Message, 8)}
+
+	first := c.workerFor(kafka.Message{Key: []byte("doc-1"), Partition: 0})
+	for partition := 1; partition < 4; partition++ {
+		if got := c.workerFor(kafka.Message{Key: []byte("doc-1"), Partition: partition}); got != first {
+			t.Errorf("doc-1 on partition %d went to worker %d, expected %d", partition, got, first)
+		}
+	}
+}
+
+func TestWorkerFor_SpreadsKeys(t *testing.T) {
+	c := &Consumer{workers: make([]chan kafka.Message, 8)}
+
+	used := make(map[int]bool)
+	for i := 0; i < 100; i++ {
+		w := c.workerFor(kafka.Message{Key: []byte{byte(i), 'k'}})
+		if w < 0 || w >= len(c.workers) {
+			t.Fatalf("worker %d out of range", w)
+		}
+		used[w] = true
+	}
+	if len(used) < 2 {
+		t.Errorf("expected keys spread over several workers, got %d", len(used))
+	}
+}
+
+func TestWorkerFor_UnkeyedUsesPartition(t *testing.T) {
+	c := &Consumer{workers: make([]chan kafka.Message, 8)}
+
+	a := c.workerFor(kafka.Message{Partition: 3, Offset: 1})
+	b := c.workerFor(kafka.Message{Partition: 3, Offset: 2})
+	if a != b {
+		t.Errorf("unkeyed messages from one partition split across workers %d and %d", a, b)
+	}
+}
+
+func TestProcessMessage_CancelledDuringBackoffReleasesSlot(t *testing.T) {
+	c := &Consumer{
+		handler: func(ctx context.Context, event *models.ChangeEvent, ack func(error)) error {
+			return errors.New("es unavailable")
+		},
+		cfg:      config.KafkaConfig{MaxRetries: 3},
+		logger:   zap.NewNop(),
+		offsets:  newOffsetTracker(),
+		inflight: make(chan struct{}, 1),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c.inflight <- struct{}{}
+	c.offsets.track(0, 7)
+	c.processMessage(ctx, kafka.Message{Partition: 0, Offset: 7, Value: []byte(`{"type":"CREATE"}`)})
+
+	if len(c.inflight) != 0 {
+		t.Error("expected the in-flight slot to be released on shutdown")
+	}
+	if got := c.offsets.inFlight()[0]; got != 1 {
+		t.Errorf("expected the message to stay uncommitted, got %d in flight", got)
+	}
+}
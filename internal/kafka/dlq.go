@@ -0,0 +1,600 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// Headers Consumer.sendToDLQ adds to a dead-lettered message, and the one a
+// replay adds so a message that fails again can be traced to its DLQ entry.
+const (
+	headerDLQReason         = "dlq_reason"
+	headerOriginalTopic     = "original_topic"
+	headerOriginalPartition = "original_partition"
+	headerOriginalOffset    = "original_offset"
+	headerReplayedFrom      = "dlq_replayed_from"
+)
+
+const (
+	// defaultDLQLimit and maxDLQLimit bound how many messages one list or
+	// replay touches.
+	defaultDLQLimit = 100
+	maxDLQLimit     = 10000
+	// dlqReadTimeout ends a partition scan that stalls before reaching the
+	// high-water mark, e.g. on a trailing transaction marker.
+	dlqReadTimeout = 10 * time.Second
+)
+
+// Replay targets.
+const (
+	ReplayToTopic     = "topic"
+	ReplayToProcessor = "processor"
+)
+
+var (
+	// ErrDLQMessageNotFound is returned by Get for an offset outside the
+	// partition's retained range.
+	ErrDLQMessageNotFound = errors.New("dlq message not found")
+	// ErrReplayInProgress is returned when a replay is started while another
+	// is still running.
+	ErrReplayInProgress = errors.New("dlq replay already in progress")
+	// ErrInvalidReplay is returned for a replay request that cannot run.
+	ErrInvalidReplay = errors.New("invalid dlq replay")
+
+	errStopScan = errors.New("stop scan")
+)
+
+// DLQMessage is a dead-lettered change event with the metadata recorded when
+// it was dead-lettered.
+type DLQMessage struct {
+	Partition         int                 `json:"partition"`
+	Offset            int64               `json:"offset"`
+	Time              time.Time           `json:"time"`
+	Reason            string              `json:"reason"`
+	OriginalTopic     string              `json:"original_topic,omitempty"`
+	OriginalPartition int                 `json:"original_partition"`
+	OriginalOffset    int64               `json:"original_offset"`
+	DocumentID        string              `json:"document_id,omitempty"`
+	Event             *models.ChangeEvent `json:"event,omitempty"`
+	// Raw holds the payload when it is not a valid change event.
+	Raw string `json:"raw,omitempty"`
+
+	key     []byte
+	value   []byte
+	headers []kafka.Header
+}
+
+func newDLQMessage(msg kafka.Message) DLQMessage {
+	m := DLQMessage{
+		Partition:  msg.Partition,
+		Offset:     msg.Offset,
+		Time:       msg.Time,
+		DocumentID: string(msg.Key),
+		key:        msg.Key,
+		value:      msg.Value,
+		headers:    msg.Headers,
+	}
+	for _, h := range msg.Headers {
+		switch h.Key {
+		case headerDLQReason:
+			m.Reason = string(h.Value)
+		case headerOriginalTopic:
+			m.OriginalTopic = string(h.Value)
+		case headerOriginalPartition:
+			m.OriginalPartition, _ = strconv.Atoi(string(h.Value))
+		case headerOriginalOffset:
+			m.OriginalOffset, _ = strconv.ParseInt(string(h.Value), 10, 64)
+		}
+	}
+
+	var event models.ChangeEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		m.Raw = string(msg.Value)
+		return m
+	}
+	m.Event = &event
+	if m.DocumentID == "" {
+		m.DocumentID = event.DocumentID
+	}
+	return m
+}
+
+// DLQFilter selects DLQ messages. Empty fields match everything.
+type DLQFilter struct {
+	// Reason matches a case-insensitive substring of the dead-letter reason.
+	Reason     string    `json:"reason,omitempty"`
+	DocumentID string    `json:"document_id,omitempty"`
+	Since      time.Time `json:"since,omitempty"`
+	Until      time.Time `json:"until,omitempty"`
+	// Limit caps the number of matches; zero means the default of 100.
+	Limit int `json:"limit,omitempty"`
+}
+
+// Match reports whether m passes the filter.
+func (f DLQFilter) Match(m *DLQMessage) bool {
+	if f.Reason != "" && !strings.Contains(strings.ToLower(m.Reason), strings.ToLower(f.Reason)) {
+		return false
+	}
+	if f.DocumentID != "" && m.DocumentID != f.DocumentID {
+		return false
+	}
+	if !f.Since.IsZero() && m.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !m.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+func (f DLQFilter) limit() int {
+	switch {
+	case f.Limit <= 0:
+		return defaultDLQLimit
+	case f.Limit > maxDLQLimit:
+		return maxDLQLimit
+	default:
+		return f.Limit
+	}
+}
+
+// ReplayRequest selects DLQ messages and says where to send them. Rate is in
+// messages per second; zero uses the configured default.
+type ReplayRequest struct {
+	DLQFilter
+	Target string  `json:"target"`
+	DryRun bool    `json:"dry_run,omitempty"`
+	Rate   float64 `json:"rate,omitempty"`
+}
+
+// ReplayStatus is the progress of the current or most recent replay.
+type ReplayStatus struct {
+	Running  bool   `json:"running"`
+	Target   string `json:"target,omitempty"`
+	DryRun   bool   `json:"dry_run"`
+	Matched  int    `json:"matched"`
+	Replayed int    `json:"replayed"`
+	Failed   int    `json:"failed"`
+	// Rejected counts processor replays Elasticsearch rejected again; they
+	// are dead-lettered anew.
+	Rejected   int        `json:"rejected"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	// Messages lists the matches of a dry run.
+	Messages []DLQMessage `json:"messages,omitempty"`
+}
+
+// ProcessFunc applies a replayed change event directly, bypassing the change
+// topic. It follows the MessageHandler contract: once it accepts the event,
+// ack reports whether it was applied.
+type ProcessFunc MessageHandler
+
+// DLQ reads the dead-letter topic back and replays its messages, either onto
+// the change topic, where they go through the consumer again, or straight
+// into a ProcessFunc. Replaying does not remove messages from the DLQ;
+// versioned indexing makes replaying one twice harmless. Only one replay runs
+// at a time.
+type DLQ struct {
+	cfg     config.KafkaConfig
+	writer  *kafka.Writer
+	process ProcessFunc
+	// deadLetters writes processor replays that fail again back to the DLQ.
+	deadLetters *kafka.Writer
+	writeDLQ    func(ctx context.Context, msgs ...kafka.Message) error
+	defaultRate float64
+	logger      *zap.Logger
+
+	mu     sync.Mutex
+	status ReplayStatus
+}
+
+// NewDLQ creates a DLQ reader for cfg.TopicDLQ that replays onto
+// cfg.TopicChanges. process may be nil, in which case only topic replays
+// are available.
+func NewDLQ(cfg config.KafkaConfig, process ProcessFunc, logger *zap.Logger) *DLQ {
+	// Replays write one message at a time at the requested rate; a batch
+	// size of one sends each at once instead of waiting the default second
+	// for company.
+	d := &DLQ{
+		cfg: cfg,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.TopicChanges,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchSize:    1,
+		},
+		process: process,
+		deadLetters: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.TopicDLQ,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchSize:    1,
+		},
+		defaultRate: cfg.DLQReplayRate,
+		logger:      logger,
+	}
+	d.writeDLQ = d.deadLetters.WriteMessages
+	return d
+}
+
+// List returns the messages matching filter, oldest first per partition.
+func (d *DLQ) List(ctx context.Context, filter DLQFilter) ([]DLQMessage, error) {
+	var messages []DLQMessage
+	err := d.scan(ctx, filter, func(m DLQMessage) error {
+		messages = append(messages, m)
+		return nil
+	})
+	return messages, err
+}
+
+// Get returns the message at partition and offset.
+func (d *DLQ) Get(ctx context.Context, partition int, offset int64) (*DLQMessage, error) {
+	first, last, err := d.offsets(ctx, partition)
+	if err != nil {
+		return nil, err
+	}
+	if offset < first || offset >= last {
+		return nil, ErrDLQMessageNotFound
+	}
+
+	reader := d.partitionReader(partition)
+	defer reader.Close()
+	if err := reader.SetOffset(offset); err != nil {
+		return nil, fmt.Errorf("seeking dlq partition %d: %w", partition, err)
+	}
+	readCtx, cancel := context.WithTimeout(ctx, dlqReadTimeout)
+	defer cancel()
+	msg, err := reader.ReadMessage(readCtx)
+	if err != nil {
+		return nil, fmt.Errorf("reading dlq message: %w", err)
+	}
+	m := newDLQMessage(msg)
+	return &m, nil
+}
+
+// Replay replays the messages req selects and waits for it to finish. A dry
+// run only lists what would be replayed.
+func (d *DLQ) Replay(ctx context.Context, req ReplayRequest) (ReplayStatus, error) {
+	if err := d.begin(req); err != nil {
+		return ReplayStatus{}, err
+	}
+	err := d.run(ctx, req)
+	return d.Status(), err
+}
+
+// StartReplay begins a replay in the background and returns immediately. The
+// replay stops early if ctx is cancelled.
+func (d *DLQ) StartReplay(ctx context.Context, req ReplayRequest) error {
+	if err := d.begin(req); err != nil {
+		return err
+	}
+	go d.run(ctx, req)
+	return nil
+}
+
+// Status returns a snapshot of the current or most recent replay.
+func (d *DLQ) Status() ReplayStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+// Close releases the replay writers.
+func (d *DLQ) Close() error {
+	return errors.Join(d.writer.Close(), d.deadLetters.Close())
+}
+
+func (d *DLQ) begin(req ReplayRequest) error {
+	switch req.Target {
+	case ReplayToTopic:
+	case ReplayToProcessor:
+		if d.process == nil {
+			return fmt.Errorf("%w: processor target unavailable", ErrInvalidReplay)
+		}
+	default:
+		return fmt.Errorf("%w: target must be %q or %q", ErrInvalidReplay, ReplayToTopic, ReplayToProcessor)
+	}
+	if req.Rate < 0 {
+		return fmt.Errorf("%w: rate must not be negative", ErrInvalidReplay)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.status.Running {
+		return ErrReplayInProgress
+	}
+	now := time.Now().UTC()
+	d.status = ReplayStatus{
+		Running:   true,
+		Target:    req.Target,
+		DryRun:    req.DryRun,
+		StartedAt: &now,
+	}
+	return nil
+}
+
+func (d *DLQ) run(ctx context.Context, req ReplayRequest) error {
+	rate := req.Rate
+	if rate == 0 {
+		rate = d.defaultRate
+	}
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	defer ticker.Stop()
+
+	err := d.scan(ctx, req.DLQFilter, func(m DLQMessage) error {
+		d.mu.Lock()
+		d.status.Matched++
+		if req.DryRun {
+			d.status.Messages = append(d.status.Messages, m)
+		}
+		d.mu.Unlock()
+		if req.DryRun {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		err := d.replay(ctx, req.Target, &m)
+		status := "success"
+		if err != nil {
+			status = "error"
+			d.logger.Warn("dlq replay failed",
+				zap.Int("partition", m.Partition),
+				zap.Int64("offset", m.Offset),
+				zap.String("doc_id", m.DocumentID),
+				zap.Error(err),
+			)
+		}
+		observability.DLQReplayedTotal.WithLabelValues(req.Target, status).Inc()
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if err != nil {
+			d.status.Failed++
+			d.status.LastError = err.Error()
+		} else {
+			d.status.Replayed++
+		}
+		return nil
+	})
+
+	d.finish(err)
+	return err
+}
+
+func (d *DLQ) replay(ctx context.Context, target string, m *DLQMessage) error {
+	if target == ReplayToProcessor {
+		if m.Event == nil {
+			return fmt.Errorf("payload is not a change event")
+		}
+		return d.process(ctx, m.Event, func(err error) {
+			if err != nil {
+				d.deadLetterAgain(m, err)
+			}
+		})
+	}
+
+	// Carry the original headers over, minus the dead-letter ones, which
+	// would be added again if the message fails again.
+	headers := make([]kafka.Header, 0, len(m.headers)+1)
+	for _, h := range m.headers {
+		switch h.Key {
+		case headerDLQReason, headerOriginalTopic, headerOriginalPartition, headerOriginalOffset, headerReplayedFrom:
+			continue
+		}
+		headers = append(headers, h)
+	}
+	headers = append(headers, kafka.Header{
+		Key:   headerReplayedFrom,
+		Value: []byte(fmt.Sprintf("%d/%d", m.Partition, m.Offset)),
+	})
+
+	return d.writer.WriteMessages(ctx, kafka.Message{
+		Key:     m.key,
+		Value:   m.value,
+		Headers: headers,
+	})
+}
+
+// deadLetterAgain writes a processor replay Elasticsearch rejected back to
+// the DLQ with the new reason, keeping where it originally came from.
+func (d *DLQ) deadLetterAgain(m *DLQMessage, reason error) {
+	d.logger.Warn("replayed dlq event rejected, dead-lettering it again",
+		zap.Int("partition", m.Partition),
+		zap.Int64("offset", m.Offset),
+		zap.String("doc_id", m.DocumentID),
+		zap.Error(reason),
+	)
+	observability.DLQReplayedTotal.WithLabelValues(ReplayToProcessor, "rejected").Inc()
+	d.mu.Lock()
+	d.status.Rejected++
+	d.mu.Unlock()
+
+	headers := make([]kafka.Header, 0, len(m.headers)+5)
+	for _, h := range m.headers {
+		switch h.Key {
+		case headerDLQReason, headerOriginalTopic, headerOriginalPartition, headerOriginalOffset, headerReplayedFrom:
+			continue
+		}
+		headers = append(headers, h)
+	}
+	headers = append(headers,
+		kafka.Header{Key: headerDLQReason, Value: []byte(reason.Error())},
+		kafka.Header{Key: headerOriginalTopic, Value: []byte(m.OriginalTopic)},
+		kafka.Header{Key: headerOriginalPartition, Value: []byte(strconv.Itoa(m.OriginalPartition))},
+		kafka.Header{Key: headerOriginalOffset, Value: []byte(strconv.FormatInt(m.OriginalOffset, 10))},
+		kafka.Header{Key: headerReplayedFrom, Value: []byte(fmt.Sprintf("%d/%d", m.Partition, m.Offset))},
+	)
+
+	// The ack may come after the replay has finished, so the write does not
+	// follow the replay's context.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := d.writeDLQ(ctx, kafka.Message{Key: m.key, Value: m.value, Headers: headers}); err != nil {
+		d.logger.Error("failed to dead-letter replayed event",
+			zap.String("doc_id", m.DocumentID),
+			zap.Error(err),
+		)
+	}
+}
+
+func (d *DLQ) finish(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now().UTC()
+	d.status.Running = false
+	d.status.FinishedAt = &now
+	if err != nil {
+		d.status.LastError = err.Error()
+	}
+
+	d.logger.Info("dlq replay finished",
+		zap.String("target", d.status.Target),
+		zap.Bool("dry_run", d.status.DryRun),
+		zap.Int("matched", d.status.Matched),
+		zap.Int("replayed", d.status.Replayed),
+		zap.Int("failed", d.status.Failed),
+		zap.Error(err),
+	)
+}
+
+// scan calls fn for each message matching filter, partition by partition,
+// up to the filter's limit. It reads up to each partition's high-water mark
+// as of the start of the scan.
+func (d *DLQ) scan(ctx context.Context, filter DLQFilter, fn func(DLQMessage) error) error {
+	partitions, err := d.partitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	limit := filter.limit()
+	matched := 0
+	for _, partition := range partitions {
+		err := d.scanPartition(ctx, partition, func(m DLQMessage) error {
+			if !filter.Match(&m) {
+				return nil
+			}
+			if err := fn(m); err != nil {
+				return err
+			}
+			matched++
+			if matched >= limit {
+				return errStopScan
+			}
+			return nil
+		})
+		if errors.Is(err, errStopScan) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DLQ) scanPartition(ctx context.Context, partition int, fn func(DLQMessage) error) error {
+	first, last, err := d.offsets(ctx, partition)
+	if err != nil {
+		return err
+	}
+	if first >= last {
+		return nil
+	}
+
+	reader := d.partitionReader(partition)
+	defer reader.Close()
+	if err := reader.SetOffset(first); err != nil {
+		return fmt.Errorf("seeking dlq partition %d: %w", partition, err)
+	}
+
+	for {
+		readCtx, cancel := context.WithTimeout(ctx, dlqReadTimeout)
+		msg, err := reader.ReadMessage(readCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				d.logger.Warn("dlq partition scan stalled before high-water mark",
+					zap.Int("partition", partition),
+					zap.Int64("high_water_mark", last),
+				)
+				return nil
+			}
+			return fmt.Errorf("reading dlq partition %d: %w", partition, err)
+		}
+		if err := fn(newDLQMessage(msg)); err != nil {
+			return err
+		}
+		if msg.Offset >= last-1 {
+			return nil
+		}
+	}
+}
+
+func (d *DLQ) partitions(ctx context.Context) ([]int, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", d.cfg.Brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("dialing kafka: %w", err)
+	}
+	defer conn.Close()
+
+	parts, err := conn.ReadPartitions(d.cfg.TopicDLQ)
+	if err != nil {
+		return nil, fmt.Errorf("reading dlq partitions: %w", err)
+	}
+	ids := make([]int, len(parts))
+	for i, p := range parts {
+		ids[i] = p.ID
+	}
+	return ids, nil
+}
+
+// offsets returns the first retained offset of a partition and its
+// high-water mark.
+func (d *DLQ) offsets(ctx context.Context, partition int) (int64, int64, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", d.cfg.Brokers[0], d.cfg.TopicDLQ, partition)
+	if err != nil {
+		return 0, 0, fmt.Errorf("dialing dlq partition %d leader: %w", partition, err)
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, fmt.Errorf("reading dlq partition %d offsets: %w", partition, err)
+	}
+	return first, last, nil
+}
+
+func (d *DLQ) partitionReader(partition int) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   d.cfg.Brokers,
+		Topic:     d.cfg.TopicDLQ,
+		Partition: partition,
+		MinBytes:  1,
+		MaxBytes:  10e6, // 10MB
+		MaxWait:   500 * time.Millisecond,
+	})
+}
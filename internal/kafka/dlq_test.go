@@ -0,0 +1,162 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+func TestNewDLQMessage(t *testing.T) {
+	msg := kafka.Message{
+		Partition: 2,
+		Offset:    40,
+		Key:       []byte("doc-1"),
+		Value:     []byte(`{"type":"UPDATE","document_id":"doc-1","version":7}`),
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte("UPDATE")},
+			{Key: headerDLQReason, Value: []byte("mapper_parsing_exception")},
+			{Key: headerOriginalTopic, Value: []byte("docs.changes")},
+			{Key: headerOriginalPartition, Value: []byte("5")},
+			{Key: headerOriginalOffset, Value: []byte("1234")},
+		},
+	}
+
+	m := newDLQMessage(msg)
+
+	if m.Reason != "mapper_parsing_exception" || m.OriginalTopic != "docs.changes" {
+		t.Errorf("unexpected reason/topic: %+v", m)
+	}
+	if m.OriginalPartition != 5 || m.OriginalOffset != 1234 {
+		t.Errorf("expected original 5/1234, got %d/%d", m.OriginalPartition, m.OriginalOffset)
+	}
+	if m.Event == nil || m.Event.Version != 7 || m.Raw != "" {
+		t.Errorf("expected decoded event, got event=%+v raw=%q", m.Event, m.Raw)
+	}
+}
+
+func TestNewDLQMessage_Undecodable(t *testing.T) {
+	m := newDLQMessage(kafka.Message{Value: []byte("{not json")})
+	if m.Event != nil || m.Raw != "{not json" {
+		t.Errorf("expected raw payload only, got event=%+v raw=%q", m.Event, m.Raw)
+	}
+}
+
+func TestDLQFilter_Match(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := &DLQMessage{Reason: "Elasticsearch rejected: mapper_parsing_exception", DocumentID: "doc-1", Time: at}
+
+	tests := []struct {
+		name   string
+		filter DLQFilter
+		want   bool
+	}{
+		{"empty", DLQFilter{}, true},
+		{"reason substring, any case", DLQFilter{Reason: "MAPPER"}, true},
+		{"other reason", DLQFilter{Reason: "unmarshal"}, false},
+		{"doc id", DLQFilter{DocumentID: "doc-1"}, true},
+		{"other doc id", DLQFilter{DocumentID: "doc-2"}, false},
+		{"since before", DLQFilter{Since: at.Add(-time.Hour)}, true},
+		{"since after", DLQFilter{Since: at.Add(time.Hour)}, false},
+		{"until after", DLQFilter{Until: at.Add(time.Hour)}, true},
+		{"until exclusive", DLQFilter{Until: at}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(m); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDLQFilter_Limit(t *testing.T) {
+	for limit, want := range map[int]int{0: defaultDLQLimit, 5: 5, maxDLQLimit + 1: maxDLQLimit} {
+		if got := (DLQFilter{Limit: limit}).limit(); got != want {
+			t.Errorf("limit %d: expected %d, got %d", limit, want, got)
+		}
+	}
+}
+
+func TestDLQReplay_Validation(t *testing.T) {
+	cfg := config.DefaultConfig().Kafka
+	withProcessor := NewDLQ(cfg, func(context.Context, *models.ChangeEvent, func(error)) error { return nil }, zap.NewNop())
+	topicOnly := NewDLQ(cfg, nil, zap.NewNop())
+
+	tests := []struct {
+		name string
+		dlq  *DLQ
+		req  ReplayRequest
+	}{
+		{"missing target", withProcessor, ReplayRequest{}},
+		{"unknown target", withProcessor, ReplayRequest{Target: "elsewhere"}},
+		{"processor unavailable", topicOnly, ReplayRequest{Target: ReplayToProcessor}},
+		{"negative rate", withProcessor, ReplayRequest{Target: ReplayToTopic, Rate: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dlq.StartReplay(context.Background(), tt.req); !errors.Is(err, ErrInvalidReplay) {
+				t.Errorf("expected ErrInvalidReplay, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDLQReplay_InProgress(t *testing.T) {
+	d := NewDLQ(config.DefaultConfig().Kafka, nil, zap.NewNop())
+	d.status.Running = true
+
+	if err := d.StartReplay(context.Background(), ReplayRequest{Target: ReplayToTopic}); !errors.Is(err, ErrReplayInProgress) {
+		t.Errorf("expected ErrReplayInProgress, got %v", err)
+	}
+}
+
+func TestDLQReplay_ProcessorRejectionIsDeadLetteredAgain(t *testing.T) {
+	d := NewDLQ(config.DefaultConfig().Kafka, func(_ context.Context, _ *models.ChangeEvent, ack func(error)) error {
+		ack(errors.New("mapper_parsing_exception"))
+		return nil
+	}, zap.NewNop())
+	var written []kafka.Message
+	d.writeDLQ = func(_ context.Context, msgs ...kafka.Message) error {
+		written = append(written, msgs...)
+		return nil
+	}
+
+	m := newDLQMessage(kafka.Message{
+		Partition: 1,
+		Offset:    9,
+		Key:       []byte("doc-1"),
+		Value:     []byte(`{"type":"UPDATE","document_id":"doc-1","version":7}`),
+		Headers: []kafka.Header{
+			{Key: "event_type", Value: []byte("UPDATE")},
+			{Key: headerDLQReason, Value: []byte("timeout")},
+			{Key: headerOriginalTopic, Value: []byte("docs.changes")},
+			{Key: headerOriginalPartition, Value: []byte("5")},
+			{Key: headerOriginalOffset, Value: []byte("1234")},
+		},
+	})
+	if err := d.replay(context.Background(), ReplayToProcessor, &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(written) != 1 {
+		t.Fatalf("expected the rejected event to be dead-lettered again, got %d messages", len(written))
+	}
+	again := newDLQMessage(written[0])
+	if again.Reason != "mapper_parsing_exception" || again.OriginalTopic != "docs.changes" ||
+		again.OriginalPartition != 5 || again.OriginalOffset != 1234 {
+		t.Errorf("expected the new reason and the original position, got %+v", again)
+	}
+	if again.Event == nil || again.Event.Version != 7 || string(written[0].Key) != "doc-1" {
+		t.Errorf("expected the original key and payload, got %+v", written[0])
+	}
+	if d.Status().Rejected != 1 {
+		t.Errorf("expected one rejected replay, got %+v", d.Status())
+	}
+}
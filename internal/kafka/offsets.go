@@ -0,0 +1,69 @@
+package kafka
+
+import "sync"
+
+// offsetTracker records, per partition, which fetched offsets have been fully
+// processed and reports the watermark: the highest offset below which every
+// fetched message is done. Only the watermark is safe to commit, since
+// messages can finish out of order.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[int]*partitionOffsets
+}
+
+type partitionOffsets struct {
+	// pending holds fetched offsets in fetch order, which for one partition
+	// is ascending.
+	pending []int64
+	done    map[int64]bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[int]*partitionOffsets)}
+}
+
+// track registers a fetched offset as in flight.
+func (t *offsetTracker) track(partition int, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.partitions[partition]
+	if !ok {
+		p = &partitionOffsets{done: make(map[int64]bool)}
+		t.partitions[partition] = p
+	}
+	p.pending = append(p.pending, offset)
+}
+
+// complete marks an offset done. It returns the new watermark offset, the
+// last message that may be committed, and whether it advanced.
+func (t *offsetTracker) complete(partition int, offset int64) (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.partitions[partition]
+	if !ok {
+		return 0, false
+	}
+	p.done[offset] = true
+
+	var watermark int64
+	advanced := false
+	for len(p.pending) > 0 && p.done[p.pending[0]] {
+		watermark = p.pending[0]
+		delete(p.done, watermark)
+		p.pending = p.pending[1:]
+		advanced = true
+	}
+	return watermark, advanced
+}
+
+// inFlight returns the number of tracked offsets not yet committable, per
+// partition.
+func (t *offsetTracker) inFlight() map[int]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	counts := make(map[int]int, len(t.partitions))
+	for partition, p := range t.partitions {
+		counts[partition] = len(p.pending)
+	}
+	return counts
+}
@@ -0,0 +1,43 @@
+package kafka
+
+import "testing"
+
+func TestOffsetTracker_WatermarkWaitsForGaps(t *testing.T) {
+	tr := newOffsetTracker()
+	for _, off := range []int64{10, 11, 12} {
+		tr.track(0, off)
+	}
+
+	if _, advanced := tr.complete(0, 11); advanced {
+		t.Fatal("watermark should not advance past unfinished offset 10")
+	}
+	if wm, advanced := tr.complete(0, 10); !advanced || wm != 11 {
+		t.Fatalf("expected watermark 11, got %d (advanced=%v)", wm, advanced)
+	}
+	if got := tr.inFlight()[0]; got != 1 {
+		t.Errorf("expected 1 offset in flight, got %d", got)
+	}
+	if wm, advanced := tr.complete(0, 12); !advanced || wm != 12 {
+		t.Fatalf("expected watermark 12, got %d (advanced=%v)", wm, advanced)
+	}
+}
+
+func TestOffsetTracker_PartitionsIndependent(t *testing.T) {
+	tr := newOffsetTracker()
+	tr.track(0, 5)
+	tr.track(1, 7)
+
+	if wm, advanced := tr.complete(1, 7); !advanced || wm != 7 {
+		t.Fatalf("expected partition 1 watermark 7, got %d (advanced=%v)", wm, advanced)
+	}
+	if got := tr.inFlight()[0]; got != 1 {
+		t.Errorf("expected partition 0 untouched, got %d in flight", got)
+	}
+}
+
+func TestOffsetTracker_UnknownPartition(t *testing.T) {
+	tr := newOffsetTracker()
+	if _, advanced := tr.complete(3, 1); advanced {
+		t.Error("completing an untracked partition should not advance")
+	}
+}
@@ -27,17 +27,19 @@ func (i Intent) String() string {
 }
 
 type SearchRequest struct {
-	Query       string            `json:"query"`
-	Filters     map[string]any    `json:"filters,omitempty"`
-	Page        int               `json:"page"`
-	PageSize    int               `json:"page_size"`
-	Sort        string            `json:"sort,omitempty"`
-	Region      string            `json:"region,omitempty"`
-	UserID      string            `json:"user_id,omitempty"`
-	ForceFresh  bool              `json:"force_fresh,omitempty"`
-	Fields      []string          `json:"fields,omitempty"`
-	UserContext *UserContext       `json:"user_context,omitempty"`
-	RequestID   string            `json:"request_id,omitempty"`
+	Query       string         `json:"query"`
+	Filters     map[string]any `json:"filters,omitempty"`
+	Page        int            `json:"page"`
+	PageSize    int            `json:"page_size"`
+	Sort        string         `json:"sort,omitempty"`
+	Region      string         `json:"region,omitempty"`
+	UserID      string         `json:"user_id,omitempty"`
+	ForceFresh  bool           `json:"force_fresh,omitempty"`
+	Fields      []string       `json:"fields,omitempty"`
+	UserContext *UserContext   `json:"user_context,omitempty"`
+	RequestID   string         `json:"request_id,omitempty"`
+	Explain     bool           `json:"explain,omitempty"`
+	Profile     bool           `json:"profile,omitempty"`
 }
 
 type UserContext struct {
@@ -48,28 +50,31 @@ type UserContext struct {
 }
 
 type SearchResponse struct {
-	Results    []SearchResult    `json:"results"`
-	Total      int64             `json:"total"`
-	Page       int               `json:"page"`
-	PageSize   int               `json:"page_size"`
-	TookMs     int64             `json:"took_ms"`
-	Source     string            `json:"source"`
-	Facets     map[string][]Facet `json:"facets,omitempty"`
-	Metadata   ResponseMetadata  `json:"metadata"`
+	Results  []SearchResult     `json:"results"`
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+	TookMs   int64              `json:"took_ms"`
+	Source   string             `json:"source"`
+	Facets   map[string][]Facet `json:"facets,omitempty"`
+	Metadata ResponseMetadata   `json:"metadata"`
+	Explain  *ExplainInfo       `json:"explain,omitempty"`
+	Profile  *QueryProfile      `json:"profile,omitempty"`
 }
 
 type SearchResult struct {
-	ID              string         `json:"id"`
-	Score           float64        `json:"score"`
-	Title           string         `json:"title,omitempty"`
-	Description     string         `json:"description,omitempty"`
-	Category        string         `json:"category,omitempty"`
-	Tags            []string       `json:"tags,omitempty"`
-	Region          string         `json:"region,omitempty"`
-	CreatedAt       time.Time      `json:"created_at,omitempty"`
-	PopularityScore float64        `json:"popularity_score,omitempty"`
+	ID              string              `json:"id"`
+	Score           float64             `json:"score"`
+	Title           string              `json:"title,omitempty"`
+	Description     string              `json:"description,omitempty"`
+	Category        string              `json:"category,omitempty"`
+	Tags            []string            `json:"tags,omitempty"`
+	Region          string              `json:"region,omitempty"`
+	CreatedAt       time.Time           `json:"created_at,omitempty"`
+	PopularityScore float64             `json:"popularity_score,omitempty"`
 	Highlights      map[string][]string `json:"highlights,omitempty"`
-	Fields          map[string]any `json:"fields,omitempty"`
+	Fields          map[string]any      `json:"fields,omitempty"`
+	Explanation     map[string]any      `json:"explanation,omitempty"`
 }
 
 type Facet struct {
@@ -86,17 +91,67 @@ type ResponseMetadata struct {
 	ShardsHit    int    `json:"shards_hit,omitempty"`
 	TimedOut     bool   `json:"timed_out"`
 	SpellCorrect string `json:"spell_correct,omitempty"`
+	// CachedAt is when the response was computed and cached; AgeMs is how old
+	// it was when served. Both are unset for responses computed for the request.
+	CachedAt *time.Time `json:"cached_at,omitempty"`
+	AgeMs    int64      `json:"age_ms,omitempty"`
+	// Fallback lists the strategies tried, in order, when the response was
+	// not served by the first strategy of its fallback chain.
+	Fallback []FallbackAttempt `json:"fallback,omitempty"`
+}
+
+// FallbackAttempt is one strategy tried for a search. Status is "served",
+// "miss" (answered without results), "failed", "timeout", "unavailable" (not
+// configured) or "skipped" (no latency budget left).
+type FallbackAttempt struct {
+	Strategy string `json:"strategy"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	TookMs   int64  `json:"took_ms"`
+}
+
+// ExplainInfo describes how a search was executed. It is only populated for
+// internal callers that set SearchRequest.Explain.
+type ExplainInfo struct {
+	Parsed        *ParsedQuery   `json:"parsed"`
+	Intent        string         `json:"intent"`
+	ESQuery       map[string]any `json:"es_query,omitempty"`
+	Index         string         `json:"index,omitempty"`
+	FallbackLevel string         `json:"fallback_level"`
+}
+
+// QueryProfile captures per-backend execution detail for a profiled search.
+type QueryProfile struct {
+	ESShards  []ShardProfile `json:"es_shards,omitempty"`
+	CHQueries []CHQueryStats `json:"ch_queries,omitempty"`
+}
+
+// ShardProfile is the timing breakdown reported by the ES profile API for one shard.
+type ShardProfile struct {
+	ShardID        string `json:"shard_id"`
+	QueryNanos     int64  `json:"query_nanos"`
+	RewriteNanos   int64  `json:"rewrite_nanos"`
+	CollectorNanos int64  `json:"collector_nanos"`
+}
+
+// CHQueryStats holds the read volume of a ClickHouse query from system.query_log.
+type CHQueryStats struct {
+	QueryID    string `json:"query_id"`
+	QueryType  string `json:"query_type"`
+	ReadRows   uint64 `json:"read_rows"`
+	ReadBytes  uint64 `json:"read_bytes"`
+	DurationMs uint64 `json:"duration_ms"`
 }
 
 type ParsedQuery struct {
-	Original     string
-	Normalized   string
-	Tokens       []string
-	SpellCorrected string
-	HasWildcard  bool
-	HasQuotes    bool
-	IsPhrase     bool
-	Fields       map[string]string
+	Original       string            `json:"original"`
+	Normalized     string            `json:"normalized"`
+	Tokens         []string          `json:"tokens"`
+	SpellCorrected string            `json:"spell_corrected,omitempty"`
+	HasWildcard    bool              `json:"has_wildcard"`
+	HasQuotes      bool              `json:"has_quotes"`
+	IsPhrase       bool              `json:"is_phrase"`
+	Fields         map[string]string `json:"fields,omitempty"`
 }
 
 type ChangeEvent struct {
@@ -110,12 +165,16 @@ type ChangeEvent struct {
 }
 
 type IndexAction struct {
-	Action    string         `json:"action"` // index, delete
-	Index     string         `json:"index"`
-	ID        string         `json:"id"`
-	Routing   string         `json:"routing,omitempty"`
-	Body      map[string]any `json:"body,omitempty"`
-	Timestamp time.Time      `json:"timestamp"`
+	Action  string         `json:"action"` // index, delete
+	Index   string         `json:"index"`
+	ID      string         `json:"id"`
+	Routing string         `json:"routing,omitempty"`
+	Body    map[string]any `json:"body,omitempty"`
+	// Version is the source document version, used as the Elasticsearch
+	// external version so an older change never overwrites a newer one.
+	// Zero means unversioned.
+	Version   int64     `json:"version,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 type AnalyticsEvent struct {
@@ -37,6 +37,80 @@ var (
 		},
 	)
 
+	CacheTierHits = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_cache_hits_total",
+			Help: "Total number of search cache hits by tier (l1=in-process, l2=redis)",
+		},
+		[]string{"tier"},
+	)
+
+	CacheTierMisses = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_cache_misses_total",
+			Help: "Total number of search cache misses by tier (l1=in-process, l2=redis)",
+		},
+		[]string{"tier"},
+	)
+
+	CoalescedRequests = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "search_coalesced_requests_total",
+			Help: "Total number of searches served by another request's in-flight backend search",
+		},
+	)
+
+	CacheRevalidations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_cache_revalidations_total",
+			Help: "Total number of background cache refreshes by trigger (stale, xfetch)",
+		},
+		[]string{"trigger"},
+	)
+
+	CacheTagEvictions = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "redis_cache_tag_evictions_total",
+			Help: "Total number of search cache entries evicted by document change tags",
+		},
+	)
+
+	CachePurgedKeys = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "redis_cache_purged_keys_total",
+			Help: "Total number of cache keys deleted by admin purges",
+		},
+	)
+
+	CacheWarmQueries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_cache_warm_queries_total",
+			Help: "Total number of queries replayed by the cache warmer by outcome (warmed, cached, error)",
+		},
+		[]string{"status"},
+	)
+
+	CacheWarmRunning = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "search_cache_warm_running",
+			Help: "Whether a cache warming run is in progress (0 or 1)",
+		},
+	)
+
+	CacheWarmLastCompleted = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "search_cache_warm_last_completed_timestamp_seconds",
+			Help: "Unix time the last cache warming run completed",
+		},
+	)
+
+	QueryLogDropped = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "search_query_log_dropped_total",
+			Help: "Total number of served searches not written to the ClickHouse query log",
+		},
+	)
+
 	ESQueryDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "es_query_duration_seconds",
@@ -62,6 +136,13 @@ var (
 		},
 	)
 
+	IndexingBufferedActions = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "indexing_buffered_actions",
+			Help: "Index actions buffered awaiting a bulk flush",
+		},
+	)
+
 	IndexingEventsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "indexing_events_total",
@@ -70,6 +151,38 @@ var (
 		[]string{"operation", "status"},
 	)
 
+	IndexingPipelineDocuments = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "indexing_pipeline_documents_total",
+			Help: "Documents changed, dropped or failed by an indexing pipeline stage",
+		},
+		[]string{"collection", "stage", "outcome"},
+	)
+
+	DLQReplayedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "indexing_dlq_replayed_total",
+			Help: "Total number of DLQ messages replayed by target and status",
+		},
+		[]string{"target", "status"},
+	)
+
+	ESBulkItemsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "es_bulk_items_total",
+			Help: "Total number of Elasticsearch bulk items by response status code",
+		},
+		[]string{"status"},
+	)
+
+	ESSchemaDrift = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "es_schema_drift",
+			Help: "Differences between the expected and live Elasticsearch schema by resource kind",
+		},
+		[]string{"resource"},
+	)
+
 	CircuitBreakerState = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "circuit_breaker_state",
@@ -94,6 +207,53 @@ var (
 		[]string{"level"},
 	)
 
+	FallbackStrategyAttempts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_fallback_strategy_attempts_total",
+			Help: "Fallback chain strategy attempts by strategy and outcome",
+		},
+		[]string{"strategy", "status"},
+	)
+
+	FallbackBudgetExhausted = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_fallback_budget_exhausted_total",
+			Help: "Fallback levels skipped or cut short because the latency budget ran out",
+		},
+		[]string{"level"},
+	)
+
+	ESHedgedRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "es_hedged_requests_total",
+			Help: "Hedged Elasticsearch searches sent, and those whose hedge answered first",
+		},
+		[]string{"outcome"},
+	)
+
+	StaticFallbackRefreshes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_static_fallback_refreshes_total",
+			Help: "Total number of static fallback list refreshes by outcome (success, empty, error)",
+		},
+		[]string{"status"},
+	)
+
+	ExportRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "search_export_requests_total",
+			Help: "Total number of export requests by outcome",
+		},
+		[]string{"status"},
+	)
+
+	ExportDocsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "search_export_docs_total",
+			Help: "Total number of documents streamed by exports",
+		},
+	)
+
 	ActiveConnections = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "active_connections",
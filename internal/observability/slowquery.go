@@ -21,6 +21,12 @@ type AnalyticsWriter interface {
 	WriteQueryPerformance(ctx context.Context, event *models.AnalyticsEvent) error
 }
 
+// ProfileWriter is optionally implemented by an AnalyticsWriter to persist
+// execution profiles of critical slow queries next to their performance row.
+type ProfileWriter interface {
+	WriteQueryProfile(ctx context.Context, event *models.AnalyticsEvent, profile *models.QueryProfile) error
+}
+
 func NewSlowQueryDetector(warningMs, criticalMs time.Duration, logger *zap.Logger, aw AnalyticsWriter) *SlowQueryDetector {
 	return &SlowQueryDetector{
 		warningThreshold:  warningMs,
@@ -31,6 +37,12 @@ func NewSlowQueryDetector(warningMs, criticalMs time.Duration, logger *zap.Logge
 }
 
 func (sqd *SlowQueryDetector) Intercept(ctx context.Context, query string, queryType string, duration time.Duration, totalHits int64, shardsHit int, timedOut bool) {
+	sqd.InterceptWithProfile(ctx, query, queryType, duration, totalHits, shardsHit, timedOut, nil)
+}
+
+// InterceptWithProfile behaves like Intercept and additionally stores profile
+// for critical-severity queries when the analytics writer supports it.
+func (sqd *SlowQueryDetector) InterceptWithProfile(ctx context.Context, query string, queryType string, duration time.Duration, totalHits int64, shardsHit int, timedOut bool, profile *models.QueryProfile) {
 	// Only log and write analytics for queries that exceed the warning threshold.
 	// Fast queries (~99% of traffic) return immediately with zero overhead.
 	if duration <= sqd.warningThreshold {
@@ -66,6 +78,8 @@ func (sqd *SlowQueryDetector) Intercept(ctx context.Context, query string, query
 			Timestamp:  time.Now().UTC(),
 			TraceID:    traceID,
 		}
+		pw, _ := sqd.analyticsWriter.(ProfileWriter)
+		storeProfile := pw != nil && profile != nil && severity == "critical"
 		go func() {
 			writeCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 			defer cancel()
@@ -75,6 +89,14 @@ func (sqd *SlowQueryDetector) Intercept(ctx context.Context, query string, query
 					zap.Error(err),
 				)
 			}
+			if storeProfile {
+				if err := pw.WriteQueryProfile(writeCtx, event, profile); err != nil {
+					sqd.logger.Error("failed to write query profile",
+						zap.String("trace_id", traceID),
+						zap.Error(err),
+					)
+				}
+			}
 		}()
 	}
 }
@@ -31,6 +31,26 @@ func (m *mockAnalyticsWriter) getEvents() []*models.AnalyticsEvent {
 	return cp
 }
 
+type mockProfileWriter struct {
+	mockAnalyticsWriter
+	profiles []*models.QueryProfile
+}
+
+func (m *mockProfileWriter) WriteQueryProfile(ctx context.Context, event *models.AnalyticsEvent, profile *models.QueryProfile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.profiles = append(m.profiles, profile)
+	return nil
+}
+
+func (m *mockProfileWriter) getProfiles() []*models.QueryProfile {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := make([]*models.QueryProfile, len(m.profiles))
+	copy(cp, m.profiles)
+	return cp
+}
+
 func TestSlowQueryDetector_ClassifySeverity(t *testing.T) {
 	sqd := &SlowQueryDetector{
 		warningThreshold:  200 * time.Millisecond,
@@ -201,3 +221,58 @@ func TestHashUint64(t *testing.T) {
 		t.Errorf("expected 0 for empty string, got %d", h4)
 	}
 }
+
+func TestSlowQueryDetector_InterceptWithProfile_CriticalStoresProfile(t *testing.T) {
+	pw := &mockProfileWriter{}
+	sqd := NewSlowQueryDetector(200*time.Millisecond, 500*time.Millisecond, zap.NewNop(), pw)
+
+	profile := &models.QueryProfile{
+		ESShards: []models.ShardProfile{{ShardID: "[n1][search-a][0]", QueryNanos: 1200}},
+	}
+	sqd.InterceptWithProfile(context.Background(), "critical query", "fulltext",
+		700*time.Millisecond, 10, 1, false, profile)
+
+	time.Sleep(100 * time.Millisecond)
+
+	if len(pw.getEvents()) != 1 {
+		t.Fatalf("expected 1 analytics event, got %d", len(pw.getEvents()))
+	}
+	profiles := pw.getProfiles()
+	if len(profiles) != 1 {
+		t.Fatalf("expected 1 stored profile, got %d", len(profiles))
+	}
+	if profiles[0] != profile {
+		t.Error("expected the provided profile to be stored")
+	}
+}
+
+func TestSlowQueryDetector_InterceptWithProfile_WarningSkipsProfile(t *testing.T) {
+	pw := &mockProfileWriter{}
+	sqd := NewSlowQueryDetector(200*time.Millisecond, 500*time.Millisecond, zap.NewNop(), pw)
+
+	sqd.InterceptWithProfile(context.Background(), "slow query", "fulltext",
+		300*time.Millisecond, 10, 1, false, &models.QueryProfile{})
+
+	time.Sleep(100 * time.Millisecond)
+
+	if len(pw.getEvents()) != 1 {
+		t.Fatalf("expected 1 analytics event, got %d", len(pw.getEvents()))
+	}
+	if len(pw.getProfiles()) != 0 {
+		t.Errorf("expected no stored profile for warning severity, got %d", len(pw.getProfiles()))
+	}
+}
+
+func TestSlowQueryDetector_InterceptWithProfile_NilProfile(t *testing.T) {
+	pw := &mockProfileWriter{}
+	sqd := NewSlowQueryDetector(200*time.Millisecond, 500*time.Millisecond, zap.NewNop(), pw)
+
+	sqd.InterceptWithProfile(context.Background(), "critical query", "fulltext",
+		700*time.Millisecond, 10, 1, false, nil)
+
+	time.Sleep(100 * time.Millisecond)
+
+	if len(pw.getProfiles()) != 0 {
+		t.Errorf("expected no stored profile without a profile, got %d", len(pw.getProfiles()))
+	}
+}
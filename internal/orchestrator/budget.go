@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// minLevelBudget is the least time worth giving a fallback level. With less
+// left the level is skipped rather than started only to time out.
+const minLevelBudget = 5 * time.Millisecond
+
+// errBudgetExhausted is recorded for strategies skipped because the deadline
+// had passed.
+var errBudgetExhausted = errors.New("latency budget exhausted")
+
+// latencyBudget splits the time left before a search's deadline between the
+// fallback levels, so a slow level cannot consume the time the next one needs
+// to answer within the SLA.
+type latencyBudget struct {
+	deadline time.Time
+}
+
+// newLatencyBudget starts a budget of total, cut short by ctx's deadline. A
+// zero total leaves only ctx's deadline, and no deadline at all makes every
+// allotment fall back to its cap.
+func newLatencyBudget(ctx context.Context, total time.Duration) *latencyBudget {
+	b := &latencyBudget{}
+	if total > 0 {
+		b.deadline = time.Now().Add(total)
+	}
+	if d, ok := ctx.Deadline(); ok && (b.deadline.IsZero() || d.Before(b.deadline)) {
+		b.deadline = d
+	}
+	return b
+}
+
+// remaining is the time left before the deadline. It reports false when
+// there is no deadline.
+func (b *latencyBudget) remaining() (time.Duration, bool) {
+	if b.deadline.IsZero() {
+		return 0, false
+	}
+	return time.Until(b.deadline), true
+}
+
+// allot returns the time a level may use: share of what remains, at most
+// limit (0 means no limit). It reports false when the budget is spent.
+func (b *latencyBudget) allot(share float64, limit time.Duration) (time.Duration, bool) {
+	left, ok := b.remaining()
+	if !ok {
+		return limit, true
+	}
+	d := time.Duration(float64(left) * share)
+	if limit > 0 && d > limit {
+		d = limit
+	}
+	if d < minLevelBudget {
+		return 0, false
+	}
+	return d, true
+}
+
+// withLevel bounds ctx to a level's allotment. A zero allotment leaves ctx
+// unbounded.
+func withLevel(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// budgetExhausted records that level was skipped for lack of time.
+func budgetExhausted(level string) {
+	observability.FallbackBudgetExhausted.WithLabelValues(level).Inc()
+}
+
+type staleResult struct {
+	resp *models.SearchResponse
+	err  error
+}
+
+// lookupStaleAsync reads the stale cache entry for req in the background so
+// it is ready if the primary search fails. The channel receives exactly one
+// result.
+func (o *Orchestrator) lookupStaleAsync(ctx context.Context, req *models.SearchRequest) <-chan staleResult {
+	ch := make(chan staleResult, 1)
+	go func() {
+		resp, err := o.cache.GetStaleResults(ctx, req)
+		ch <- staleResult{resp: resp, err: err}
+	}()
+	return ch
+}
+
+// awaitStale waits for the background stale lookup until ctx is done.
+func awaitStale(ctx context.Context, stale <-chan staleResult) (*models.SearchResponse, error) {
+	select {
+	case r := <-stale:
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,118 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+func TestLatencyBudget_Allot(t *testing.T) {
+	b := newLatencyBudget(context.Background(), 100*time.Millisecond)
+
+	d, ok := b.allot(0.5, 0)
+	if !ok || d <= 40*time.Millisecond || d > 50*time.Millisecond {
+		t.Errorf("expected about half of 100ms, got %v (ok=%v)", d, ok)
+	}
+	d, ok = b.allot(1, 20*time.Millisecond)
+	if !ok || d != 20*time.Millisecond {
+		t.Errorf("expected allotment capped at 20ms, got %v (ok=%v)", d, ok)
+	}
+}
+
+func TestLatencyBudget_ContextDeadlineWins(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	b := newLatencyBudget(ctx, time.Second)
+	if left, ok := b.remaining(); !ok || left > 10*time.Millisecond {
+		t.Errorf("expected the context deadline to bound the budget, got %v left", left)
+	}
+}
+
+func TestLatencyBudget_Exhausted(t *testing.T) {
+	b := newLatencyBudget(context.Background(), time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	if _, ok := b.allot(1, 0); ok {
+		t.Error("expected a spent budget to refuse allotments")
+	}
+}
+
+func TestLatencyBudget_NoDeadline(t *testing.T) {
+	b := newLatencyBudget(context.Background(), 0)
+
+	d, ok := b.allot(0.5, 200*time.Millisecond)
+	if !ok || d != 200*time.Millisecond {
+		t.Errorf("expected the cap without a deadline, got %v (ok=%v)", d, ok)
+	}
+}
+
+func TestAwaitStale(t *testing.T) {
+	ready := make(chan staleResult, 1)
+	ready <- staleResult{resp: &models.SearchResponse{Total: 3}}
+	if resp, err := awaitStale(context.Background(), ready); err != nil || resp == nil || resp.Total != 3 {
+		t.Errorf("expected the stale response, got %+v (err=%v)", resp, err)
+	}
+
+	failed := make(chan staleResult, 1)
+	failed <- staleResult{err: errors.New("redis down")}
+	if _, err := awaitStale(context.Background(), failed); err == nil {
+		t.Error("expected the lookup error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := awaitStale(ctx, make(chan staleResult)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded for a slow lookup, got %v", err)
+	}
+}
+
+func TestSearchWithFallback_BudgetExhaustedServesStatic(t *testing.T) {
+	o := &Orchestrator{
+		parser:         NewQueryParser(),
+		logger:         zap.NewNop(),
+		staticFallback: make(map[string][]models.SearchResult),
+	}
+	o.cfg.LatencyBudget.Enabled = true
+	o.SetStaticFallback("us", []models.SearchResult{{ID: "popular-1"}})
+
+	// With the deadline already passed neither Elasticsearch nor the cache is
+	// touched; the in-memory static list still answers.
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	req := &models.SearchRequest{Query: "shoes", Region: "us", PageSize: 10}
+	resp, err := o.searchWithFallback(ctx, req, o.parser.Parse(req.Query), models.IntentFullText)
+	if err != nil {
+		t.Fatalf("expected static fallback, got error %v", err)
+	}
+	if resp.Source != "static_fallback" {
+		t.Errorf("expected static_fallback source, got %q", resp.Source)
+	}
+}
+
+func TestFlightTimeout_FollowsSearchTimeouts(t *testing.T) {
+	o := newTestFallbackOrchestrator()
+	o.cfg.QueryTimeout = 100 * time.Millisecond
+	o.cfg.Coalescing.LockTTL = time.Minute
+	o.chains = map[string][]fallbackStep{
+		"default": {{timeout: 150 * time.Millisecond}, {}},
+	}
+
+	// Without a budget each step may use its timeout, the query timeout
+	// when it has none; the lock TTL plays no part.
+	if got := o.flightTimeout(models.IntentFullText); got != 250*time.Millisecond {
+		t.Errorf("expected the chain's timeouts, got %v", got)
+	}
+
+	o.cfg.LatencyBudget = config.LatencyBudgetConfig{Enabled: true, Total: 300 * time.Millisecond}
+	if got := o.flightTimeout(models.IntentFullText); got != 300*time.Millisecond {
+		t.Errorf("expected the latency budget, got %v", got)
+	}
+}
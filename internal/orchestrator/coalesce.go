@@ -0,0 +1,138 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+const (
+	// lockPollInterval is how often a pod that lost the refresh lock checks
+	// whether the winner has populated the cache.
+	lockPollInterval = 20 * time.Millisecond
+	// defaultFlightTimeout bounds shared searches when no query timeout is
+	// set.
+	defaultFlightTimeout = 2 * time.Second
+)
+
+// coalescedSearch runs the backend search for req at most once per cache key
+// on this pod: concurrent misses for the same key wait for the in-flight
+// search and share its response. The shared search is detached from any one
+// caller's cancellation so a disconnecting leader cannot fail the others.
+func (o *Orchestrator) coalescedSearch(ctx context.Context, req *models.SearchRequest, parsed *models.ParsedQuery, intent models.Intent, start time.Time) (*models.SearchResponse, error) {
+	key := o.cache.SearchKey(req)
+
+	leader := false
+	ch := o.flight.DoChan(key, func() (any, error) {
+		leader = true
+		flightCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), o.flightTimeout(intent))
+		defer cancel()
+		return o.refreshSearch(flightCtx, key, req, parsed, intent, start)
+	})
+
+	var res singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res = <-ch:
+	}
+
+	if !leader {
+		observability.CoalescedRequests.Inc()
+		if res.Err != nil {
+			o.recordFailure(intent, start)
+			return nil, res.Err
+		}
+		observability.SearchRequestsTotal.WithLabelValues(intent.String(), "coalesced").Inc()
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	// Every caller gets its own copy to stamp with its request details.
+	resp := *res.Val.(*models.SearchResponse)
+	resp.Metadata.RequestID = req.RequestID
+	resp.TookMs = time.Since(start).Milliseconds()
+	return &resp, nil
+}
+
+// refreshSearch executes and caches a search on behalf of every caller
+// coalesced onto key. With the distributed lock enabled, only the pod holding
+// the lock queries the backends; the others wait briefly for its result to
+// appear in the cache and fall through to their own search if it does not.
+func (o *Orchestrator) refreshSearch(ctx context.Context, key string, req *models.SearchRequest, parsed *models.ParsedQuery, intent models.Intent, start time.Time) (*models.SearchResponse, error) {
+	if o.cfg.Coalescing.DistributedLock && !req.ForceFresh {
+		release, acquired, err := o.cache.TryLock(ctx, "lock:"+key, o.cfg.Coalescing.LockTTL)
+		switch {
+		case err != nil:
+			o.logger.Warn("refresh lock unavailable, searching without it", zap.Error(err))
+		case acquired:
+			defer release()
+		default:
+			if resp := o.awaitPeerRefresh(ctx, req, intent, start); resp != nil {
+				return resp, nil
+			}
+		}
+	}
+
+	resp, err := o.searchWithFallback(ctx, req, parsed, intent)
+	if err != nil {
+		o.recordFailure(intent, start)
+		return nil, err
+	}
+	o.completeSearch(ctx, req, intent, resp, start)
+	return resp, nil
+}
+
+// awaitPeerRefresh polls the cache for up to LockWait while another pod
+// refreshes req, returning nil if the result does not arrive in time.
+func (o *Orchestrator) awaitPeerRefresh(ctx context.Context, req *models.SearchRequest, intent models.Intent, start time.Time) *models.SearchResponse {
+	deadline := time.NewTimer(o.cfg.Coalescing.LockWait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-deadline.C:
+			return nil
+		case <-ticker.C:
+			if resp := o.cachedResponse(ctx, req, intent, start); resp != nil {
+				return resp
+			}
+		}
+	}
+}
+
+// flightTimeout bounds a shared or background search, which no longer follows
+// the cancellation of the request that started it, by what the search itself
+// may take: the latency budget when enabled, and otherwise the query timeout
+// of each step of intent's fallback chain.
+func (o *Orchestrator) flightTimeout(intent models.Intent) time.Duration {
+	if o.cfg.LatencyBudget.Enabled && o.cfg.LatencyBudget.Total > 0 {
+		return o.cfg.LatencyBudget.Total
+	}
+	perStep := o.cfg.QueryTimeout
+	if perStep <= 0 {
+		perStep = defaultFlightTimeout
+	}
+	var total time.Duration
+	for _, step := range o.fallbackChain(intent) {
+		if step.timeout > 0 {
+			total += step.timeout
+		} else {
+			total += perStep
+		}
+	}
+	if total == 0 {
+		return perStep
+	}
+	return total
+}
@@ -0,0 +1,163 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+var (
+	// ErrExportUnavailable is returned when exports cannot be served because
+	// Elasticsearch is not configured.
+	ErrExportUnavailable = errors.New("export unavailable: elasticsearch not configured")
+	// ErrInvalidCursor is returned for cursor tokens that cannot be decoded.
+	ErrInvalidCursor = errors.New("invalid export cursor")
+	// ErrCursorExpired is returned when the cursor's point in time has been
+	// released, either by keep_alive expiry or a cluster restart.
+	ErrCursorExpired = errors.New("export cursor expired")
+)
+
+// ExportCursor identifies an export and its position. A fresh export has an
+// empty PITID; the cursor returned by Export carries the original query so a
+// resumed request reads the same snapshot with the same filters.
+type ExportCursor struct {
+	PITID       string         `json:"pit,omitempty"`
+	SearchAfter []any          `json:"after,omitempty"`
+	Query       string         `json:"q"`
+	Filters     map[string]any `json:"filters,omitempty"`
+	Region      string         `json:"region,omitempty"`
+	Fields      []string       `json:"fields,omitempty"`
+}
+
+// EncodeExportCursor serializes a cursor into an opaque URL-safe token.
+func EncodeExportCursor(c *ExportCursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("marshaling export cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeExportCursor parses a token produced by EncodeExportCursor.
+func DecodeExportCursor(token string) (*ExportCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	// Sort values are longs; keep them as json.Number so they are sent back
+	// to ES without float rounding.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var c ExportCursor
+	if err := dec.Decode(&c); err != nil || c.PITID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// ExportEmitter delivers one page of exported documents. Returning an error
+// (e.g. the client went away) aborts the export.
+type ExportEmitter func(hits []elasticsearch.ExportHit) error
+
+// Export walks every document matching the cursor's query using a point in
+// time and search_after, emitting one page at a time. It stops after
+// MaxDocsPerRequest documents or Timeout and returns the cursor to resume
+// from; a nil cursor means the export is complete and the PIT was released.
+// On error the returned cursor, if non-nil, points just after the last page
+// that was emitted successfully.
+func (o *Orchestrator) Export(ctx context.Context, cursor *ExportCursor, emit ExportEmitter) (*ExportCursor, error) {
+	if o.esClient == nil {
+		return nil, ErrExportUnavailable
+	}
+
+	ctx, span := observability.StartSpan(ctx, "orchestrator.export",
+		attribute.String("query", cursor.Query),
+		attribute.Bool("resumed", cursor.PITID != ""),
+	)
+	defer span.End()
+
+	expCfg := o.cfg.Export
+	deadline := time.Now().Add(expCfg.Timeout)
+	keepAlive := fmt.Sprintf("%dms", expCfg.KeepAlive.Milliseconds())
+
+	req := &models.SearchRequest{
+		Query:   cursor.Query,
+		Filters: cursor.Filters,
+		Region:  cursor.Region,
+		Fields:  cursor.Fields,
+	}
+	parsed := o.parser.Parse(req.Query)
+
+	next := *cursor
+	if next.PITID == "" {
+		pitID, err := o.esClient.OpenPointInTime(ctx, o.searchIndex(o.esClient, req), expCfg.KeepAlive)
+		if err != nil {
+			observability.ExportRequestsTotal.WithLabelValues("error").Inc()
+			return nil, fmt.Errorf("starting export: %w", err)
+		}
+		next.PITID = pitID
+	}
+
+	exported := 0
+	for {
+		remaining := expCfg.MaxDocsPerRequest - exported
+		if remaining <= 0 || time.Now().After(deadline) {
+			observability.ExportRequestsTotal.WithLabelValues("partial").Inc()
+			return &next, nil
+		}
+		size := min(expCfg.PageSize, remaining)
+
+		query := o.queryBuilder(o.esClient).BuildExportQuery(parsed, req, next.PITID, keepAlive, next.SearchAfter, size)
+		page, err := o.esClient.SearchAfter(ctx, query)
+		if err != nil {
+			if errors.Is(err, elasticsearch.ErrPointInTimeExpired) {
+				observability.ExportRequestsTotal.WithLabelValues("expired").Inc()
+				return nil, ErrCursorExpired
+			}
+			observability.ExportRequestsTotal.WithLabelValues("error").Inc()
+			return &next, fmt.Errorf("reading export page: %w", err)
+		}
+		if page.PITID != "" {
+			next.PITID = page.PITID
+		}
+
+		if len(page.Hits) > 0 {
+			if err := emit(page.Hits); err != nil {
+				observability.ExportRequestsTotal.WithLabelValues("aborted").Inc()
+				return &next, err
+			}
+			exported += len(page.Hits)
+			observability.ExportDocsTotal.Add(float64(len(page.Hits)))
+			next.SearchAfter = page.Hits[len(page.Hits)-1].Sort
+		}
+
+		if len(page.Hits) < size {
+			o.closePointInTime(next.PITID)
+			observability.ExportRequestsTotal.WithLabelValues("complete").Inc()
+			return nil, nil
+		}
+	}
+}
+
+// closePointInTime releases a finished export's PIT. It must not use the
+// request context, which is typically cancelled as soon as the response ends.
+func (o *Orchestrator) closePointInTime(pitID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := o.esClient.ClosePointInTime(ctx, pitID); err != nil {
+		// Not fatal: the PIT is released when its keep_alive lapses.
+		o.logger.Warn("closing export point in time failed", zap.Error(err))
+	}
+}
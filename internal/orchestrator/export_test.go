@@ -0,0 +1,48 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestExportCursor_RoundTrip(t *testing.T) {
+	in := &ExportCursor{
+		PITID:       "pit-abc",
+		SearchAfter: []any{json.Number("9007199254740993")},
+		Query:       "laptop",
+		Filters:     map[string]any{"category": "electronics"},
+		Region:      "us-east",
+		Fields:      []string{"title", "tags"},
+	}
+
+	token, err := EncodeExportCursor(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := DecodeExportCursor(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if out.PITID != in.PITID || out.Query != in.Query || out.Region != in.Region {
+		t.Errorf("cursor mismatch: got %+v", out)
+	}
+	if len(out.Fields) != 2 || out.Filters["category"] != "electronics" {
+		t.Errorf("cursor mismatch: got %+v", out)
+	}
+	// Sort values above 2^53 must not lose precision.
+	if n, ok := out.SearchAfter[0].(json.Number); !ok || n.String() != "9007199254740993" {
+		t.Errorf("expected exact sort value, got %v", out.SearchAfter[0])
+	}
+}
+
+func TestDecodeExportCursor_Invalid(t *testing.T) {
+	missingPIT, _ := EncodeExportCursor(&ExportCursor{Query: "laptop"})
+
+	for _, token := range []string{"%%%", "bm90LWpzb24", missingPIT} {
+		if _, err := DecodeExportCursor(token); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("token %q: expected ErrInvalidCursor, got %v", token, err)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+var (
+	// ErrFallbackMiss is returned by a strategy that worked but had nothing
+	// to serve, such as a stale cache miss.
+	ErrFallbackMiss = errors.New("no results")
+
+	// ErrStrategyUnavailable is returned by a strategy whose backend is not
+	// configured.
+	ErrStrategyUnavailable = errors.New("strategy unavailable")
+)
+
+// FallbackRequest is the search a fallback strategy is asked to answer.
+type FallbackRequest struct {
+	Request *models.SearchRequest
+	Parsed  *models.ParsedQuery
+	Intent  models.Intent
+
+	// stale is the stale cache lookup started alongside the chain, if any.
+	stale <-chan staleResult
+}
+
+// FallbackStrategy is one way of answering a search. Strategies are tried in
+// the order of the intent's fallback chain until one serves a response.
+type FallbackStrategy interface {
+	Name() string
+	Search(ctx context.Context, req *FallbackRequest) (*models.SearchResponse, error)
+}
+
+// fallbackStep is a strategy with its place in a configured chain.
+type fallbackStep struct {
+	strategy    FallbackStrategy
+	timeout     time.Duration
+	budgetShare float64
+}
+
+// buildFallbackChains resolves the configured chains into strategies.
+func (o *Orchestrator) buildFallbackChains(cfg config.FallbackConfig) map[string][]fallbackStep {
+	chains := make(map[string][]fallbackStep, len(cfg.Chains))
+	for intent, steps := range cfg.Chains {
+		chains[intent] = o.buildFallbackChain(steps)
+	}
+	return chains
+}
+
+func (o *Orchestrator) buildFallbackChain(steps []config.FallbackStepConfig) []fallbackStep {
+	chain := make([]fallbackStep, 0, len(steps))
+	for _, s := range steps {
+		strategy := o.fallbackStrategy(s.Strategy)
+		if strategy == nil {
+			o.logger.Warn("unknown fallback strategy ignored", zap.String("strategy", s.Strategy))
+			continue
+		}
+		timeout := s.Timeout
+		if timeout == 0 && (s.Strategy == "primary" || s.Strategy == "replica") {
+			timeout = o.cfg.QueryTimeout
+		}
+		chain = append(chain, fallbackStep{strategy: strategy, timeout: timeout, budgetShare: s.BudgetShare})
+	}
+	return chain
+}
+
+func (o *Orchestrator) fallbackStrategy(name string) FallbackStrategy {
+	switch name {
+	case "primary":
+		return primaryStrategy{o}
+	case "replica":
+		return replicaStrategy{o}
+	case "stale_cache":
+		return staleCacheStrategy{o}
+	case "clickhouse":
+		return clickhouseStrategy{o}
+	case "static":
+		return staticStrategy{o}
+	default:
+		return nil
+	}
+}
+
+// fallbackChain returns the chain for intent. Orchestrators not built by New
+// use the default configuration's chain.
+func (o *Orchestrator) fallbackChain(intent models.Intent) []fallbackStep {
+	if chain, ok := o.chains[intent.String()]; ok {
+		return chain
+	}
+	if chain, ok := o.chains["default"]; ok {
+		return chain
+	}
+	return o.buildFallbackChain(config.DefaultConfig().Search.Fallback.Chains["default"])
+}
+
+// runFallbackChain tries each step of chain in turn. attempts holds steps
+// already tried by the caller and is extended with every step run here; it
+// is attached to the response when anything but the first step served it.
+func (o *Orchestrator) runFallbackChain(ctx context.Context, chain []fallbackStep, req *FallbackRequest, budget *latencyBudget, attempts []models.FallbackAttempt) (*models.SearchResponse, error) {
+	for _, step := range chain {
+		name := step.strategy.Name()
+		attempt := models.FallbackAttempt{Strategy: name}
+
+		timeout, ok := step.timeout, true
+		if step.budgetShare > 0 {
+			timeout, ok = o.levelTimeout(budget, step.budgetShare, step.timeout, name)
+		}
+		if !ok {
+			attempt.Status = "skipped"
+			attempt.Error = errBudgetExhausted.Error()
+			attempts = append(attempts, attempt)
+			observability.FallbackStrategyAttempts.WithLabelValues(name, attempt.Status).Inc()
+			continue
+		}
+
+		start := time.Now()
+		stepCtx, cancel := withLevel(ctx, timeout)
+		resp, err := step.strategy.Search(stepCtx, req)
+		cancel()
+		attempt.TookMs = time.Since(start).Milliseconds()
+
+		if err == nil && resp != nil {
+			attempt.Status = "served"
+			attempts = append(attempts, attempt)
+			observability.FallbackStrategyAttempts.WithLabelValues(name, attempt.Status).Inc()
+			if len(attempts) > 1 {
+				observability.FallbackCounter.WithLabelValues(name).Inc()
+				resp.Metadata.Fallback = attempts
+			}
+			return resp, nil
+		}
+
+		attempt.Status = fallbackStatus(err)
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		attempts = append(attempts, attempt)
+		observability.FallbackStrategyAttempts.WithLabelValues(name, attempt.Status).Inc()
+		if name == "primary" {
+			o.logger.Warn("primary search failed, trying fallback", zap.Error(err))
+			observability.FallbackCounter.WithLabelValues("primary_failed").Inc()
+		} else if attempt.Status != "miss" && attempt.Status != "unavailable" {
+			o.logger.Warn("fallback strategy failed",
+				zap.String("strategy", name),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil, fmt.Errorf("all search paths exhausted: %s", describeAttempts(attempts))
+}
+
+func fallbackStatus(err error) string {
+	switch {
+	case err == nil, errors.Is(err, ErrFallbackMiss):
+		return "miss"
+	case errors.Is(err, ErrStrategyUnavailable):
+		return "unavailable"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "failed"
+	}
+}
+
+// describeAttempts summarizes why each strategy failed, for the error
+// returned when the whole chain does.
+func describeAttempts(attempts []models.FallbackAttempt) string {
+	parts := make([]string, 0, len(attempts))
+	for _, a := range attempts {
+		reason := a.Status
+		if a.Error != "" {
+			reason = a.Error
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", a.Strategy, reason))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// primaryStrategy searches the primary Elasticsearch cluster, routed by
+// intent.
+type primaryStrategy struct{ o *Orchestrator }
+
+func (s primaryStrategy) Name() string { return "primary" }
+
+func (s primaryStrategy) Search(ctx context.Context, req *FallbackRequest) (*models.SearchResponse, error) {
+	return s.o.intentSearch(ctx, s.o.esClient, req.Request, req.Parsed, req.Intent)
+}
+
+// replicaStrategy runs the Elasticsearch search against the replica cluster.
+// Analytics searches, which the primary strategy serves from ClickHouse,
+// fall back to a full-text search there rather than repeating that query.
+type replicaStrategy struct{ o *Orchestrator }
+
+func (s replicaStrategy) Name() string { return "replica" }
+
+func (s replicaStrategy) Search(ctx context.Context, req *FallbackRequest) (*models.SearchResponse, error) {
+	if s.o.replicaClient == nil {
+		return nil, ErrStrategyUnavailable
+	}
+	var resp *models.SearchResponse
+	var err error
+	if req.Intent == models.IntentFaceted {
+		resp, err = s.o.facetedSearch(ctx, s.o.replicaClient, req.Request, req.Parsed)
+	} else {
+		resp, err = s.o.fullTextSearch(ctx, s.o.replicaClient, req.Request, req.Parsed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Source = "replica"
+	resp.Metadata.Source = "elasticsearch_replica"
+	return resp, nil
+}
+
+// staleCacheStrategy serves the long-lived stale copy of the response.
+type staleCacheStrategy struct{ o *Orchestrator }
+
+func (s staleCacheStrategy) Name() string { return "stale_cache" }
+
+func (s staleCacheStrategy) Search(ctx context.Context, req *FallbackRequest) (*models.SearchResponse, error) {
+	var stale *models.SearchResponse
+	var err error
+	switch {
+	case req.stale != nil:
+		stale, err = awaitStale(ctx, req.stale)
+	case s.o.cache != nil:
+		stale, err = s.o.cache.GetStaleResults(ctx, req.Request)
+	default:
+		return nil, ErrStrategyUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stale == nil {
+		return nil, ErrFallbackMiss
+	}
+
+	stale.Metadata.Stale = true
+	if stale.Metadata.CachedAt != nil {
+		stale.Metadata.AgeMs = time.Since(*stale.Metadata.CachedAt).Milliseconds()
+	}
+	stale.Source = "stale_cache"
+	stale.Metadata.Source = "stale_cache"
+	return stale, nil
+}
+
+// clickhouseStrategy runs a basic text search on ClickHouse. Faceted searches
+// also get their facet counts from it.
+type clickhouseStrategy struct{ o *Orchestrator }
+
+func (s clickhouseStrategy) Name() string { return "clickhouse" }
+
+func (s clickhouseStrategy) Search(ctx context.Context, req *FallbackRequest) (*models.SearchResponse, error) {
+	if s.o.chClient == nil {
+		return nil, ErrStrategyUnavailable
+	}
+
+	var facetsCh chan facetsResult
+	if req.Intent == models.IntentFaceted {
+		facetsCh = make(chan facetsResult, 1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					facetsCh <- facetsResult{err: fmt.Errorf("panic in CH facets: %v", r)}
+				}
+			}()
+			facetsCh <- s.o.queryFacets(ctx, req.Request)
+		}()
+	}
+
+	results, err := s.o.chClient.FallbackSearch(ctx, req.Parsed.Normalized, req.Request.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrFallbackMiss
+	}
+
+	resp := &models.SearchResponse{
+		Results: results,
+		Total:   int64(len(results)),
+		Source:  "degraded",
+		Metadata: models.ResponseMetadata{
+			Source: "degraded_clickhouse",
+		},
+	}
+	if facetsCh != nil {
+		if fr := <-facetsCh; fr.err != nil {
+			s.o.logger.Warn("facet counts from clickhouse failed", zap.Error(fr.err))
+		} else {
+			resp.Facets = fr.facets
+		}
+	}
+	return resp, nil
+}
+
+// staticStrategy serves the region's pre-loaded popular results from memory.
+type staticStrategy struct{ o *Orchestrator }
+
+func (s staticStrategy) Name() string { return "static" }
+
+func (s staticStrategy) Search(ctx context.Context, req *FallbackRequest) (*models.SearchResponse, error) {
+	results := s.o.getStaticFallback(req.Request.Region)
+	if len(results) == 0 {
+		return nil, ErrFallbackMiss
+	}
+	return &models.SearchResponse{
+		Results: results,
+		Total:   int64(len(results)),
+		Source:  "static_fallback",
+		Metadata: models.ResponseMetadata{
+			Source: "static_fallback",
+		},
+	}, nil
+}
@@ -0,0 +1,194 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+type fakeStrategy struct {
+	name  string
+	resp  *models.SearchResponse
+	err   error
+	delay time.Duration
+	calls int
+}
+
+func (f *fakeStrategy) Name() string { return f.name }
+
+func (f *fakeStrategy) Search(ctx context.Context, req *FallbackRequest) (*models.SearchResponse, error) {
+	f.calls++
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return f.resp, f.err
+}
+
+func newTestFallbackOrchestrator() *Orchestrator {
+	return &Orchestrator{
+		parser:         NewQueryParser(),
+		logger:         zap.NewNop(),
+		staticFallback: make(map[string][]models.SearchResult),
+	}
+}
+
+func TestRunFallbackChain_RecordsAttempts(t *testing.T) {
+	o := newTestFallbackOrchestrator()
+	primary := &fakeStrategy{name: "primary", err: errors.New("es down")}
+	stale := &fakeStrategy{name: "stale_cache", err: ErrFallbackMiss}
+	slow := &fakeStrategy{name: "clickhouse", delay: time.Second}
+	static := &fakeStrategy{name: "static", resp: &models.SearchResponse{Source: "static_fallback"}}
+	chain := []fallbackStep{
+		{strategy: primary},
+		{strategy: stale},
+		{strategy: slow, timeout: 5 * time.Millisecond},
+		{strategy: static},
+	}
+
+	resp, err := o.runFallbackChain(context.Background(), chain, &FallbackRequest{Request: &models.SearchRequest{}}, nil, nil)
+	if err != nil {
+		t.Fatalf("expected the static strategy to serve, got %v", err)
+	}
+
+	want := []struct{ strategy, status string }{
+		{"primary", "failed"},
+		{"stale_cache", "miss"},
+		{"clickhouse", "timeout"},
+		{"static", "served"},
+	}
+	if len(resp.Metadata.Fallback) != len(want) {
+		t.Fatalf("expected %d attempts, got %+v", len(want), resp.Metadata.Fallback)
+	}
+	for i, w := range want {
+		got := resp.Metadata.Fallback[i]
+		if got.Strategy != w.strategy || got.Status != w.status {
+			t.Errorf("attempt %d: expected %s/%s, got %s/%s", i, w.strategy, w.status, got.Strategy, got.Status)
+		}
+	}
+	if resp.Metadata.Fallback[0].Error != "es down" {
+		t.Errorf("expected the primary failure reason, got %q", resp.Metadata.Fallback[0].Error)
+	}
+}
+
+func TestRunFallbackChain_FirstStrategyServesWithoutAttempts(t *testing.T) {
+	o := newTestFallbackOrchestrator()
+	primary := &fakeStrategy{name: "primary", resp: &models.SearchResponse{Source: "primary"}}
+	static := &fakeStrategy{name: "static"}
+
+	resp, err := o.runFallbackChain(context.Background(), []fallbackStep{{strategy: primary}, {strategy: static}},
+		&FallbackRequest{Request: &models.SearchRequest{}}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Metadata.Fallback != nil {
+		t.Errorf("expected no fallback attempts, got %+v", resp.Metadata.Fallback)
+	}
+	if static.calls != 0 {
+		t.Errorf("expected later strategies not to run, got %d calls", static.calls)
+	}
+}
+
+func TestRunFallbackChain_AllFail(t *testing.T) {
+	o := newTestFallbackOrchestrator()
+	chain := []fallbackStep{
+		{strategy: &fakeStrategy{name: "primary", err: errors.New("es down")}},
+		{strategy: &fakeStrategy{name: "replica", err: ErrStrategyUnavailable}},
+	}
+
+	_, err := o.runFallbackChain(context.Background(), chain, &FallbackRequest{Request: &models.SearchRequest{}}, nil, nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"primary: es down", "replica: strategy unavailable"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err.Error())
+		}
+	}
+}
+
+func TestRunFallbackChain_BudgetSkipsSharedStrategies(t *testing.T) {
+	o := newTestFallbackOrchestrator()
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	budget := newLatencyBudget(ctx, 0)
+
+	budgeted := &fakeStrategy{name: "clickhouse", resp: &models.SearchResponse{}}
+	exempt := &fakeStrategy{name: "static", resp: &models.SearchResponse{Source: "static_fallback"}}
+	chain := []fallbackStep{
+		{strategy: budgeted, budgetShare: 1},
+		{strategy: exempt},
+	}
+
+	resp, err := o.runFallbackChain(context.Background(), chain, &FallbackRequest{Request: &models.SearchRequest{}}, budget, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if budgeted.calls != 0 {
+		t.Error("expected the budgeted strategy to be skipped")
+	}
+	if resp.Metadata.Fallback[0].Status != "skipped" {
+		t.Errorf("expected skipped status, got %+v", resp.Metadata.Fallback[0])
+	}
+}
+
+func TestFallbackChain_PerIntent(t *testing.T) {
+	o := newTestFallbackOrchestrator()
+	o.chains = o.buildFallbackChains(config.FallbackConfig{
+		Chains: map[string][]config.FallbackStepConfig{
+			"default":      {{Strategy: "primary"}, {Strategy: "stale_cache"}, {Strategy: "static"}},
+			"autocomplete": {{Strategy: "primary"}, {Strategy: "static"}},
+		},
+	})
+
+	names := func(chain []fallbackStep) string {
+		var parts []string
+		for _, s := range chain {
+			parts = append(parts, s.strategy.Name())
+		}
+		return strings.Join(parts, ",")
+	}
+	if got := names(o.fallbackChain(models.IntentAutocomplete)); got != "primary,static" {
+		t.Errorf("expected autocomplete chain, got %s", got)
+	}
+	if got := names(o.fallbackChain(models.IntentFaceted)); got != "primary,stale_cache,static" {
+		t.Errorf("expected default chain for faceted, got %s", got)
+	}
+}
+
+func TestDegradedSearch_SkipsPrimary(t *testing.T) {
+	o := newTestFallbackOrchestrator()
+	o.chains = o.buildFallbackChains(config.FallbackConfig{
+		Chains: map[string][]config.FallbackStepConfig{
+			"default": {{Strategy: "primary"}, {Strategy: "replica"}, {Strategy: "static"}},
+		},
+	})
+	o.SetStaticFallback("us", []models.SearchResult{{ID: "popular-1"}})
+
+	req := &models.SearchRequest{Query: "shoes", Region: "us"}
+	resp, err := o.degradedSearch(context.Background(), req, o.parser.Parse(req.Query), models.IntentFullText, errors.New("msearch failed"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"primary/failed", "replica/unavailable", "static/served"}
+	if len(resp.Metadata.Fallback) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, resp.Metadata.Fallback)
+	}
+	for i, w := range want {
+		got := resp.Metadata.Fallback[i].Strategy + "/" + resp.Metadata.Fallback[i].Status
+		if got != w {
+			t.Errorf("attempt %d: expected %s, got %s", i, w, got)
+		}
+	}
+}
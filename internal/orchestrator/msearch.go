@@ -0,0 +1,140 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/elasticsearch"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// MultiSearchResult is the outcome of one request in a MultiSearch batch.
+// Exactly one of Response and Err is set.
+type MultiSearchResult struct {
+	Response *models.SearchResponse
+	Err      error
+}
+
+// pendingSearch is a batch item that missed the cache and is waiting on ES.
+type pendingSearch struct {
+	idx    int
+	req    *models.SearchRequest
+	parsed *models.ParsedQuery
+	intent models.Intent
+	index  string
+	query  map[string]any
+}
+
+// MultiSearch executes a batch of independent searches. Cache hits are served
+// individually, full-text misses share a single ES _msearch round trip, and
+// searches routed to other backends (analytics, faceted) or carrying debug
+// options run through Search concurrently. A failure in one item never fails
+// the others.
+func (o *Orchestrator) MultiSearch(ctx context.Context, reqs []*models.SearchRequest) []MultiSearchResult {
+	start := time.Now()
+	ctx, span := observability.StartSpan(ctx, "orchestrator.msearch",
+		attribute.Int("batch_size", len(reqs)),
+	)
+	defer span.End()
+
+	results := make([]MultiSearchResult, len(reqs))
+	var pending []pendingSearch
+	var wg sync.WaitGroup
+
+	for i, req := range reqs {
+		o.normalizePageSize(req)
+		parsed := o.parser.Parse(req.Query)
+		intent := o.classifier.Classify(parsed)
+
+		batchable := o.esClient != nil && !req.Explain && !req.Profile &&
+			(intent == models.IntentFullText || intent == models.IntentAutocomplete)
+		if !batchable {
+			wg.Add(1)
+			go func(i int, req *models.SearchRequest) {
+				defer wg.Done()
+				defer func() {
+					if r := recover(); r != nil {
+						results[i] = MultiSearchResult{Err: fmt.Errorf("panic in search: %v", r)}
+					}
+				}()
+				resp, err := o.Search(ctx, req)
+				results[i] = MultiSearchResult{Response: resp, Err: err}
+			}(i, req)
+			continue
+		}
+
+		if !req.ForceFresh {
+			if cached := o.lookupCache(ctx, req, intent, start); cached != nil {
+				results[i] = MultiSearchResult{Response: cached}
+				continue
+			}
+		}
+
+		pending = append(pending, pendingSearch{
+			idx:    i,
+			req:    req,
+			parsed: parsed,
+			intent: intent,
+			index:  o.searchIndex(o.esClient, req),
+			query:  o.queryBuilder(o.esClient).BuildESQuery(parsed, req),
+		})
+	}
+
+	if len(pending) > 0 {
+		o.executeBatch(ctx, pending, results, start)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// executeBatch sends pending searches to ES as one _msearch and completes
+// each item, walking the degraded fallback levels for items that failed.
+func (o *Orchestrator) executeBatch(ctx context.Context, pending []pendingSearch, results []MultiSearchResult, start time.Time) {
+	esCtx, cancel := context.WithTimeout(ctx, o.cfg.QueryTimeout)
+	defer cancel()
+
+	items := make([]elasticsearch.MultiSearchItem, len(pending))
+	for i, p := range pending {
+		items[i] = elasticsearch.MultiSearchItem{Index: p.index, Query: p.query}
+	}
+
+	itemResults, batchErr := o.esClient.MultiSearch(esCtx, items)
+	if batchErr != nil {
+		o.logger.Warn("es msearch failed, falling back per item",
+			zap.Int("batch_size", len(items)),
+			zap.Error(batchErr),
+		)
+	}
+
+	for i, p := range pending {
+		var resp *models.SearchResponse
+		err := batchErr
+		if err == nil {
+			err = itemResults[i].Err
+		}
+
+		if err == nil {
+			resp = o.primaryResponse(esCtx, p.req, itemResults[i].Result, p.query, p.index)
+		} else {
+			o.logger.Warn("primary search failed, trying fallback", zap.Error(err))
+			observability.FallbackCounter.WithLabelValues("primary_failed").Inc()
+			resp, err = o.degradedSearch(ctx, p.req, p.parsed, p.intent, err)
+		}
+
+		if err != nil {
+			o.recordFailure(p.intent, start)
+			results[p.idx] = MultiSearchResult{Err: err}
+			continue
+		}
+
+		o.completeSearch(ctx, p.req, p.intent, resp, start)
+		results[p.idx] = MultiSearchResult{Response: resp}
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/shubhsaxena/high-scale-search/internal/cache"
 	"github.com/shubhsaxena/high-scale-search/internal/clickhouse"
@@ -35,6 +36,18 @@ type Orchestrator struct {
 	// Static fallback results by category
 	staticFallback map[string][]models.SearchResult
 	mu             sync.RWMutex
+
+	// Deduplicates concurrent backend searches for the same cache key
+	flight singleflight.Group
+
+	// Records served searches for cache warming; nil when disabled
+	queryLog *clickhouse.QueryLog
+
+	// Replica cluster for the replica fallback strategy; nil when disabled
+	replicaClient *elasticsearch.Client
+
+	// Fallback chains by intent name
+	chains map[string][]fallbackStep
 }
 
 func New(
@@ -47,7 +60,7 @@ func New(
 	esCfg config.ElasticsearchConfig,
 	logger *zap.Logger,
 ) *Orchestrator {
-	return &Orchestrator{
+	o := &Orchestrator{
 		esClient:       esClient,
 		chClient:       chClient,
 		fsClient:       fsClient,
@@ -61,6 +74,8 @@ func New(
 		logger:         logger,
 		staticFallback: make(map[string][]models.SearchResult),
 	}
+	o.chains = o.buildFallbackChains(cfg.Fallback)
+	return o
 }
 
 func (o *Orchestrator) Search(ctx context.Context, req *models.SearchRequest) (*models.SearchResponse, error) {
@@ -70,12 +85,10 @@ func (o *Orchestrator) Search(ctx context.Context, req *models.SearchRequest) (*
 	)
 	defer span.End()
 
-	// Normalize page size
-	if req.PageSize <= 0 {
-		req.PageSize = o.cfg.DefaultPageSize
-	}
-	if req.PageSize > o.cfg.MaxPageSize {
-		req.PageSize = o.cfg.MaxPageSize
+	o.normalizePageSize(req)
+
+	if o.queryLog != nil && shouldLogQuery(ctx, req) {
+		o.queryLog.Record(req)
 	}
 
 	// Step 1: Parse query
@@ -88,37 +101,123 @@ func (o *Orchestrator) Search(ctx context.Context, req *models.SearchRequest) (*
 		zap.String("intent", intent.String()),
 	)
 
-	// Step 3: Check cache
-	if !req.ForceFresh {
-		cached, err := o.cache.GetSearchResults(ctx, req)
-		if err != nil {
-			o.logger.Warn("cache lookup error", zap.Error(err))
-		}
-		if cached != nil {
-			cached.Metadata.CacheHit = true
-			cached.TookMs = time.Since(start).Milliseconds()
-			observability.SearchRequestsTotal.WithLabelValues(intent.String(), "cache_hit").Inc()
+	// Profiled searches tag every ClickHouse query so its read stats can be
+	// resolved from system.query_log afterwards.
+	var chTracker *clickhouse.QueryTracker
+	if req.Profile {
+		chTracker = clickhouse.NewQueryTracker()
+		ctx = clickhouse.WithQueryTracker(ctx, chTracker)
+	}
+
+	// Step 3: Check cache. Explain and profile requests always execute against
+	// the backends so the returned breakdown reflects the live query.
+	if !req.ForceFresh && !req.Explain && !req.Profile {
+		if cached := o.lookupCache(ctx, req, intent, start); cached != nil {
 			return cached, nil
 		}
 	}
 
-	// Step 4-6: Route, execute, rank
+	// Step 4-6: Route, execute, rank. Cacheable searches are coalesced so a
+	// popular key expiring sends one search to the backends, not one per caller.
+	if o.cfg.Coalescing.Enabled && !req.Explain && !req.Profile {
+		return o.coalescedSearch(ctx, req, parsed, intent, start)
+	}
+
 	resp, err := o.searchWithFallback(ctx, req, parsed, intent)
 	if err != nil {
-		observability.SearchRequestsTotal.WithLabelValues(intent.String(), "error").Inc()
-		observability.SearchRequestDuration.WithLabelValues(intent.String(), "error", "error").Observe(time.Since(start).Seconds())
+		o.recordFailure(intent, start)
 		return nil, err
 	}
 
+	if req.Explain {
+		if resp.Explain == nil {
+			resp.Explain = &models.ExplainInfo{}
+		}
+		resp.Explain.Parsed = parsed
+		resp.Explain.Intent = intent.String()
+		resp.Explain.FallbackLevel = fallbackLevel(resp.Source)
+	}
+	if req.Profile {
+		if resp.Profile == nil {
+			resp.Profile = &models.QueryProfile{}
+		}
+		resp.Profile.CHQueries = o.resolveCHStats(ctx, chTracker.Queries())
+	}
+
+	o.completeSearch(ctx, req, intent, resp, start)
+	return resp, nil
+}
+
+// resolveCHStats fills in the rows/bytes read by a profiled search's
+// ClickHouse queries. On failure the queries are returned with their IDs
+// only, so they can still be looked up in system.query_log.
+func (o *Orchestrator) resolveCHStats(ctx context.Context, queries []models.CHQueryStats) []models.CHQueryStats {
+	if o.chClient == nil || len(queries) == 0 {
+		return queries
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.cfg.QueryTimeout)
+	defer cancel()
+	resolved, err := o.chClient.QueryLogStats(ctx, queries)
+	if err != nil {
+		o.logger.Warn("resolving clickhouse query stats failed", zap.Error(err))
+		return queries
+	}
+	return resolved
+}
+
+func (o *Orchestrator) normalizePageSize(req *models.SearchRequest) {
+	if req.PageSize <= 0 {
+		req.PageSize = o.cfg.DefaultPageSize
+	}
+	if req.PageSize > o.cfg.MaxPageSize {
+		req.PageSize = o.cfg.MaxPageSize
+	}
+}
+
+// LookupCache returns the cache entries req would be served from, resolving
+// the page size the same way Search does.
+func (o *Orchestrator) LookupCache(ctx context.Context, req *models.SearchRequest) (*cache.CacheLookup, error) {
+	o.normalizePageSize(req)
+	return o.cache.Lookup(ctx, req)
+}
+
+// cachedResponse returns the cached response for req, or nil on a miss.
+func (o *Orchestrator) cachedResponse(ctx context.Context, req *models.SearchRequest, intent models.Intent, start time.Time) *models.SearchResponse {
+	cached, err := o.cache.GetSearchResults(ctx, req)
+	if err != nil {
+		o.logger.Warn("cache lookup error", zap.Error(err))
+	}
+	if cached == nil {
+		return nil
+	}
+	// Decide on early refresh before TookMs is overwritten: XFetch weighs the
+	// time the cached response originally took to compute.
+	if o.cfg.StaleWhileRevalidate.Enabled && o.cache.RefreshDue(cached, o.cfg.StaleWhileRevalidate.XFetchBeta) {
+		o.revalidate(ctx, req, intent, "xfetch")
+	}
+	if cached.Metadata.CachedAt != nil {
+		cached.Metadata.AgeMs = time.Since(*cached.Metadata.CachedAt).Milliseconds()
+	}
+	cached.Metadata.CacheHit = true
+	cached.TookMs = time.Since(start).Milliseconds()
+	observability.SearchRequestsTotal.WithLabelValues(intent.String(), "cache_hit").Inc()
+	return cached
+}
+
+// completeSearch stamps request metadata on a successful response, caches it
+// and records metrics and slow query analytics.
+func (o *Orchestrator) completeSearch(ctx context.Context, req *models.SearchRequest, intent models.Intent, resp *models.SearchResponse, start time.Time) {
 	resp.TookMs = time.Since(start).Milliseconds()
 	resp.Page = req.Page
 	resp.PageSize = req.PageSize
 	resp.Metadata.RequestID = req.RequestID
 	resp.Metadata.Intent = intent.String()
 
-	// Step 7: Cache results
-	if err := o.cache.SetSearchResults(ctx, req, resp); err != nil {
-		o.logger.Warn("cache set error", zap.Error(err))
+	if !req.Explain && !req.Profile {
+		// Step 7: Cache results (debug responses are never cached)
+		if err := o.cache.SetSearchResults(ctx, req, resp); err != nil {
+			o.logger.Warn("cache set error", zap.Error(err))
+		}
 	}
 
 	// Track metrics
@@ -126,83 +225,96 @@ func (o *Orchestrator) Search(ctx context.Context, req *models.SearchRequest) (*
 	observability.SearchRequestDuration.WithLabelValues(intent.String(), resp.Source, "success").Observe(time.Since(start).Seconds())
 
 	// Slow query detection
-	o.slowQuery.Intercept(ctx, req.Query, intent.String(),
-		time.Since(start), resp.Total, resp.Metadata.ShardsHit, resp.Metadata.TimedOut)
+	o.slowQuery.InterceptWithProfile(ctx, req.Query, intent.String(),
+		time.Since(start), resp.Total, resp.Metadata.ShardsHit, resp.Metadata.TimedOut, resp.Profile)
+}
 
-	return resp, nil
+func (o *Orchestrator) recordFailure(intent models.Intent, start time.Time) {
+	observability.SearchRequestsTotal.WithLabelValues(intent.String(), "error").Inc()
+	observability.SearchRequestDuration.WithLabelValues(intent.String(), "error", "error").Observe(time.Since(start).Seconds())
 }
 
 func (o *Orchestrator) searchWithFallback(ctx context.Context, req *models.SearchRequest, parsed *models.ParsedQuery, intent models.Intent) (*models.SearchResponse, error) {
-	// Level 1: Primary search
-	resp, err := o.primarySearch(ctx, req, parsed, intent)
-	if err == nil {
-		return resp, nil
-	}
-	o.logger.Warn("primary search failed, trying fallback", zap.Error(err))
-	observability.FallbackCounter.WithLabelValues("primary_failed").Inc()
-
-	// Level 2: Stale cache
-	stale, cacheErr := o.cache.GetStaleResults(ctx, req)
-	if cacheErr == nil && stale != nil {
-		stale.Metadata.Stale = true
-		stale.Source = "stale_cache"
-		stale.Metadata.Source = "stale_cache"
-		observability.FallbackCounter.WithLabelValues("stale_cache").Inc()
-		return stale, nil
-	}
-
-	// Level 3: ClickHouse degraded search
-	if o.chClient != nil {
-		chResults, chErr := o.chClient.FallbackSearch(ctx, parsed.Normalized, req.PageSize)
-		if chErr == nil && len(chResults) > 0 {
-			observability.FallbackCounter.WithLabelValues("clickhouse").Inc()
-			return &models.SearchResponse{
-				Results: chResults,
-				Total:   int64(len(chResults)),
-				Source:  "degraded",
-				Metadata: models.ResponseMetadata{
-					Source: "degraded_clickhouse",
-				},
-			}, nil
-		}
-		if chErr != nil {
-			o.logger.Warn("clickhouse fallback failed", zap.Error(chErr))
+	freq := &FallbackRequest{Request: req, Parsed: parsed, Intent: intent}
+
+	var budget *latencyBudget
+	if o.cfg.LatencyBudget.Enabled {
+		budget = newLatencyBudget(ctx, o.cfg.LatencyBudget.Total)
+		if o.cfg.LatencyBudget.ParallelStale && o.cache != nil {
+			// The stale copy is only needed if the strategies before it fail,
+			// but by then reading it would eat into the time left for the rest.
+			staleCtx, cancelStale := context.WithCancel(ctx)
+			defer cancelStale()
+			freq.stale = o.lookupStaleAsync(staleCtx, req)
 		}
 	}
 
-	// Level 4: Static popular results
-	staticResults := o.getStaticFallback(req.Region)
-	if len(staticResults) > 0 {
-		observability.FallbackCounter.WithLabelValues("static").Inc()
-		return &models.SearchResponse{
-			Results: staticResults,
-			Total:   int64(len(staticResults)),
-			Source:  "static_fallback",
-			Metadata: models.ResponseMetadata{
-				Source: "static_fallback",
-			},
-		}, nil
+	return o.runFallbackChain(ctx, o.fallbackChain(intent), freq, budget, nil)
+}
+
+// degradedSearch runs the rest of intent's fallback chain after the caller's
+// own primary search failed with primaryErr.
+func (o *Orchestrator) degradedSearch(ctx context.Context, req *models.SearchRequest, parsed *models.ParsedQuery, intent models.Intent, primaryErr error) (*models.SearchResponse, error) {
+	attempts := []models.FallbackAttempt{{
+		Strategy: "primary",
+		Status:   fallbackStatus(primaryErr),
+		Error:    primaryErr.Error(),
+	}}
+	observability.FallbackStrategyAttempts.WithLabelValues("primary", attempts[0].Status).Inc()
+	var rest []fallbackStep
+	for _, step := range o.fallbackChain(intent) {
+		if step.strategy.Name() != "primary" {
+			rest = append(rest, step)
+		}
 	}
+	return o.runFallbackChain(ctx, rest, &FallbackRequest{Request: req, Parsed: parsed, Intent: intent}, nil, attempts)
+}
 
-	return nil, fmt.Errorf("all search paths exhausted: primary error: %w", err)
+// levelTimeout returns how long a level may run: limit without a budget, and
+// otherwise share of the remaining budget capped at limit. It reports false,
+// and records it, when the budget has run out.
+func (o *Orchestrator) levelTimeout(budget *latencyBudget, share float64, limit time.Duration, level string) (time.Duration, bool) {
+	if budget == nil {
+		return limit, true
+	}
+	d, ok := budget.allot(share, limit)
+	if !ok {
+		budgetExhausted(level)
+	}
+	return d, ok
 }
 
-func (o *Orchestrator) primarySearch(ctx context.Context, req *models.SearchRequest, parsed *models.ParsedQuery, intent models.Intent) (*models.SearchResponse, error) {
-	ctx, cancel := context.WithTimeout(ctx, o.cfg.QueryTimeout)
-	defer cancel()
+// fallbackLevel maps a response source to the fallback chain level that served it.
+func fallbackLevel(source string) string {
+	switch source {
+	case "replica":
+		return "replica"
+	case "stale_cache":
+		return "stale_cache"
+	case "degraded":
+		return "clickhouse"
+	case "static_fallback":
+		return "static"
+	default:
+		return "primary"
+	}
+}
 
+// intentSearch routes a search by intent, with es serving the Elasticsearch
+// part.
+func (o *Orchestrator) intentSearch(ctx context.Context, es *elasticsearch.Client, req *models.SearchRequest, parsed *models.ParsedQuery, intent models.Intent) (*models.SearchResponse, error) {
 	switch intent {
 	case models.IntentFullText, models.IntentAutocomplete:
-		return o.fullTextSearch(ctx, req, parsed)
+		return o.fullTextSearch(ctx, es, req, parsed)
 
 	case models.IntentAnalytics:
-		return o.analyticsSearch(ctx, req, parsed)
+		return o.analyticsSearch(ctx, es, req, parsed)
 
 	case models.IntentFaceted:
-		return o.facetedSearch(ctx, req, parsed)
+		return o.facetedSearch(ctx, es, req, parsed)
 
 	default:
-		return o.fullTextSearch(ctx, req, parsed)
+		return o.fullTextSearch(ctx, es, req, parsed)
 	}
 }
 
@@ -216,13 +328,29 @@ func sanitizeIndexComponent(s string) string {
 	return ""
 }
 
-func (o *Orchestrator) fullTextSearch(ctx context.Context, req *models.SearchRequest, parsed *models.ParsedQuery) (*models.SearchResponse, error) {
-	if o.esClient == nil {
+func (o *Orchestrator) fullTextSearch(ctx context.Context, es *elasticsearch.Client, req *models.SearchRequest, parsed *models.ParsedQuery) (*models.SearchResponse, error) {
+	if es == nil {
 		return nil, fmt.Errorf("elasticsearch client unavailable")
 	}
 
-	esQuery := o.builder.BuildESQuery(parsed, req)
+	esQuery := o.queryBuilder(es).BuildESQuery(parsed, req)
+	index := o.searchIndex(es, req)
+
+	result, err := es.Search(ctx, index, esQuery)
+	if err != nil {
+		return nil, fmt.Errorf("es fulltext search: %w", err)
+	}
 
+	return o.primaryResponse(ctx, req, result, esQuery, index), nil
+}
+
+// searchIndex returns the index pattern a request is executed against on es:
+// its read alias once it exists, otherwise the monthly indices, narrowed to
+// the request's region.
+func (o *Orchestrator) searchIndex(es *elasticsearch.Client, req *models.SearchRequest) string {
+	if alias := readAlias(es); alias != "" {
+		return alias
+	}
 	index := fmt.Sprintf("%s-*", o.esCfg.IndexPrefix)
 	if req.Region != "" {
 		region := sanitizeIndexComponent(req.Region)
@@ -230,12 +358,32 @@ func (o *Orchestrator) fullTextSearch(ctx context.Context, req *models.SearchReq
 			index = fmt.Sprintf("%s-*-%s-*", o.esCfg.IndexPrefix, region)
 		}
 	}
+	return index
+}
 
-	result, err := o.esClient.Search(ctx, index, esQuery)
-	if err != nil {
-		return nil, fmt.Errorf("es fulltext search: %w", err)
+// readAlias returns the read alias searches on es should use, or "" for the
+// monthly index layout. Each cluster follows its own aliases.
+func readAlias(es *elasticsearch.Client) string {
+	if es == nil {
+		return ""
 	}
+	return es.SearchAlias()
+}
 
+// queryBuilder returns the builder for searches on es, which filters on the
+// region once es serves every region from one index behind its read alias.
+func (o *Orchestrator) queryBuilder(es *elasticsearch.Client) *QueryBuilder {
+	if readAlias(es) == "" {
+		return o.builder
+	}
+	aliased := *o.builder
+	aliased.regionFilter = true
+	return &aliased
+}
+
+// primaryResponse hydrates ES hits when requested and wraps them in a
+// primary-source response.
+func (o *Orchestrator) primaryResponse(ctx context.Context, req *models.SearchRequest, result *elasticsearch.SearchResult, esQuery map[string]any, index string) *models.SearchResponse {
 	// Hydrate from Firestore if extra fields needed
 	if len(req.Fields) > 0 && o.fsClient != nil {
 		hydrated, err := o.fsClient.HydrateResults(ctx, result.Hits, "documents")
@@ -246,27 +394,38 @@ func (o *Orchestrator) fullTextSearch(ctx context.Context, req *models.SearchReq
 		}
 	}
 
-	return &models.SearchResponse{
+	resp := &models.SearchResponse{
 		Results: result.Hits,
 		Total:   result.Total,
 		Source:  "primary",
 		Metadata: models.ResponseMetadata{
-			Source:    "elasticsearch",
-			ShardsHit: result.ShardsHit,
-			TimedOut:  result.TimedOut,
+			Source:       "elasticsearch",
+			ShardsHit:    result.ShardsHit,
+			TimedOut:     result.TimedOut,
+			SpellCorrect: result.SpellSuggestion,
 		},
-	}, nil
+	}
+	if req.Explain {
+		resp.Explain = &models.ExplainInfo{
+			ESQuery: esQuery,
+			Index:   index,
+		}
+	}
+	if req.Profile {
+		resp.Profile = &models.QueryProfile{ESShards: result.Profile}
+	}
+	return resp
 }
 
-func (o *Orchestrator) analyticsSearch(ctx context.Context, req *models.SearchRequest, parsed *models.ParsedQuery) (*models.SearchResponse, error) {
+func (o *Orchestrator) analyticsSearch(ctx context.Context, es *elasticsearch.Client, req *models.SearchRequest, parsed *models.ParsedQuery) (*models.SearchResponse, error) {
 	if o.chClient == nil {
-		return o.fullTextSearch(ctx, req, parsed)
+		return o.fullTextSearch(ctx, es, req, parsed)
 	}
 
 	aggResult, err := o.chClient.QueryAnalytics(ctx, parsed.Normalized, req.Filters)
 	if err != nil {
 		o.logger.Warn("clickhouse analytics failed, falling back to ES", zap.Error(err))
-		return o.fullTextSearch(ctx, req, parsed)
+		return o.fullTextSearch(ctx, es, req, parsed)
 	}
 
 	return &models.SearchResponse{
@@ -279,18 +438,35 @@ func (o *Orchestrator) analyticsSearch(ctx context.Context, req *models.SearchRe
 	}, nil
 }
 
-func (o *Orchestrator) facetedSearch(ctx context.Context, req *models.SearchRequest, parsed *models.ParsedQuery) (*models.SearchResponse, error) {
+type facetsResult struct {
+	facets map[string][]models.Facet
+	err    error
+}
+
+// queryFacets fetches facet counts for the request's category from ClickHouse.
+func (o *Orchestrator) queryFacets(ctx context.Context, req *models.SearchRequest) facetsResult {
+	if o.chClient == nil {
+		return facetsResult{err: fmt.Errorf("clickhouse not available")}
+	}
+	category := ""
+	if c, ok := req.Filters["category"].(string); ok {
+		category = c
+	}
+	aggResult, err := o.chClient.QueryFacets(ctx, category, req.Filters)
+	if err != nil {
+		return facetsResult{err: err}
+	}
+	return facetsResult{facets: aggResult.Facets}
+}
+
+func (o *Orchestrator) facetedSearch(ctx context.Context, es *elasticsearch.Client, req *models.SearchRequest, parsed *models.ParsedQuery) (*models.SearchResponse, error) {
 	type esResult struct {
 		resp *models.SearchResponse
 		err  error
 	}
-	type chResult struct {
-		facets map[string][]models.Facet
-		err    error
-	}
 
 	esCh := make(chan esResult, 1)
-	chCh := make(chan chResult, 1)
+	chCh := make(chan facetsResult, 1)
 
 	// Fan-out: ES for results + ClickHouse for facet counts.
 	// Each goroutine has panic recovery to prevent a deadlock on the channel receive.
@@ -300,30 +476,17 @@ func (o *Orchestrator) facetedSearch(ctx context.Context, req *models.SearchRequ
 				esCh <- esResult{err: fmt.Errorf("panic in ES search: %v", r)}
 			}
 		}()
-		resp, err := o.fullTextSearch(ctx, req, parsed)
+		resp, err := o.fullTextSearch(ctx, es, req, parsed)
 		esCh <- esResult{resp: resp, err: err}
 	}()
 
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				chCh <- chResult{err: fmt.Errorf("panic in CH facets: %v", r)}
+				chCh <- facetsResult{err: fmt.Errorf("panic in CH facets: %v", r)}
 			}
 		}()
-		if o.chClient == nil {
-			chCh <- chResult{err: fmt.Errorf("clickhouse not available")}
-			return
-		}
-		category := ""
-		if c, ok := req.Filters["category"].(string); ok {
-			category = c
-		}
-		aggResult, err := o.chClient.QueryFacets(ctx, category, req.Filters)
-		if err != nil {
-			chCh <- chResult{err: err}
-			return
-		}
-		chCh <- chResult{facets: aggResult.Facets}
+		chCh <- o.queryFacets(ctx, req)
 	}()
 
 	esRes := <-esCh
@@ -345,12 +508,30 @@ func (o *Orchestrator) facetedSearch(ctx context.Context, req *models.SearchRequ
 	return resp, nil
 }
 
+// SetQueryLog makes the orchestrator record served searches to ql, the source
+// of popular queries for cache warming.
+func (o *Orchestrator) SetQueryLog(ql *clickhouse.QueryLog) {
+	o.queryLog = ql
+}
+
+// SetReplica makes the replica fallback strategy search es.
+func (o *Orchestrator) SetReplica(es *elasticsearch.Client) {
+	o.replicaClient = es
+}
+
 func (o *Orchestrator) SetStaticFallback(region string, results []models.SearchResult) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 	o.staticFallback[region] = results
 }
 
+// replaceStaticFallback swaps in a complete set of static fallback lists.
+func (o *Orchestrator) replaceStaticFallback(lists map[string][]models.SearchResult) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.staticFallback = lists
+}
+
 func (o *Orchestrator) getStaticFallback(region string) []models.SearchResult {
 	o.mu.RLock()
 	defer o.mu.RUnlock()
@@ -371,3 +552,10 @@ func (o *Orchestrator) getStaticFallback(region string) []models.SearchResult {
 	copy(cp, src)
 	return cp
 }
+
+// shouldLogQuery reports whether a search belongs in the query log. First
+// pages (page 0) are what users land on and what the cache warmer replays;
+// diagnostic and warming searches are left out.
+func shouldLogQuery(ctx context.Context, req *models.SearchRequest) bool {
+	return req.Page == 0 && !req.Explain && !req.Profile && !isWarmRequest(ctx)
+}
@@ -1,6 +1,7 @@
 package orchestrator
 
 import (
+	"context"
 	"testing"
 
 	"github.com/shubhsaxena/high-scale-search/internal/models"
@@ -158,3 +159,51 @@ func TestStaticFallback_Overwrite(t *testing.T) {
 		t.Errorf("expected overwritten result, got %v", got)
 	}
 }
+
+func TestFallbackLevel(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"primary", "primary"},
+		{"faceted", "primary"},
+		{"analytics", "primary"},
+		{"stale_cache", "stale_cache"},
+		{"degraded", "clickhouse"},
+		{"static_fallback", "static"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			if got := fallbackLevel(tt.source); got != tt.want {
+				t.Errorf("fallbackLevel(%q) = %q, want %q", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldLogQuery(t *testing.T) {
+	ctx := context.Background()
+	warmCtx := context.WithValue(ctx, warmRequestKey{}, true)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  models.SearchRequest
+		want bool
+	}{
+		{"first page", ctx, models.SearchRequest{Query: "laptop", Page: 0}, true},
+		{"second page", ctx, models.SearchRequest{Query: "laptop", Page: 1}, false},
+		{"explain", ctx, models.SearchRequest{Query: "laptop", Explain: true}, false},
+		{"profile", ctx, models.SearchRequest{Query: "laptop", Profile: true}, false},
+		{"warm request", warmCtx, models.SearchRequest{Query: "laptop"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldLogQuery(tt.ctx, &tt.req); got != tt.want {
+				t.Errorf("shouldLogQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
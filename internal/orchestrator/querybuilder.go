@@ -6,7 +6,12 @@ import (
 
 const maxESFromPlusSize = 10000
 
-type QueryBuilder struct{}
+type QueryBuilder struct {
+	// regionFilter applies a request's region as a filter, needed when
+	// every region shares one index behind the read alias. Otherwise regions
+	// are selected by index name.
+	regionFilter bool
+}
 
 func NewQueryBuilder() *QueryBuilder {
 	return &QueryBuilder{}
@@ -15,7 +20,107 @@ func NewQueryBuilder() *QueryBuilder {
 func (qb *QueryBuilder) BuildESQuery(parsed *models.ParsedQuery, req *models.SearchRequest) map[string]any {
 	query := make(map[string]any)
 
-	// Build the main bool query
+	boolQuery := qb.buildBoolQuery(parsed, req)
+
+	// Wrap bool query in script_score for popularity boosting
+	query["query"] = map[string]any{
+		"script_score": map[string]any{
+			"query": map[string]any{
+				"bool": boolQuery,
+			},
+			"script": map[string]any{
+				"source": "_score * (1 + Math.log1p(doc['popularity_score'].value))",
+			},
+		},
+	}
+
+	// Pagination with deep pagination guard
+	from := req.Page * req.PageSize
+	if from+req.PageSize > maxESFromPlusSize {
+		from = maxESFromPlusSize - req.PageSize
+		if from < 0 {
+			from = 0
+		}
+	}
+	query["from"] = from
+	query["size"] = req.PageSize
+
+	// Highlighting
+	query["highlight"] = map[string]any{
+		"fields": map[string]any{
+			"title":       map[string]any{},
+			"description": map[string]any{"fragment_size": 150},
+		},
+		"pre_tags":  []string{"<em>"},
+		"post_tags": []string{"</em>"},
+	}
+
+	// Sorting
+	if req.Sort != "" {
+		switch req.Sort {
+		case "relevance":
+			// default ES score sort
+		case "newest":
+			query["sort"] = []map[string]any{
+				{"created_at": map[string]any{"order": "desc"}},
+				{"_score": map[string]any{"order": "desc"}},
+			}
+		case "popular":
+			query["sort"] = []map[string]any{
+				{"popularity_score": map[string]any{"order": "desc"}},
+				{"_score": map[string]any{"order": "desc"}},
+			}
+		}
+	}
+
+	// Per-hit score breakdown for internal relevance debugging
+	if req.Explain {
+		query["explain"] = true
+	}
+
+	// Per-shard timing breakdown for profiled (slow query) investigation
+	if req.Profile {
+		query["profile"] = true
+	}
+
+	// Suggest for spell correction
+	query["suggest"] = map[string]any{
+		"text": parsed.Original,
+		"spell_suggest": map[string]any{
+			"phrase": map[string]any{
+				"field":      "title.suggest",
+				"size":       1,
+				"gram_size":  3,
+				"confidence": 1.0,
+			},
+		},
+	}
+
+	return query
+}
+
+func (qb *QueryBuilder) BuildAutocompleteQuery(prefix string, size int) map[string]any {
+	return map[string]any{
+		"size": 0,
+		"suggest": map[string]any{
+			"autocomplete": map[string]any{
+				"prefix": prefix,
+				"completion": map[string]any{
+					"field":           "title.autocomplete",
+					"size":            size,
+					"skip_duplicates": true,
+					"fuzzy": map[string]any{
+						"fuzziness": "AUTO",
+					},
+				},
+			},
+		},
+	}
+}
+
+// buildBoolQuery builds the text match, filters and region boost shared by
+// search and export queries.
+func (qb *QueryBuilder) buildBoolQuery(parsed *models.ParsedQuery, req *models.SearchRequest) map[string]any {
 	var boolQuery map[string]any
 
 	if parsed.IsPhrase {
@@ -89,6 +194,14 @@ func (qb *QueryBuilder) BuildESQuery(parsed *models.ParsedQuery, req *models.Sea
 
 	// Add region routing boost
 	if req.Region != "" {
+		if qb.regionFilter {
+			filters, _ := boolQuery["filter"].([]map[string]any)
+			boolQuery["filter"] = append(filters, map[string]any{
+				"term": map[string]any{
+					"region": req.Region,
+				},
+			})
+		}
 		boolQuery["should"] = []map[string]any{
 			{
 				"term": map[string]any{
@@ -101,88 +214,32 @@ func (qb *QueryBuilder) BuildESQuery(parsed *models.ParsedQuery, req *models.Sea
 		}
 	}
 
-	// Wrap bool query in script_score for popularity boosting
-	query["query"] = map[string]any{
-		"script_score": map[string]any{
-			"query": map[string]any{
-				"bool": boolQuery,
-			},
-			"script": map[string]any{
-				"source": "_score * (1 + Math.log1p(doc['popularity_score'].value))",
-			},
-		},
-	}
-
-	// Pagination with deep pagination guard
-	from := req.Page * req.PageSize
-	if from+req.PageSize > maxESFromPlusSize {
-		from = maxESFromPlusSize - req.PageSize
-		if from < 0 {
-			from = 0
-		}
-	}
-	query["from"] = from
-	query["size"] = req.PageSize
+	return boolQuery
+}
 
-	// Highlighting
-	query["highlight"] = map[string]any{
-		"fields": map[string]any{
-			"title":       map[string]any{},
-			"description": map[string]any{"fragment_size": 150},
+// BuildExportQuery builds one search_after page over a point in time. Hits are
+// sorted by _shard_doc, the cheapest total order for PIT iteration, so
+// relevance scoring and highlighting are skipped.
+func (qb *QueryBuilder) BuildExportQuery(parsed *models.ParsedQuery, req *models.SearchRequest, pitID, keepAlive string, searchAfter []any, size int) map[string]any {
+	query := map[string]any{
+		"query": map[string]any{
+			"bool": qb.buildBoolQuery(parsed, req),
+		},
+		"size":             size,
+		"sort":             []map[string]any{{"_shard_doc": "asc"}},
+		"track_total_hits": false,
+		"pit": map[string]any{
+			"id":         pitID,
+			"keep_alive": keepAlive,
 		},
-		"pre_tags":  []string{"<em>"},
-		"post_tags": []string{"</em>"},
 	}
 
-	// Sorting
-	if req.Sort != "" {
-		switch req.Sort {
-		case "relevance":
-			// default ES score sort
-		case "newest":
-			query["sort"] = []map[string]any{
-				{"created_at": map[string]any{"order": "desc"}},
-				{"_score": map[string]any{"order": "desc"}},
-			}
-		case "popular":
-			query["sort"] = []map[string]any{
-				{"popularity_score": map[string]any{"order": "desc"}},
-				{"_score": map[string]any{"order": "desc"}},
-			}
-		}
+	if len(req.Fields) > 0 {
+		query["_source"] = req.Fields
 	}
-
-	// Suggest for spell correction
-	query["suggest"] = map[string]any{
-		"text": parsed.Original,
-		"spell_suggest": map[string]any{
-			"phrase": map[string]any{
-				"field":      "title.suggest",
-				"size":       1,
-				"gram_size":  3,
-				"confidence": 1.0,
-			},
-		},
+	if len(searchAfter) > 0 {
+		query["search_after"] = searchAfter
 	}
 
 	return query
 }
-
-func (qb *QueryBuilder) BuildAutocompleteQuery(prefix string, size int) map[string]any {
-	return map[string]any{
-		"size": 0,
-		"suggest": map[string]any{
-			"autocomplete": map[string]any{
-				"prefix": prefix,
-				"completion": map[string]any{
-					"field":           "title.autocomplete",
-					"size":            size,
-					"skip_duplicates": true,
-					"fuzzy": map[string]any{
-						"fuzziness": "AUTO",
-					},
-				},
-			},
-		},
-	}
-}
@@ -221,6 +221,40 @@ func TestQueryBuilder_BuildESQuery_WithRegion(t *testing.T) {
 	}
 }
 
+func TestQueryBuilder_BuildESQuery_RegionFilterBehindAlias(t *testing.T) {
+	qb := NewQueryBuilder()
+	parsed := &models.ParsedQuery{
+		Normalized: "laptop",
+		Tokens:     []string{"laptop"},
+		Fields:     make(map[string]string),
+	}
+	req := &models.SearchRequest{
+		Query:    "laptop",
+		PageSize: 10,
+		Region:   "us-east",
+	}
+
+	boolOf := func(query map[string]any) map[string]any {
+		scriptScore := query["query"].(map[string]any)["script_score"].(map[string]any)
+		return scriptScore["query"].(map[string]any)["bool"].(map[string]any)
+	}
+
+	// Monthly indices are selected by region, so no filter is needed.
+	if _, ok := boolOf(qb.BuildESQuery(parsed, req))["filter"]; ok {
+		t.Error("expected no region filter without the read alias")
+	}
+
+	qb.regionFilter = true
+	filters, ok := boolOf(qb.BuildESQuery(parsed, req))["filter"].([]map[string]any)
+	if !ok || len(filters) != 1 {
+		t.Fatalf("expected one region filter, got %v", filters)
+	}
+	term := filters[0]["term"].(map[string]any)
+	if term["region"] != "us-east" {
+		t.Errorf("expected region filter us-east, got %v", term)
+	}
+}
+
 func TestQueryBuilder_BuildESQuery_Pagination(t *testing.T) {
 	qb := NewQueryBuilder()
 	parsed := &models.ParsedQuery{
@@ -427,3 +461,71 @@ func TestQueryBuilder_BuildESQuery_CombinedFieldsAndRequestFilters(t *testing.T)
 		t.Errorf("expected at least 2 filters (field + request), got %d", len(filters))
 	}
 }
+
+func TestQueryBuilder_BuildESQuery_Explain(t *testing.T) {
+	qb := NewQueryBuilder()
+	parsed := &models.ParsedQuery{Original: "laptop", Normalized: "laptop", Tokens: []string{"laptop"}}
+
+	query := qb.BuildESQuery(parsed, &models.SearchRequest{Query: "laptop", PageSize: 20})
+	if _, ok := query["explain"]; ok {
+		t.Error("explain should not be set by default")
+	}
+
+	query = qb.BuildESQuery(parsed, &models.SearchRequest{Query: "laptop", PageSize: 20, Explain: true})
+	if query["explain"] != true {
+		t.Errorf("expected explain=true, got %v", query["explain"])
+	}
+}
+
+func TestQueryBuilder_BuildESQuery_Profile(t *testing.T) {
+	qb := NewQueryBuilder()
+	parsed := &models.ParsedQuery{Original: "laptop", Normalized: "laptop", Tokens: []string{"laptop"}}
+
+	query := qb.BuildESQuery(parsed, &models.SearchRequest{Query: "laptop", PageSize: 20})
+	if _, ok := query["profile"]; ok {
+		t.Error("profile should not be set by default")
+	}
+
+	query = qb.BuildESQuery(parsed, &models.SearchRequest{Query: "laptop", PageSize: 20, Profile: true})
+	if query["profile"] != true {
+		t.Errorf("expected profile=true, got %v", query["profile"])
+	}
+}
+
+func TestQueryBuilder_BuildExportQuery(t *testing.T) {
+	qb := NewQueryBuilder()
+	parsed := &models.ParsedQuery{Original: "laptop", Normalized: "laptop", Tokens: []string{"laptop"}}
+	req := &models.SearchRequest{
+		Query:   "laptop",
+		Filters: map[string]any{"category": "electronics"},
+		Fields:  []string{"title", "tags"},
+	}
+
+	query := qb.BuildExportQuery(parsed, req, "pit-1", "300000ms", nil, 500)
+
+	if query["size"] != 500 {
+		t.Errorf("expected size 500, got %v", query["size"])
+	}
+	pit, ok := query["pit"].(map[string]any)
+	if !ok || pit["id"] != "pit-1" || pit["keep_alive"] != "300000ms" {
+		t.Errorf("unexpected pit clause: %v", query["pit"])
+	}
+	if _, ok := query["search_after"]; ok {
+		t.Error("search_after should not be set for the first page")
+	}
+	for _, key := range []string{"from", "highlight", "suggest"} {
+		if _, ok := query[key]; ok {
+			t.Errorf("%s should not be set for export queries", key)
+		}
+	}
+	boolQuery := query["query"].(map[string]any)["bool"].(map[string]any)
+	if filters, ok := boolQuery["filter"].([]map[string]any); !ok || len(filters) != 1 {
+		t.Errorf("expected request filter in bool query, got %v", boolQuery["filter"])
+	}
+
+	query = qb.BuildExportQuery(parsed, req, "pit-1", "300000ms", []any{int64(42)}, 500)
+	after, ok := query["search_after"].([]any)
+	if !ok || len(after) != 1 || after[0] != int64(42) {
+		t.Errorf("unexpected search_after: %v", query["search_after"])
+	}
+}
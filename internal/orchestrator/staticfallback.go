@@ -0,0 +1,283 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/clickhouse"
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// defaultFallbackRegion is the list served to regions without their own.
+const defaultFallbackRegion = "default"
+
+// PopularSource returns the most popular documents per region.
+type PopularSource func(ctx context.Context, perRegion int) (map[string][]models.SearchResult, error)
+
+// StaticFallbackSnapshot is the persisted state of the static fallback lists.
+// Generated lists are replaced on every refresh; overrides are set by
+// operators and take precedence until removed.
+type StaticFallbackSnapshot struct {
+	UpdatedAt *time.Time                       `json:"updated_at,omitempty"`
+	Generated map[string][]models.SearchResult `json:"generated"`
+	Overrides map[string][]models.SearchResult `json:"overrides"`
+}
+
+// StaticFallbackRegion is one region's effective list for the admin API.
+type StaticFallbackRegion struct {
+	Results    []models.SearchResult `json:"results"`
+	Overridden bool                  `json:"overridden"`
+}
+
+// StaticFallbackManager keeps the orchestrator's level 4 fallback lists
+// populated. Lists are refreshed from ClickHouse popularity and written to a
+// snapshot file, so a restart with every backend down still has results to
+// serve.
+type StaticFallbackManager struct {
+	orch   *Orchestrator
+	source PopularSource
+	cfg    config.StaticFallbackConfig
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	snapshot StaticFallbackSnapshot
+}
+
+// NewStaticFallbackManager creates a manager. chClient may be nil, in which
+// case lists come only from the snapshot file and overrides.
+func NewStaticFallbackManager(orch *Orchestrator, chClient *clickhouse.Client, cfg config.StaticFallbackConfig, logger *zap.Logger) *StaticFallbackManager {
+	m := &StaticFallbackManager{
+		orch:   orch,
+		cfg:    cfg,
+		logger: logger,
+		snapshot: StaticFallbackSnapshot{
+			Generated: make(map[string][]models.SearchResult),
+			Overrides: make(map[string][]models.SearchResult),
+		},
+	}
+	if chClient != nil {
+		m.source = chClient.PopularDocuments
+	}
+	return m
+}
+
+// LoadSnapshot applies the lists persisted by a previous run. A missing
+// snapshot file is not an error.
+func (m *StaticFallbackManager) LoadSnapshot() error {
+	if m.cfg.SnapshotPath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(m.cfg.SnapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading static fallback snapshot: %w", err)
+	}
+
+	var snap StaticFallbackSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decoding static fallback snapshot: %w", err)
+	}
+	if snap.Generated == nil {
+		snap.Generated = make(map[string][]models.SearchResult)
+	}
+	if snap.Overrides == nil {
+		snap.Overrides = make(map[string][]models.SearchResult)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshot = snap
+	m.applyLocked()
+	m.logger.Info("static fallback snapshot loaded",
+		zap.Int("regions", len(snap.Generated)),
+		zap.Int("overrides", len(snap.Overrides)),
+	)
+	return nil
+}
+
+// Run refreshes the lists immediately and then every refresh interval until
+// ctx is cancelled.
+func (m *StaticFallbackManager) Run(ctx context.Context) {
+	if m.source == nil {
+		return
+	}
+	ticker := time.NewTicker(m.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Refresh(ctx); err != nil {
+			m.logger.Warn("static fallback refresh failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh replaces the generated lists with the current most popular
+// documents and persists the result. On error the previous lists stay.
+func (m *StaticFallbackManager) Refresh(ctx context.Context) error {
+	if m.source == nil {
+		return fmt.Errorf("no popularity source configured")
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.RefreshTimeout)
+	defer cancel()
+
+	generated, err := m.source(ctx, m.cfg.PerRegion)
+	if err != nil {
+		observability.StaticFallbackRefreshes.WithLabelValues("error").Inc()
+		return err
+	}
+	if len(generated) == 0 {
+		// An empty table most likely means ClickHouse lost its data; keep the
+		// last good lists rather than serving nothing.
+		observability.StaticFallbackRefreshes.WithLabelValues("empty").Inc()
+		return fmt.Errorf("popularity source returned no documents")
+	}
+	generated[defaultFallbackRegion] = topAcrossRegions(generated, m.cfg.PerRegion)
+
+	now := time.Now().UTC()
+	m.mu.Lock()
+	m.snapshot.Generated = generated
+	m.snapshot.UpdatedAt = &now
+	m.applyLocked()
+	err = m.persistLocked()
+	m.mu.Unlock()
+
+	observability.StaticFallbackRefreshes.WithLabelValues("success").Inc()
+	return err
+}
+
+// topAcrossRegions returns the n most popular documents over all regions. The
+// global top n is always contained in the union of each region's top n.
+func topAcrossRegions(byRegion map[string][]models.SearchResult, n int) []models.SearchResult {
+	var all []models.SearchResult
+	seen := make(map[string]bool)
+	for region, results := range byRegion {
+		if region == defaultFallbackRegion {
+			continue
+		}
+		for _, r := range results {
+			if !seen[r.ID] {
+				seen[r.ID] = true
+				all = append(all, r)
+			}
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].PopularityScore != all[j].PopularityScore {
+			return all[i].PopularityScore > all[j].PopularityScore
+		}
+		return all[i].ID < all[j].ID
+	})
+	if len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
+
+// Regions returns the effective list for every region.
+func (m *StaticFallbackManager) Regions() map[string]StaticFallbackRegion {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	regions := make(map[string]StaticFallbackRegion, len(m.snapshot.Generated)+len(m.snapshot.Overrides))
+	for region, results := range m.snapshot.Generated {
+		regions[region] = StaticFallbackRegion{Results: results}
+	}
+	for region, results := range m.snapshot.Overrides {
+		regions[region] = StaticFallbackRegion{Results: results, Overridden: true}
+	}
+	return regions
+}
+
+// UpdatedAt returns when the generated lists were last refreshed.
+func (m *StaticFallbackManager) UpdatedAt() *time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.snapshot.UpdatedAt
+}
+
+// SetOverride pins region's list to results until the override is removed.
+func (m *StaticFallbackManager) SetOverride(region string, results []models.SearchResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshot.Overrides[region] = results
+	m.applyLocked()
+	return m.persistLocked()
+}
+
+// RemoveOverride returns region to its generated list. It reports whether an
+// override existed.
+func (m *StaticFallbackManager) RemoveOverride(region string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.snapshot.Overrides[region]; !ok {
+		return false, nil
+	}
+	delete(m.snapshot.Overrides, region)
+	m.applyLocked()
+	return true, m.persistLocked()
+}
+
+// applyLocked pushes the effective lists to the orchestrator, replacing any
+// region no longer present.
+func (m *StaticFallbackManager) applyLocked() {
+	effective := make(map[string][]models.SearchResult, len(m.snapshot.Generated)+len(m.snapshot.Overrides))
+	for region, results := range m.snapshot.Generated {
+		effective[region] = results
+	}
+	for region, results := range m.snapshot.Overrides {
+		effective[region] = results
+	}
+	m.orch.replaceStaticFallback(effective)
+}
+
+// persistLocked writes the snapshot atomically so a crash mid-write never
+// leaves a truncated file behind.
+func (m *StaticFallbackManager) persistLocked() error {
+	if m.cfg.SnapshotPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(m.snapshot)
+	if err != nil {
+		return fmt.Errorf("encoding static fallback snapshot: %w", err)
+	}
+
+	dir := filepath.Dir(m.cfg.SnapshotPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating static fallback snapshot dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".static_fallback-*")
+	if err != nil {
+		return fmt.Errorf("creating static fallback snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing static fallback snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing static fallback snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.cfg.SnapshotPath); err != nil {
+		return fmt.Errorf("replacing static fallback snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+func newTestFallbackManager(t *testing.T, source PopularSource) (*StaticFallbackManager, *Orchestrator) {
+	t.Helper()
+	o := &Orchestrator{staticFallback: make(map[string][]models.SearchResult)}
+	m := NewStaticFallbackManager(o, nil, config.StaticFallbackConfig{
+		PerRegion:      2,
+		RefreshTimeout: time.Second,
+		SnapshotPath:   filepath.Join(t.TempDir(), "fallback", "snapshot.json"),
+	}, zap.NewNop())
+	m.source = source
+	return m, o
+}
+
+func popularFixture(ctx context.Context, perRegion int) (map[string][]models.SearchResult, error) {
+	return map[string][]models.SearchResult{
+		"us": {{ID: "us-1", PopularityScore: 9}, {ID: "us-2", PopularityScore: 5}},
+		"eu": {{ID: "eu-1", PopularityScore: 7}},
+	}, nil
+}
+
+func TestStaticFallbackManager_Refresh(t *testing.T) {
+	m, o := newTestFallbackManager(t, popularFixture)
+
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := o.getStaticFallback("us"); len(got) != 2 || got[0].ID != "us-1" {
+		t.Errorf("unexpected us list: %v", got)
+	}
+	// Unknown regions get the global top list.
+	got := o.getStaticFallback("apac")
+	if len(got) != 2 || got[0].ID != "us-1" || got[1].ID != "eu-1" {
+		t.Errorf("unexpected default list: %v", got)
+	}
+	if m.UpdatedAt() == nil {
+		t.Error("expected updated_at to be set")
+	}
+}
+
+func TestStaticFallbackManager_SnapshotSurvivesRestart(t *testing.T) {
+	m, _ := newTestFallbackManager(t, popularFixture)
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.SetOverride("eu", []models.SearchResult{{ID: "pinned"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A new process with no popularity source restores both lists.
+	o := &Orchestrator{staticFallback: make(map[string][]models.SearchResult)}
+	restored := NewStaticFallbackManager(o, nil, m.cfg, zap.NewNop())
+	if err := restored.LoadSnapshot(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := o.getStaticFallback("us"); len(got) != 2 {
+		t.Errorf("expected generated list to be restored, got %v", got)
+	}
+	if got := o.getStaticFallback("eu"); len(got) != 1 || got[0].ID != "pinned" {
+		t.Errorf("expected override to be restored, got %v", got)
+	}
+}
+
+func TestStaticFallbackManager_LoadSnapshotMissing(t *testing.T) {
+	m, _ := newTestFallbackManager(t, nil)
+	if err := m.LoadSnapshot(); err != nil {
+		t.Errorf("expected a missing snapshot to be ignored, got %v", err)
+	}
+}
+
+func TestStaticFallbackManager_OverrideTakesPrecedence(t *testing.T) {
+	m, o := newTestFallbackManager(t, popularFixture)
+
+	if err := m.SetOverride("us", []models.SearchResult{{ID: "pinned"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := o.getStaticFallback("us"); len(got) != 1 || got[0].ID != "pinned" {
+		t.Errorf("expected override to survive refresh, got %v", got)
+	}
+	if !m.Regions()["us"].Overridden {
+		t.Error("expected region to be reported as overridden")
+	}
+
+	existed, err := m.RemoveOverride("us")
+	if err != nil || !existed {
+		t.Fatalf("expected override removal, got existed=%v err=%v", existed, err)
+	}
+	if got := o.getStaticFallback("us"); len(got) != 2 || got[0].ID != "us-1" {
+		t.Errorf("expected generated list after removing override, got %v", got)
+	}
+	if existed, _ := m.RemoveOverride("us"); existed {
+		t.Error("expected second removal to report no override")
+	}
+}
+
+func TestStaticFallbackManager_KeepsListsOnFailure(t *testing.T) {
+	m, o := newTestFallbackManager(t, popularFixture)
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, source := range map[string]PopularSource{
+		"error": func(ctx context.Context, perRegion int) (map[string][]models.SearchResult, error) {
+			return nil, errors.New("clickhouse down")
+		},
+		"empty": func(ctx context.Context, perRegion int) (map[string][]models.SearchResult, error) {
+			return map[string][]models.SearchResult{}, nil
+		},
+	} {
+		m.source = source
+		if err := m.Refresh(context.Background()); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if got := o.getStaticFallback("us"); len(got) != 2 {
+			t.Errorf("%s: expected previous list to be kept, got %v", name, got)
+		}
+	}
+}
+
+func TestTopAcrossRegions(t *testing.T) {
+	byRegion := map[string][]models.SearchResult{
+		"us":      {{ID: "a", PopularityScore: 3}, {ID: "shared", PopularityScore: 8}},
+		"eu":      {{ID: "shared", PopularityScore: 8}, {ID: "b", PopularityScore: 5}},
+		"default": {{ID: "old", PopularityScore: 100}},
+	}
+
+	got := topAcrossRegions(byRegion, 2)
+	if len(got) != 2 || got[0].ID != "shared" || got[1].ID != "b" {
+		t.Errorf("unexpected top list: %v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// Stream event names, in the order they are emitted.
+const (
+	StreamEventResults     = "results"
+	StreamEventFacets      = "facets"
+	StreamEventSuggestions = "suggestions"
+)
+
+// StreamEmitter delivers one stream event to the client. Returning an error
+// (e.g. the client went away) aborts the stream.
+type StreamEmitter func(event string, data any) error
+
+// StreamSuggestions is the payload of the suggestions event.
+type StreamSuggestions struct {
+	SpellCorrect string `json:"spell_correct,omitempty"`
+}
+
+// StreamSearch runs a search and delivers it progressively: ES hits as soon as
+// they are available, then ClickHouse facet counts, then spell suggestions.
+// The assembled response is cached exactly like a regular Search.
+func (o *Orchestrator) StreamSearch(ctx context.Context, req *models.SearchRequest, emit StreamEmitter) error {
+	start := time.Now()
+	ctx, span := observability.StartSpan(ctx, "orchestrator.stream_search",
+		attribute.String("query", req.Query),
+	)
+	defer span.End()
+
+	o.normalizePageSize(req)
+	parsed := o.parser.Parse(req.Query)
+	intent := o.classifier.Classify(parsed)
+
+	if !req.ForceFresh {
+		if cached := o.lookupCache(ctx, req, intent, start); cached != nil {
+			return emitResponse(cached, emit)
+		}
+	}
+
+	// Analytics results are facets only, so there is nothing to stream early.
+	if intent == models.IntentAnalytics {
+		resp, err := o.Search(ctx, req)
+		if err != nil {
+			return err
+		}
+		return emitResponse(resp, emit)
+	}
+
+	// Start facet counts first so they run while ES serves the hits.
+	var facetsCh chan facetsResult
+	if intent == models.IntentFaceted {
+		facetsCh = make(chan facetsResult, 1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					facetsCh <- facetsResult{err: fmt.Errorf("panic in CH facets: %v", r)}
+				}
+			}()
+			facetCtx, cancel := context.WithTimeout(ctx, o.cfg.QueryTimeout)
+			defer cancel()
+			facetsCh <- o.queryFacets(facetCtx, req)
+		}()
+	}
+
+	resp, err := o.streamPrimary(ctx, req, parsed)
+	if err != nil {
+		o.logger.Warn("primary search failed, trying fallback", zap.Error(err))
+		observability.FallbackCounter.WithLabelValues("primary_failed").Inc()
+		resp, err = o.degradedSearch(ctx, req, parsed, intent, err)
+	}
+	if err != nil {
+		o.recordFailure(intent, start)
+		return err
+	}
+
+	resp.Page = req.Page
+	resp.PageSize = req.PageSize
+	resp.Metadata.RequestID = req.RequestID
+	resp.Metadata.Intent = intent.String()
+	resp.TookMs = time.Since(start).Milliseconds()
+
+	// Emit a copy without facets; they follow in their own event.
+	hits := *resp
+	hits.Facets = nil
+	if err := emit(StreamEventResults, &hits); err != nil {
+		return err
+	}
+
+	if facetsCh != nil {
+		fr := <-facetsCh
+		if fr.err != nil {
+			o.logger.Warn("facet counts from clickhouse failed", zap.Error(fr.err))
+		} else {
+			resp.Facets = fr.facets
+			if resp.Source == "primary" {
+				resp.Source = "faceted"
+				resp.Metadata.Source = "elasticsearch+clickhouse"
+			}
+		}
+		if err := emit(StreamEventFacets, resp.Facets); err != nil {
+			return err
+		}
+	}
+
+	if err := emit(StreamEventSuggestions, StreamSuggestions{SpellCorrect: resp.Metadata.SpellCorrect}); err != nil {
+		return err
+	}
+
+	o.completeSearch(ctx, req, intent, resp, start)
+	return nil
+}
+
+func (o *Orchestrator) streamPrimary(ctx context.Context, req *models.SearchRequest, parsed *models.ParsedQuery) (*models.SearchResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.cfg.QueryTimeout)
+	defer cancel()
+	return o.fullTextSearch(ctx, o.esClient, req, parsed)
+}
+
+// emitResponse streams an already complete response as the standard event sequence.
+func emitResponse(resp *models.SearchResponse, emit StreamEmitter) error {
+	facets := resp.Facets
+	hits := *resp
+	hits.Facets = nil
+	if err := emit(StreamEventResults, &hits); err != nil {
+		return err
+	}
+	if len(facets) > 0 {
+		if err := emit(StreamEventFacets, facets); err != nil {
+			return err
+		}
+	}
+	return emit(StreamEventSuggestions, StreamSuggestions{SpellCorrect: resp.Metadata.SpellCorrect})
+}
@@ -0,0 +1,86 @@
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+type recordedEvent struct {
+	name string
+	data any
+}
+
+func TestEmitResponse_EventOrder(t *testing.T) {
+	resp := &models.SearchResponse{
+		Results: []models.SearchResult{{ID: "1"}},
+		Total:   1,
+		Facets: map[string][]models.Facet{
+			"brand": {{Value: "acme", Count: 4}},
+		},
+		Metadata: models.ResponseMetadata{SpellCorrect: "laptop"},
+	}
+
+	var events []recordedEvent
+	err := emitResponse(resp, func(event string, data any) error {
+		events = append(events, recordedEvent{event, data})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	want := []string{StreamEventResults, StreamEventFacets, StreamEventSuggestions}
+	for i, name := range want {
+		if events[i].name != name {
+			t.Errorf("event %d: expected %q, got %q", i, name, events[i].name)
+		}
+	}
+
+	hits := events[0].data.(*models.SearchResponse)
+	if hits.Facets != nil {
+		t.Error("results event should not carry facets")
+	}
+	if resp.Facets == nil {
+		t.Error("emitting should not strip facets from the original response")
+	}
+	if s := events[2].data.(StreamSuggestions); s.SpellCorrect != "laptop" {
+		t.Errorf("expected spell correction 'laptop', got %q", s.SpellCorrect)
+	}
+}
+
+func TestEmitResponse_SkipsEmptyFacets(t *testing.T) {
+	resp := &models.SearchResponse{Results: []models.SearchResult{{ID: "1"}}}
+
+	var names []string
+	emitResponse(resp, func(event string, data any) error {
+		names = append(names, event)
+		return nil
+	})
+
+	if len(names) != 2 || names[0] != StreamEventResults || names[1] != StreamEventSuggestions {
+		t.Errorf("expected results and suggestions only, got %v", names)
+	}
+}
+
+func TestEmitResponse_StopsOnEmitError(t *testing.T) {
+	resp := &models.SearchResponse{Results: []models.SearchResult{{ID: "1"}}}
+	emitErr := errors.New("client gone")
+
+	calls := 0
+	err := emitResponse(resp, func(event string, data any) error {
+		calls++
+		return emitErr
+	})
+
+	if !errors.Is(err, emitErr) {
+		t.Errorf("expected emit error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected stream to stop after first failed emit, got %d calls", calls)
+	}
+}
@@ -0,0 +1,72 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+// lookupCache serves req from cache. A fresh hit may schedule an early XFetch
+// refresh. When the fresh entry has expired, a stale copy no older than
+// MaxStale is served while a background refresh replaces it. A nil result
+// means the caller must search.
+func (o *Orchestrator) lookupCache(ctx context.Context, req *models.SearchRequest, intent models.Intent, start time.Time) *models.SearchResponse {
+	if cached := o.cachedResponse(ctx, req, intent, start); cached != nil {
+		return cached
+	}
+	if !o.cfg.StaleWhileRevalidate.Enabled {
+		return nil
+	}
+
+	stale, err := o.cache.GetStaleResults(ctx, req)
+	if err != nil {
+		o.logger.Warn("stale cache lookup error", zap.Error(err))
+		return nil
+	}
+	if stale == nil || stale.Metadata.CachedAt == nil {
+		return nil
+	}
+	age := time.Since(*stale.Metadata.CachedAt)
+	if age > o.cfg.StaleWhileRevalidate.MaxStale {
+		return nil
+	}
+
+	o.revalidate(ctx, req, intent, "stale")
+
+	stale.Metadata.CacheHit = true
+	stale.Metadata.Stale = true
+	stale.Metadata.AgeMs = age.Milliseconds()
+	stale.TookMs = time.Since(start).Milliseconds()
+	observability.SearchRequestsTotal.WithLabelValues(intent.String(), "stale_hit").Inc()
+	return stale
+}
+
+// revalidate refreshes req's cache entry in the background. Refreshes share
+// the coalescing flight for the key, so a key is refreshed at most once at a
+// time per pod however many readers trigger it.
+func (o *Orchestrator) revalidate(ctx context.Context, req *models.SearchRequest, intent models.Intent, trigger string) {
+	key := o.cache.SearchKey(req)
+	bg := *req
+	bgCtx := context.WithoutCancel(ctx)
+
+	// The result channel is buffered, so nobody needs to wait on it.
+	o.flight.DoChan(key, func() (any, error) {
+		observability.CacheRevalidations.WithLabelValues(trigger).Inc()
+		flightCtx, cancel := context.WithTimeout(bgCtx, o.flightTimeout(intent))
+		defer cancel()
+
+		resp, err := o.refreshSearch(flightCtx, key, &bg, o.parser.Parse(bg.Query), intent, time.Now())
+		if err != nil {
+			o.logger.Warn("background cache refresh failed",
+				zap.String("query", bg.Query),
+				zap.String("trigger", trigger),
+				zap.Error(err),
+			)
+		}
+		return resp, err
+	})
+}
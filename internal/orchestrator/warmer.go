@@ -0,0 +1,298 @@
+package orchestrator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/clickhouse"
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+	"github.com/shubhsaxena/high-scale-search/internal/observability"
+)
+
+var (
+	// ErrWarmInProgress is returned when a warming run is requested while
+	// another is still running.
+	ErrWarmInProgress = errors.New("cache warming already in progress")
+	// ErrNoWarmSource is returned when neither a seed file nor ClickHouse is
+	// available to supply popular queries.
+	ErrNoWarmSource = errors.New("no cache warming source available")
+)
+
+type warmRequestKey struct{}
+
+// isWarmRequest reports whether ctx belongs to a search replayed by the
+// cache warmer, which must not count towards query popularity.
+func isWarmRequest(ctx context.Context) bool {
+	warm, _ := ctx.Value(warmRequestKey{}).(bool)
+	return warm
+}
+
+// QuerySource supplies the searches a warming run replays.
+type QuerySource interface {
+	Name() string
+	Queries(ctx context.Context) ([]models.SearchRequest, error)
+}
+
+// NewQuerySource returns the seed file source when cfg.SeedFile is set and
+// the ClickHouse query log source otherwise.
+func NewQuerySource(cfg config.CacheWarmerConfig, chClient *clickhouse.Client) (QuerySource, error) {
+	if cfg.SeedFile != "" {
+		return &seedFileSource{path: cfg.SeedFile, perRegion: cfg.TopN}, nil
+	}
+	if chClient != nil {
+		return &clickHouseSource{client: chClient, perRegion: cfg.TopN, window: cfg.Window}, nil
+	}
+	return nil, ErrNoWarmSource
+}
+
+type clickHouseSource struct {
+	client    *clickhouse.Client
+	perRegion int
+	window    time.Duration
+}
+
+func (s *clickHouseSource) Name() string { return "clickhouse" }
+
+func (s *clickHouseSource) Queries(ctx context.Context) ([]models.SearchRequest, error) {
+	return s.client.TopQueries(ctx, s.perRegion, s.window)
+}
+
+// seedFileSource reads search requests, one JSON object per line, in
+// priority order. At most perRegion requests are taken for each region.
+type seedFileSource struct {
+	path      string
+	perRegion int
+}
+
+func (s *seedFileSource) Name() string { return "seed_file" }
+
+func (s *seedFileSource) Queries(ctx context.Context) ([]models.SearchRequest, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("opening seed file: %w", err)
+	}
+	defer f.Close()
+
+	perRegion := make(map[string]int)
+	var queries []models.SearchRequest
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var req models.SearchRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return nil, fmt.Errorf("seed file line %d: %w", line, err)
+		}
+		if req.Query == "" || perRegion[req.Region] >= s.perRegion {
+			continue
+		}
+		perRegion[req.Region]++
+		queries = append(queries, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading seed file: %w", err)
+	}
+	return queries, nil
+}
+
+// WarmStatus is the progress of the current or most recent warming run.
+type WarmStatus struct {
+	Running    bool       `json:"running"`
+	Trigger    string     `json:"trigger,omitempty"`
+	Source     string     `json:"source,omitempty"`
+	Total      int        `json:"total"`
+	Completed  int        `json:"completed"`
+	Warmed     int        `json:"warmed"`
+	Cached     int        `json:"cached"`
+	Failed     int        `json:"failed"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// CacheWarmer replays popular searches through the orchestrator so a cold
+// cache (after a deploy or Redis failover) is filled before user traffic has
+// to miss on it. Only one run is active at a time.
+type CacheWarmer struct {
+	search func(ctx context.Context, req *models.SearchRequest) (*models.SearchResponse, error)
+	health func(ctx context.Context) error
+	source QuerySource
+	cfg    config.CacheWarmerConfig
+	logger *zap.Logger
+
+	mu     sync.Mutex
+	status WarmStatus
+}
+
+func NewCacheWarmer(orch *Orchestrator, source QuerySource, cfg config.CacheWarmerConfig, logger *zap.Logger) *CacheWarmer {
+	return &CacheWarmer{
+		search: orch.Search,
+		health: orch.cache.HealthCheck,
+		source: source,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Start begins a warming run in the background and returns immediately. The
+// run stops early if ctx is cancelled.
+func (w *CacheWarmer) Start(ctx context.Context, trigger string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status.Running {
+		return ErrWarmInProgress
+	}
+	now := time.Now().UTC()
+	w.status = WarmStatus{
+		Running:   true,
+		Trigger:   trigger,
+		Source:    w.source.Name(),
+		StartedAt: &now,
+	}
+	observability.CacheWarmRunning.Set(1)
+
+	go w.run(ctx)
+	return nil
+}
+
+// Status returns a snapshot of the current or most recent run.
+func (w *CacheWarmer) Status() WarmStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *CacheWarmer) run(ctx context.Context) {
+	queries, err := w.source.Queries(ctx)
+	if err != nil {
+		w.logger.Warn("cache warming source failed", zap.String("source", w.source.Name()), zap.Error(err))
+		w.finish(err)
+		return
+	}
+
+	w.mu.Lock()
+	w.status.Total = len(queries)
+	w.mu.Unlock()
+	w.logger.Info("cache warming started",
+		zap.String("source", w.source.Name()),
+		zap.Int("queries", len(queries)),
+	)
+
+	warmCtx := context.WithValue(ctx, warmRequestKey{}, true)
+	sem := make(chan struct{}, w.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := range queries {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(req *models.SearchRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			w.warm(warmCtx, req)
+		}(&queries[i])
+	}
+	wg.Wait()
+
+	w.finish(ctx.Err())
+}
+
+func (w *CacheWarmer) warm(ctx context.Context, req *models.SearchRequest) {
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.QueryTimeout)
+	defer cancel()
+
+	req.RequestID = "cache-warmer"
+	resp, err := w.search(ctx, req)
+
+	status := "warmed"
+	switch {
+	case err != nil:
+		status = "error"
+		w.logger.Debug("cache warming query failed", zap.String("query", req.Query), zap.Error(err))
+	case resp.Metadata.CacheHit:
+		status = "cached"
+	}
+	observability.CacheWarmQueries.WithLabelValues(status).Inc()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.status.Completed++
+	switch status {
+	case "warmed":
+		w.status.Warmed++
+	case "cached":
+		w.status.Cached++
+	default:
+		w.status.Failed++
+		w.status.LastError = err.Error()
+	}
+}
+
+func (w *CacheWarmer) finish(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := time.Now().UTC()
+	w.status.Running = false
+	w.status.FinishedAt = &now
+	if err != nil {
+		w.status.LastError = err.Error()
+	}
+	observability.CacheWarmRunning.Set(0)
+	observability.CacheWarmLastCompleted.Set(float64(now.Unix()))
+
+	w.logger.Info("cache warming finished",
+		zap.Int("total", w.status.Total),
+		zap.Int("warmed", w.status.Warmed),
+		zap.Int("cached", w.status.Cached),
+		zap.Int("failed", w.status.Failed),
+		zap.Error(err),
+	)
+}
+
+// WatchCache probes Redis every interval and starts a warming run whenever it
+// becomes reachable again after failing, as a recovered or failed-over Redis
+// may have come back empty. It returns when ctx is cancelled.
+func (w *CacheWarmer) WatchCache(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	healthy := true
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		err := w.health(checkCtx)
+		cancel()
+
+		switch {
+		case err != nil:
+			healthy = false
+		case !healthy:
+			healthy = true
+			w.logger.Info("redis recovered, warming cache")
+			if err := w.Start(ctx, "recovery"); err != nil {
+				w.logger.Info("cache warming not started", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/shubhsaxena/high-scale-search/internal/config"
+	"github.com/shubhsaxena/high-scale-search/internal/models"
+)
+
+type staticSource struct {
+	queries []models.SearchRequest
+}
+
+func (s *staticSource) Name() string { return "static" }
+
+func (s *staticSource) Queries(ctx context.Context) ([]models.SearchRequest, error) {
+	return s.queries, nil
+}
+
+func newTestWarmer(source QuerySource, search func(context.Context, *models.SearchRequest) (*models.SearchResponse, error)) *CacheWarmer {
+	return &CacheWarmer{
+		search: search,
+		source: source,
+		cfg:    config.CacheWarmerConfig{Concurrency: 2, QueryTimeout: time.Second},
+		logger: zap.NewNop(),
+	}
+}
+
+func waitForWarm(t *testing.T, w *CacheWarmer) WarmStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if s := w.Status(); !s.Running {
+			return s
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("warming run did not finish")
+	return WarmStatus{}
+}
+
+func TestSeedFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.jsonl")
+	seed := `{"query":"laptop","region":"us"}
+{"query":"phone","region":"us"}
+
+{"query":"tablet","region":"us"}
+{"query":"","region":"eu"}
+{"query":"laptop","region":"eu","filters":{"category":"electronics"}}
+`
+	if err := os.WriteFile(path, []byte(seed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &seedFileSource{path: path, perRegion: 2}
+	queries, err := src.Queries(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"us:laptop", "us:phone", "eu:laptop"}
+	if len(queries) != len(want) {
+		t.Fatalf("expected %d queries, got %d", len(want), len(queries))
+	}
+	for i, q := range queries {
+		if got := q.Region + ":" + q.Query; got != want[i] {
+			t.Errorf("query %d: expected %s, got %s", i, want[i], got)
+		}
+	}
+	if queries[2].Filters["category"] != "electronics" {
+		t.Errorf("expected filters to be preserved, got %v", queries[2].Filters)
+	}
+}
+
+func TestSeedFileSource_InvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.jsonl")
+	if err := os.WriteFile(path, []byte("{\"query\":\"laptop\"}\nnot json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &seedFileSource{path: path, perRegion: 10}
+	if _, err := src.Queries(context.Background()); err == nil {
+		t.Error("expected error for invalid seed line")
+	}
+}
+
+func TestNewQuerySource_NoSource(t *testing.T) {
+	if _, err := NewQuerySource(config.CacheWarmerConfig{TopN: 10}, nil); !errors.Is(err, ErrNoWarmSource) {
+		t.Errorf("expected ErrNoWarmSource, got %v", err)
+	}
+}
+
+func TestCacheWarmer_Run(t *testing.T) {
+	source := &staticSource{queries: []models.SearchRequest{
+		{Query: "laptop"}, {Query: "phone"}, {Query: "tablet"}, {Query: "broken"},
+	}}
+
+	var inFlight, maxInFlight int32
+	search := func(ctx context.Context, req *models.SearchRequest) (*models.SearchResponse, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		if !isWarmRequest(ctx) {
+			t.Error("expected warm request context")
+		}
+		switch req.Query {
+		case "broken":
+			return nil, errors.New("search failed")
+		case "phone":
+			return &models.SearchResponse{Metadata: models.ResponseMetadata{CacheHit: true}}, nil
+		}
+		return &models.SearchResponse{}, nil
+	}
+
+	w := newTestWarmer(source, search)
+	if err := w.Start(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status := waitForWarm(t, w)
+
+	if status.Total != 4 || status.Completed != 4 {
+		t.Errorf("expected 4 of 4 completed, got %d of %d", status.Completed, status.Total)
+	}
+	if status.Warmed != 2 || status.Cached != 1 || status.Failed != 1 {
+		t.Errorf("unexpected counts: %+v", status)
+	}
+	if status.LastError == "" || status.FinishedAt == nil || status.Trigger != "test" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent searches, got %d", maxInFlight)
+	}
+}
+
+func TestCacheWarmer_RejectsConcurrentRuns(t *testing.T) {
+	release := make(chan struct{})
+	search := func(ctx context.Context, req *models.SearchRequest) (*models.SearchResponse, error) {
+		<-release
+		return &models.SearchResponse{}, nil
+	}
+	w := newTestWarmer(&staticSource{queries: []models.SearchRequest{{Query: "laptop"}}}, search)
+
+	if err := w.Start(context.Background(), "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Start(context.Background(), "test"); !errors.Is(err, ErrWarmInProgress) {
+		t.Errorf("expected ErrWarmInProgress, got %v", err)
+	}
+	close(release)
+	waitForWarm(t, w)
+
+	if err := w.Start(context.Background(), "test"); err != nil {
+		t.Errorf("expected a new run to start after the first finished, got %v", err)
+	}
+	waitForWarm(t, w)
+}